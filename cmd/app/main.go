@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // база часовых поясов для образа без системной tzdata
 
 	"github.com/vandermeer0/pr-reviewer/internal/config"
 	"github.com/vandermeer0/pr-reviewer/internal/infrastructure/repository/postgresql"
@@ -18,7 +19,10 @@ import (
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -32,14 +36,56 @@ func main() {
 	userRepo := postgresql.NewUserRepository(pool)
 	teamRepo := postgresql.NewTeamRepository(pool)
 	prRepo := postgresql.NewPullRequestRepository(pool)
+	ownershipRepo := postgresql.NewOwnershipRepository(pool)
+	codeOwnersRepo := postgresql.NewCodeOwnersRepository(pool)
+	conflictRepo := postgresql.NewConflictRepository(pool)
+	rotationRepo := postgresql.NewRotationRepository(pool)
+	reviewRulesRepo := postgresql.NewReviewRulesRepository(pool)
+
+	selectors, err := usecase.NewReviewerSelectors(
+		cfg.Assignment.DefaultStrategy,
+		cfg.Assignment.TeamStrategies,
+		usecase.WithPersistentRotation(rotationRepo),
+	)
+	if err != nil {
+		log.Fatalf("invalid reviewer assignment config: %v", err)
+	}
+
+	assignment := []usecase.AssignmentOption{
+		usecase.WithReviewerSelectors(selectors),
+		usecase.WithPairHistoryWindow(cfg.Assignment.PairHistoryWindow),
+		usecase.WithOwnershipRules(ownershipRepo),
+		usecase.WithCodeOwners(codeOwnersRepo),
+		usecase.WithConflicts(conflictRepo),
+		usecase.WithReviewRules(reviewRulesRepo),
+	}
 
 	teamSvc := usecase.NewTeamService(userRepo, teamRepo)
-	userSvc := usecase.NewUserService(userRepo)
-	prSvc := usecase.NewPullRequestService(prRepo, userRepo, teamRepo)
+	userSvc := usecase.NewUserService(userRepo, assignment...)
+	prSvc := usecase.NewPullRequestService(prRepo, userRepo, teamRepo, assignment...)
+	ownershipSvc := usecase.NewOwnershipService(ownershipRepo, userRepo, teamRepo)
+	codeOwnersSvc := usecase.NewCodeOwnersService(codeOwnersRepo, userRepo, teamRepo)
+	conflictSvc := usecase.NewConflictService(conflictRepo, userRepo)
+	reviewRulesSvc := usecase.NewReviewRulesService(reviewRulesRepo, userRepo, teamRepo)
 	statsSvc := usecase.NewStatsService(pool)
-	teamMaintSvc := usecase.NewTeamMaintenanceService(pool)
+	teamMaintSvc := usecase.NewTeamMaintenanceService(pool, assignment...)
+
+	if path := cfg.Assignment.CodeOwnersPath; path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("failed to read CODEOWNERS: %v", err)
+		}
+		res, err := codeOwnersSvc.Import(ctx, string(content))
+		if err != nil {
+			log.Fatalf("failed to import CODEOWNERS: %v", err)
+		}
+		log.Printf("imported %d CODEOWNERS rules from %s", len(res.Rules), path)
+		if len(res.UnknownOwners) > 0 {
+			log.Printf("CODEOWNERS owners not found: %v", res.UnknownOwners)
+		}
+	}
 
-	apiServer := httpapi.NewServer(teamSvc, userSvc, prSvc, statsSvc, teamMaintSvc)
+	apiServer := httpapi.NewServer(teamSvc, userSvc, prSvc, statsSvc, teamMaintSvc, ownershipSvc, codeOwnersSvc, conflictSvc, reviewRulesSvc)
 	mux := http.NewServeMux()
 	apiServer.RegisterRoutes(mux)
 
@@ -1,56 +1,110 @@
-// Package config отвечает за загрузку конфигурации приложения
-package config
-
-import (
-	"fmt"
-	"os"
-)
-
-// Config содержит конфигурацию всего приложения
-type Config struct {
-	DB DBConfig
-}
-
-// DBConfig содержит параметры подключения к базе данных PostgreSQL
-type DBConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
-	SSLMode  string
-}
-
-// Load загружает конфигурацию из переменных окружения
-func Load() *Config {
-	return &Config{
-		DB: DBConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			Name:     getEnv("DB_NAME", "pr_reviewer"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
-		},
-	}
-}
-
-// ConnString возвращает строку подключения к PostgreSQL
-func (c DBConfig) ConnString() string {
-	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		c.User,
-		c.Password,
-		c.Host,
-		c.Port,
-		c.Name,
-		c.SSLMode,
-	)
-}
-
-func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return def
-}
+// Package config отвечает за загрузку конфигурации приложения
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config содержит конфигурацию всего приложения
+type Config struct {
+	DB         DBConfig
+	Assignment AssignmentConfig
+}
+
+// DBConfig содержит параметры подключения к базе данных PostgreSQL
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+// AssignmentConfig содержит параметры назначения ревьюверов
+type AssignmentConfig struct {
+	// DefaultStrategy - стратегия выбора ревьюверов по умолчанию
+	DefaultStrategy string
+	// TeamStrategies - стратегии отдельных команд, team_name -> стратегия
+	TeamStrategies map[string]string
+	// PairHistoryWindow - окно истории пар автор/ревьювер для стратегии pair_diversity
+	PairHistoryWindow time.Duration
+	// CodeOwnersPath - файл CODEOWNERS, импортируемый при старте, пусто - не импортировать
+	CodeOwnersPath string
+}
+
+// Load загружает конфигурацию из переменных окружения
+func Load() (*Config, error) {
+	pairWindow, err := getDurationEnv("REVIEWER_PAIR_WINDOW", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		DB: DBConfig{
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     getEnv("DB_PORT", "5432"),
+			User:     getEnv("DB_USER", "postgres"),
+			Password: getEnv("DB_PASSWORD", "postgres"),
+			Name:     getEnv("DB_NAME", "pr_reviewer"),
+			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+		},
+		Assignment: AssignmentConfig{
+			DefaultStrategy:   getEnv("REVIEWER_STRATEGY", "random"),
+			TeamStrategies:    parseKeyValues(os.Getenv("REVIEWER_TEAM_STRATEGIES")),
+			PairHistoryWindow: pairWindow,
+			CodeOwnersPath:    os.Getenv("CODEOWNERS_PATH"),
+		},
+	}, nil
+}
+
+// ConnString возвращает строку подключения к PostgreSQL
+func (c DBConfig) ConnString() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+		c.User,
+		c.Password,
+		c.Host,
+		c.Port,
+		c.Name,
+		c.SSLMode,
+	)
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getDurationEnv читает длительность вида "720h", пустое значение - значение по умолчанию
+func getDurationEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return d, nil
+}
+
+// parseKeyValues разбирает строку вида "a=x,b=y"
+func parseKeyValues(raw string) map[string]string {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
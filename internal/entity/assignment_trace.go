@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+// TraceAction - операция, в которой подбирались ревьюверы
+type TraceAction string
+
+const (
+	// TraceActionCreate - назначение при создании PR
+	TraceActionCreate TraceAction = "create"
+	// TraceActionReady - назначение когда черновик отмечен готовым к ревью
+	TraceActionReady TraceAction = "ready_for_review"
+	// TraceActionReassign - замена ревьювера
+	TraceActionReassign TraceAction = "reassign"
+	// TraceActionTopUp - добор ревьюверов после деактивации
+	TraceActionTopUp TraceAction = "top_up"
+	// TraceActionAdd - ревьювер добавлен вручную
+	TraceActionAdd TraceAction = "add"
+	// TraceActionRemove - ревьювер снят вручную
+	TraceActionRemove TraceAction = "remove"
+)
+
+// AssignmentTrace - объяснение одного назначения ревьюверов
+type AssignmentTrace struct {
+	ID            int64
+	PullRequestID string
+	Action        TraceAction
+	// ReplacedReviewerID - кого заменяли или сняли, для reassign и remove
+	ReplacedReviewerID string
+	// Reviewers - кто назначен в итоге этой операции
+	Reviewers []string
+	Steps     []AssignmentTraceStep
+	CreatedAt time.Time
+}
+
+// AssignmentTraceStep - один подбор из группы кандидатов
+// Теги задают формат хранения шагов в JSONB
+type AssignmentTraceStep struct {
+	// Stage - зачем подбирали: requested, code_owners, rule, seniority, ownership, team, fallback, reassign, handoff, top_up, rebalance, shadow
+	Stage string `json:"stage"`
+	// Scope - уточнение этапа: правило CODEOWNERS или команды, уровень опыта или запасная команда
+	Scope      string           `json:"scope,omitempty"`
+	TeamName   string           `json:"team_name"`
+	Strategy   string           `json:"strategy,omitempty"`
+	Seeds      []int64          `json:"seeds,omitempty"`
+	Candidates []TraceCandidate `json:"candidates"`
+	Excluded   []TraceExclusion `json:"excluded"`
+	Selected   []string         `json:"selected"`
+}
+
+// TraceCandidate - кандидат, прошедший фильтры
+type TraceCandidate struct {
+	UserID       string `json:"user_id"`
+	OpenReviews  int    `json:"open_reviews"`
+	WorkingHours bool   `json:"working_hours"`
+	PairReviews  int    `json:"pair_reviews,omitempty"`
+}
+
+// TraceExclusion - отсеянный пользователь и причина
+type TraceExclusion struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
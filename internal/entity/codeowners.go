@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// CodeOwnersRule - строка файла CODEOWNERS: шаблон пути и его владельцы
+type CodeOwnersRule struct {
+	// Line - номер строки в файле, определяет приоритет правила
+	Line    int
+	Pattern string
+	// Owners - владельцы как в файле: @user, @org/team или email
+	Owners []string
+}
+
+// CodeOwner - владелец из CODEOWNERS, заполнено ровно одно поле
+type CodeOwner struct {
+	Username string
+	TeamName string
+}
+
+// RequiredReviewer - ревьювер, обязательный по правилу CODEOWNERS
+type RequiredReviewer struct {
+	UserID  string
+	Pattern string
+}
+
+// ParseCodeOwners разбирает файл CODEOWNERS в формате GitHub
+func ParseCodeOwners(content string) ([]*CodeOwnersRule, error) {
+	var rules []*CodeOwnersRule
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, "[]") {
+			return nil, fmt.Errorf("line %d: unsupported pattern %q", line, pattern)
+		}
+		if _, err := globToRegexp(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", line, pattern, err)
+		}
+
+		rule := &CodeOwnersRule{Line: line, Pattern: pattern}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			if !strings.Contains(owner, "@") {
+				return nil, fmt.Errorf("line %d: invalid owner %q", line, owner)
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Matches сообщает подпадает ли путь под шаблон по правилам CODEOWNERS
+// Шаблон без `/` в середине совпадает на любой глубине, шаблон
+// каталога совпадает со всем его содержимым, кроме вида `dir/*`
+func (r *CodeOwnersRule) Matches(path string) bool {
+	p := r.Pattern
+	if !strings.HasPrefix(p, "/") && !strings.Contains(strings.TrimSuffix(p, "/"), "/") {
+		p = "**/" + p
+	}
+	if MatchGlob(p, path) {
+		return true
+	}
+	if strings.HasSuffix(p, "/") || strings.HasSuffix(p, "/*") || p == "**/*" {
+		return false
+	}
+	return MatchGlob(p+"/**", path)
+}
+
+// ParsedOwners возвращает владельцев правила, email-адреса пропускаются
+func (r *CodeOwnersRule) ParsedOwners() []CodeOwner {
+	var out []CodeOwner
+	for _, o := range r.Owners {
+		if owner, ok := ParseCodeOwner(o); ok {
+			out = append(out, owner)
+		}
+	}
+	return out
+}
+
+// ParseCodeOwner разбирает @user или @org/team, для email возвращает false
+func ParseCodeOwner(raw string) (CodeOwner, bool) {
+	handle, ok := strings.CutPrefix(raw, "@")
+	if !ok || handle == "" {
+		return CodeOwner{}, false
+	}
+	if _, team, ok := strings.Cut(handle, "/"); ok {
+		return CodeOwner{TeamName: team}, true
+	}
+	return CodeOwner{Username: handle}, true
+}
+
+// MatchCodeOwners возвращает последнее подходящее под путь правило или nil
+func MatchCodeOwners(rules []*CodeOwnersRule, path string) *CodeOwnersRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Matches(path) {
+			return rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReviewConflict - пара пользователей, которые не должны ревьюить PR друг друга
+// Пара симметрична: порядок UserID и OtherUserID не важен
+type ReviewConflict struct {
+	ID          int64
+	UserID      string
+	OtherUserID string
+	// Reason - пояснение администратора, например "руководитель и подчинённый"
+	Reason string
+}
+
+// Validate проверяет что в паре два разных пользователя
+func (c *ReviewConflict) Validate() error {
+	if strings.TrimSpace(c.UserID) == "" || strings.TrimSpace(c.OtherUserID) == "" {
+		return fmt.Errorf("user_id and other_user_id are required")
+	}
+	if c.UserID == c.OtherUserID {
+		return fmt.Errorf("user cannot conflict with themselves")
+	}
+	return nil
+}
+
+// Involves сообщает входит ли пользователь в пару
+func (c *ReviewConflict) Involves(userID string) bool {
+	return c.UserID == userID || c.OtherUserID == userID
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// OutOfOffice - период когда пользователь недоступен для ревью
+type OutOfOffice struct {
+	ID       int64
+	UserID   string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Reason   string
+}
+
+// Covers сообщает попадает ли момент t в период отсутствия
+func (o *OutOfOffice) Covers(t time.Time) bool {
+	return !t.Before(o.StartsAt) && t.Before(o.EndsAt)
+}
+
+// Validate проверяет корректность периода
+func (o *OutOfOffice) Validate() error {
+	if o.UserID == "" {
+		return fmt.Errorf("user id is empty")
+	}
+	if o.StartsAt.IsZero() || o.EndsAt.IsZero() {
+		return fmt.Errorf("starts_at and ends_at are required")
+	}
+	if !o.EndsAt.After(o.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
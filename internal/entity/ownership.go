@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OwnershipRule - glob путей и их владелец: пользователь или команда
+type OwnershipRule struct {
+	ID       int64
+	Pattern  string
+	UserID   string
+	TeamName string
+}
+
+// Validate проверяет что у правила есть шаблон и ровно один владелец
+func (r *OwnershipRule) Validate() error {
+	if strings.TrimSpace(r.Pattern) == "" {
+		return fmt.Errorf("pattern is empty")
+	}
+	if (r.UserID == "") == (r.TeamName == "") {
+		return fmt.Errorf("exactly one of user_id and team_name is required")
+	}
+	if _, err := globToRegexp(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	return nil
+}
+
+// Matches сообщает подпадает ли путь под шаблон правила
+func (r *OwnershipRule) Matches(path string) bool {
+	return MatchGlob(r.Pattern, path)
+}
+
+// MatchGlob сопоставляет путь от корня репозитория с glob-шаблоном
+// `*` и `?` не пересекают `/`, `**` совпадает с любым числом каталогов,
+// шаблон с `/` на конце совпадает со всем содержимым каталога
+func MatchGlob(pattern, path string) bool {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(p, "/") {
+		p += "**"
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '*' && strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
@@ -1,34 +1,149 @@
-package entity
-
-import "time"
-
-// PRStatus - строгий тип для статуса PR
-type PRStatus string
-
-const (
-	// StatusOpen - PR открыт и ждёт ревью
-	StatusOpen PRStatus = "OPEN"
-	// StatusMerged - PR влит изменения запрещены
-	StatusMerged PRStatus = "MERGED"
-)
-
-// PullRequest - основная сущность задачи
-type PullRequest struct {
-	ID        string
-	Name      string
-	AuthorID  string
-	Status    PRStatus
-	Reviewers []string
-	CreatedAt time.Time
-	MergedAt  *time.Time
-}
-
-// CanBeMerged - мержить можно только открытый PR
-func (pr *PullRequest) CanBeMerged() bool {
-	return pr.Status == StatusOpen
-}
-
-// CanReassignReviewers - менять ревьюверов можно только пока PR открыт
-func (pr *PullRequest) CanReassignReviewers() bool {
-	return pr.Status == StatusOpen
-}
+package entity
+
+import "time"
+
+// PRStatus - строгий тип для статуса PR
+type PRStatus string
+
+const (
+	// StatusOpen - PR открыт и ждёт ревью
+	StatusOpen PRStatus = "OPEN"
+	// StatusMerged - PR влит изменения запрещены
+	StatusMerged PRStatus = "MERGED"
+	// StatusClosed - PR закрыт без слияния, его можно переоткрыть
+	StatusClosed PRStatus = "CLOSED"
+	// StatusDraft - черновик, ревьюверы назначаются когда автор отметит PR готовым к ревью
+	StatusDraft PRStatus = "DRAFT"
+)
+
+// PullRequest - основная сущность задачи
+type PullRequest struct {
+	ID        string
+	Name      string
+	AuthorID  string
+	Status    PRStatus
+	Reviewers []string
+	// RequiredReviewers - ревьюверы из Reviewers, обязательные по CODEOWNERS
+	RequiredReviewers []RequiredReviewer
+	// FallbackReviewers - ревьюверы из Reviewers, взятые из запасных команд
+	FallbackReviewers []string
+	// RuleReviewers - ревьюверы из Reviewers, добавленные правилами команды сверх TargetReviewers
+	RuleReviewers []string
+	// ShadowReviewers - стажёры, которые наблюдают за ревью, в Reviewers и TargetReviewers не входят
+	ShadowReviewers []string
+	// Draft - атрибуты подбора, переданные при создании черновика, очищаются в readyForReview
+	Draft DraftAttributes
+	// TargetReviewers - сколько ревьюверов команды должно было быть назначено при создании,
+	// добавленные правилами в это число не входят
+	TargetReviewers int
+	CreatedAt       time.Time
+	MergedAt        *time.Time
+	ClosedAt        *time.Time
+}
+
+// DraftAttributes - атрибуты черновика, по которым ревьюверы подбираются в readyForReview
+type DraftAttributes struct {
+	ChangedFiles       []string
+	RequestedReviewers []string
+	Labels             []string
+}
+
+// ReviewPairHistory - как часто и как недавно ревьювер смотрел PR автора
+type ReviewPairHistory struct {
+	// Reviews - число PR автора с этим ревьювером за окно истории
+	Reviews int
+	// LastAt - время создания последнего такого PR
+	LastAt time.Time
+}
+
+// CanBeMerged - мержить можно только открытый PR
+func (pr *PullRequest) CanBeMerged() bool {
+	return pr.Status == StatusOpen
+}
+
+// CanBeReopened - переоткрыть можно только закрытый PR
+func (pr *PullRequest) CanBeReopened() bool {
+	return pr.Status == StatusClosed
+}
+
+// IsDraft - PR ещё черновик и ревьюверов не имеет
+func (pr *PullRequest) IsDraft() bool {
+	return pr.Status == StatusDraft
+}
+
+// IsClosedDraft - закрытый черновик: ревьюверов ему не подбирали, поэтому их нет и TargetReviewers 0,
+// у PR, прошедших подбор, он не меньше 1
+func (pr *PullRequest) IsClosedDraft() bool {
+	return pr.Status == StatusClosed && pr.TargetReviewers == 0 && len(pr.Reviewers) == 0
+}
+
+// CanReassignReviewers - менять ревьюверов можно только пока PR открыт
+func (pr *PullRequest) CanReassignReviewers() bool {
+	return pr.Status == StatusOpen
+}
+
+// IsUnderstaffed - назначено меньше ревьюверов команды чем требовалось
+func (pr *PullRequest) IsUnderstaffed() bool {
+	return pr.TeamReviewerCount() < pr.TargetReviewers
+}
+
+// TeamReviewerCount - число ревьюверов без добавленных правилами
+func (pr *PullRequest) TeamReviewerCount() int {
+	n := 0
+	for _, id := range pr.Reviewers {
+		if !pr.IsRuleReviewer(id) {
+			n++
+		}
+	}
+	return n
+}
+
+// HasReviewer сообщает назначен ли пользователь ревьювером
+func (pr *PullRequest) HasReviewer(userID string) bool {
+	for _, id := range pr.Reviewers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasShadowReviewer сообщает назначен ли пользователь теневым ревьювером
+func (pr *PullRequest) HasShadowReviewer(userID string) bool {
+	for _, id := range pr.ShadowReviewers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredPattern возвращает шаблон CODEOWNERS, по которому ревьювер обязателен
+func (pr *PullRequest) RequiredPattern(userID string) (string, bool) {
+	for _, r := range pr.RequiredReviewers {
+		if r.UserID == userID {
+			return r.Pattern, true
+		}
+	}
+	return "", false
+}
+
+// IsRuleReviewer сообщает добавлен ли ревьювер правилом команды
+func (pr *PullRequest) IsRuleReviewer(userID string) bool {
+	for _, id := range pr.RuleReviewers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFallbackReviewer сообщает взят ли ревьювер из запасной команды
+func (pr *PullRequest) IsFallbackReviewer(userID string) bool {
+	for _, id := range pr.FallbackReviewers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
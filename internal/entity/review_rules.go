@@ -0,0 +1,304 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TeamReviewRules - правила подбора ревьюверов команды в исходном YAML и разобранные
+type TeamReviewRules struct {
+	TeamName string
+	Content  string
+	Rules    []*ReviewRule
+}
+
+// ReviewRule - правило команды: условия на атрибуты PR и что меняется при их выполнении
+type ReviewRule struct {
+	// Line - строка начала правила в YAML
+	Line int                 `yaml:"-"`
+	Name string              `yaml:"name"`
+	When ReviewRuleCondition `yaml:"when"`
+	Then ReviewRuleAction    `yaml:"then"`
+}
+
+// ReviewRuleCondition - условия правила, срабатывает если выполнены все заданные
+type ReviewRuleCondition struct {
+	// Label - у PR есть метка, без учёта регистра
+	Label string `yaml:"label"`
+	// NamePrefix - название PR начинается с префикса, без учёта регистра
+	NamePrefix string `yaml:"name_prefix"`
+	// AuthorSeniority - уровень опыта автора
+	AuthorSeniority Seniority `yaml:"author_seniority"`
+	// ChangedPath - glob, под который подпадает хотя бы один изменённый путь
+	ChangedPath string `yaml:"changed_path"`
+}
+
+// ReviewRuleAction - изменения подбора, заданные правилом
+type ReviewRuleAction struct {
+	// Reviewers - сколько ревьюверов назначить вместо min_reviewers и max_reviewers команды
+	Reviewers *int `yaml:"reviewers"`
+	// MinSeniorReviewers, MinJuniorReviewers - минимум ревьюверов уровня, если он выше чем у команды
+	MinSeniorReviewers int `yaml:"min_senior_reviewers"`
+	MinJuniorReviewers int `yaml:"min_junior_reviewers"`
+	// AddFromTeam - ревьюверы из другой команды сверх числа ревьюверов
+	AddFromTeam *ReviewRuleAddition `yaml:"add_from_team"`
+}
+
+// ReviewRuleAddition - сколько ревьюверов добавить из команды
+type ReviewRuleAddition struct {
+	// Rule - правило, добавившее ревьюверов, заполняется при вычислении
+	Rule     string `yaml:"-"`
+	TeamName string `yaml:"team"`
+	Count    int    `yaml:"count"`
+}
+
+// ReviewRuleError - ошибка в тексте правил и строка, где она найдена
+type ReviewRuleError struct {
+	Line    int
+	Message string
+}
+
+// Error реализует интерфейс error
+func (e ReviewRuleError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ReviewRuleErrors - все ошибки, найденные при разборе правил
+type ReviewRuleErrors []ReviewRuleError
+
+// Error реализует интерфейс error
+func (e ReviewRuleErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ParseReviewRules разбирает правила команды из YAML вида
+//
+//	rules:
+//	  - name: security
+//	    when: {label: security}
+//	    then: {add_from_team: {team: security, count: 1}}
+//
+// Пустой текст - правил нет. При ошибках возвращает ReviewRuleErrors
+func ParseReviewRules(content string) ([]*ReviewRule, error) {
+	var doc struct {
+		Rules []*ReviewRule `yaml:"rules"`
+	}
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, yamlRuleErrors(err)
+	}
+
+	// строки правил берутся из дерева документа, в структуры они не попадают
+	var nodes struct {
+		Rules []yaml.Node `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &nodes); err != nil {
+		return nil, yamlRuleErrors(err)
+	}
+
+	var errs ReviewRuleErrors
+	names := make(map[string]struct{}, len(doc.Rules))
+	for i, rule := range doc.Rules {
+		if rule == nil {
+			errs = append(errs, ReviewRuleError{Line: nodes.Rules[i].Line, Message: "rule is empty"})
+			continue
+		}
+		rule.Line = nodes.Rules[i].Line
+		for _, msg := range rule.validate() {
+			errs = append(errs, ReviewRuleError{Line: rule.Line, Message: msg})
+		}
+		if rule.Name == "" {
+			continue
+		}
+		if _, dup := names[rule.Name]; dup {
+			errs = append(errs, ReviewRuleError{Line: rule.Line, Message: fmt.Sprintf("rule %q is defined twice", rule.Name)})
+		}
+		names[rule.Name] = struct{}{}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return doc.Rules, nil
+}
+
+func (r *ReviewRule) validate() []string {
+	var msgs []string
+	prefix := "rule"
+	if r.Name == "" {
+		msgs = append(msgs, "rule name is required")
+	} else {
+		prefix = fmt.Sprintf("rule %q", r.Name)
+	}
+
+	when := r.When
+	if when == (ReviewRuleCondition{}) {
+		msgs = append(msgs, prefix+": when must set at least one condition")
+	}
+	if err := when.AuthorSeniority.Validate(); err != nil {
+		msgs = append(msgs, prefix+": author_seniority: "+err.Error())
+	}
+	if when.ChangedPath != "" {
+		if _, err := globToRegexp(when.ChangedPath); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: invalid changed_path %q: %v", prefix, when.ChangedPath, err))
+		}
+	}
+
+	then := r.Then
+	if then.Reviewers == nil && then.MinSeniorReviewers == 0 && then.MinJuniorReviewers == 0 && then.AddFromTeam == nil {
+		msgs = append(msgs, prefix+": then must set at least one action")
+	}
+	if then.Reviewers != nil && *then.Reviewers < 1 {
+		msgs = append(msgs, prefix+": reviewers must be at least 1")
+	}
+	if then.MinSeniorReviewers < 0 || then.MinJuniorReviewers < 0 {
+		msgs = append(msgs, prefix+": min_senior_reviewers and min_junior_reviewers must not be negative")
+	}
+	if add := then.AddFromTeam; add != nil {
+		if add.TeamName == "" {
+			msgs = append(msgs, prefix+": add_from_team.team is required")
+		}
+		if add.Count < 1 {
+			msgs = append(msgs, prefix+": add_from_team.count must be at least 1")
+		}
+	}
+	return msgs
+}
+
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlRuleErrors переводит ошибки yaml.v3 в ReviewRuleErrors с номерами строк
+func yamlRuleErrors(err error) ReviewRuleErrors {
+	msgs := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+
+	errs := make(ReviewRuleErrors, 0, len(msgs))
+	for _, msg := range msgs {
+		e := ReviewRuleError{Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Message = m[2]
+		}
+		// имена Go-типов в сообщениях пользователю не нужны
+		if i := strings.Index(e.Message, " in type "); i >= 0 {
+			e.Message = e.Message[:i]
+		}
+		if i := strings.Index(e.Message, " into "); i >= 0 && strings.Contains(e.Message[i:], "entity.") {
+			e.Message = e.Message[:i]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// ReviewRuleSubject - атрибуты PR, по которым проверяются условия правил
+type ReviewRuleSubject struct {
+	Name            string
+	Labels          []string
+	AuthorSeniority Seniority
+	ChangedFiles    []string
+}
+
+// Matches сообщает выполнены ли все условия правила для PR
+func (r *ReviewRule) Matches(s ReviewRuleSubject) bool {
+	when := r.When
+	if when.Label != "" && !containsFold(s.Labels, when.Label) {
+		return false
+	}
+	if when.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(s.Name), strings.ToLower(when.NamePrefix)) {
+		return false
+	}
+	if when.AuthorSeniority != SeniorityUnset && s.AuthorSeniority != when.AuthorSeniority {
+		return false
+	}
+	if when.ChangedPath != "" {
+		matched := false
+		for _, path := range s.ChangedFiles {
+			if MatchGlob(when.ChangedPath, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewRulesOutcome - суммарный эффект сработавших правил
+type ReviewRulesOutcome struct {
+	// Matched - имена сработавших правил в порядке файла
+	Matched []string
+	// Reviewers - число ревьюверов из последнего сработавшего правила, nil - по настройкам команды
+	Reviewers          *int
+	MinSeniorReviewers int
+	MinJuniorReviewers int
+	Additions          []ReviewRuleAddition
+}
+
+// EvaluateReviewRules применяет к PR все правила, условия которых выполнены
+func EvaluateReviewRules(rules []*ReviewRule, s ReviewRuleSubject) *ReviewRulesOutcome {
+	out := &ReviewRulesOutcome{}
+	for _, rule := range rules {
+		if !rule.Matches(s) {
+			continue
+		}
+		out.Matched = append(out.Matched, rule.Name)
+		then := rule.Then
+		if then.Reviewers != nil {
+			n := *then.Reviewers
+			out.Reviewers = &n
+		}
+		out.MinSeniorReviewers = max(out.MinSeniorReviewers, then.MinSeniorReviewers)
+		out.MinJuniorReviewers = max(out.MinJuniorReviewers, then.MinJuniorReviewers)
+		if add := then.AddFromTeam; add != nil {
+			out.Additions = append(out.Additions, ReviewRuleAddition{
+				Rule:     rule.Name,
+				TeamName: add.TeamName,
+				Count:    add.Count,
+			})
+		}
+	}
+	return out
+}
+
+// Apply возвращает копию настроек команды с учётом сработавших правил
+// Ревьюверы из других команд (Additions) в max_reviewers не входят и добавляются сверх него,
+// состав по уровню опыта урезается до max_reviewers: сначала младшие, потом старшие
+func (o *ReviewRulesOutcome) Apply(settings *TeamSettings) *TeamSettings {
+	out := *settings
+	if o.Reviewers != nil {
+		out.MinReviewers = *o.Reviewers
+		out.MaxReviewers = *o.Reviewers
+	}
+	out.MinSeniorReviewers = min(max(out.MinSeniorReviewers, o.MinSeniorReviewers), out.MaxReviewers)
+	out.MinJuniorReviewers = min(max(out.MinJuniorReviewers, o.MinJuniorReviewers), out.MaxReviewers-out.MinSeniorReviewers)
+	return &out
+}
@@ -0,0 +1,109 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimezone - часовой пояс пользователя по умолчанию
+const DefaultTimezone = "UTC"
+
+// WorkSchedule - рабочие часы пользователя в его часовом поясе
+type WorkSchedule struct {
+	// Start и End - минуты от полуночи, End < Start означает ночную смену
+	Start int
+	End   int
+	// Weekdays - битовая маска рабочих дней, бит i соответствует time.Weekday(i)
+	Weekdays uint8
+}
+
+// WeekdayMask собирает битовую маску из списка дней
+func WeekdayMask(days ...time.Weekday) uint8 {
+	var mask uint8
+	for _, d := range days {
+		mask |= 1 << uint(d)
+	}
+	return mask
+}
+
+// Days возвращает рабочие дни по порядку начиная с воскресенья
+func (w *WorkSchedule) Days() []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w.Weekdays&(1<<uint(d)) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// Contains сообщает попадает ли локальное время t в рабочие часы
+func (w *WorkSchedule) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	if w.Start <= w.End {
+		return w.isWorkday(day) && minute >= w.Start && minute < w.End
+	}
+	// ночная смена: хвост после полуночи относится к предыдущему дню
+	if minute >= w.Start {
+		return w.isWorkday(day)
+	}
+	if minute < w.End {
+		return w.isWorkday((day + 6) % 7)
+	}
+	return false
+}
+
+func (w *WorkSchedule) isWorkday(d time.Weekday) bool {
+	return w.Weekdays&(1<<uint(d)) != 0
+}
+
+// Validate проверяет корректность расписания
+func (w *WorkSchedule) Validate() error {
+	const day = 24 * 60
+	if w.Start < 0 || w.Start >= day || w.End < 0 || w.End >= day {
+		return fmt.Errorf("working hours must be within 00:00-23:59")
+	}
+	if w.Start == w.End {
+		return fmt.Errorf("working hours start and end must differ")
+	}
+	if w.Weekdays == 0 || w.Weekdays >= 1<<7 {
+		return fmt.Errorf("at least one working day is required")
+	}
+	return nil
+}
+
+// ParseClock разбирает время в формате HH:MM в минуты от полуночи
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatClock форматирует минуты от полуночи как HH:MM
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ParseWeekday разбирает короткое или полное английское название дня недели
+func ParseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := d.String()
+		if strings.EqualFold(s, name) || strings.EqualFold(s, name[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// ValidateTimezone проверяет что имя часового пояса есть в базе IANA
+func ValidateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	return nil
+}
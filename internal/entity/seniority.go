@@ -0,0 +1,26 @@
+package entity
+
+import "fmt"
+
+// Seniority - уровень опыта участника
+type Seniority string
+
+const (
+	// SeniorityUnset - уровень не указан
+	SeniorityUnset Seniority = ""
+	// SeniorityJunior - младший разработчик
+	SeniorityJunior Seniority = "junior"
+	// SeniorityMiddle - разработчик среднего уровня
+	SeniorityMiddle Seniority = "middle"
+	// SenioritySenior - старший разработчик
+	SenioritySenior Seniority = "senior"
+)
+
+// Validate проверяет что уровень один из известных или не указан
+func (s Seniority) Validate() error {
+	switch s {
+	case SeniorityUnset, SeniorityJunior, SeniorityMiddle, SenioritySenior:
+		return nil
+	}
+	return fmt.Errorf("unknown seniority %q: expected junior, middle or senior", string(s))
+}
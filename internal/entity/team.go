@@ -1,17 +1,106 @@
-package entity
-
-// Team — группа пользователей
-type Team struct {
-	Name    string
-	Members []*User
-}
-
-// HasMember проверяет является ли userID участником этой команды
-func (t *Team) HasMember(userID string) bool {
-	for _, m := range t.Members {
-		if m.ID == userID {
-			return true
-		}
-	}
-	return false
-}
+package entity
+
+import "fmt"
+
+const (
+	// DefaultMinReviewers - сколько ревьюверов обязательно по умолчанию
+	DefaultMinReviewers = 0
+	// DefaultMaxReviewers - сколько ревьюверов назначается по умолчанию
+	DefaultMaxReviewers = 2
+)
+
+// Team — группа пользователей
+type Team struct {
+	Name    string
+	Members []*User
+}
+
+// HasMember проверяет является ли userID участником этой команды
+func (t *Team) HasMember(userID string) bool {
+	for _, m := range t.Members {
+		if m.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamSettings - настройки назначения ревьюверов для команды
+type TeamSettings struct {
+	TeamName string
+	// MinReviewers - меньше этого числа ревьюверов PR не создаётся
+	MinReviewers int
+	// MaxReviewers - сколько ревьюверов назначается на PR
+	MaxReviewers int
+	// MaxOpenReviews - лимит открытых ревью на участника, nil - без лимита
+	MaxOpenReviews *int
+	// MinSeniorReviewers - сколько старших разработчиков должно быть среди ревьюверов
+	MinSeniorReviewers int
+	// MinJuniorReviewers - сколько младших разработчиков должно быть среди ревьюверов
+	MinJuniorReviewers int
+	// FallbackTeams - команды, из которых по порядку добираются ревьюверы,
+	// если в своей команде кандидатов не хватило
+	FallbackTeams []string
+}
+
+// DefaultTeamSettings возвращает настройки для команды без сохранённых настроек
+func DefaultTeamSettings(teamName string) *TeamSettings {
+	return &TeamSettings{
+		TeamName:     teamName,
+		MinReviewers: DefaultMinReviewers,
+		MaxReviewers: DefaultMaxReviewers,
+	}
+}
+
+// Validate проверяет согласованность настроек
+func (s *TeamSettings) Validate() error {
+	if s.TeamName == "" {
+		return fmt.Errorf("team name is empty")
+	}
+	if s.MinReviewers < 0 {
+		return fmt.Errorf("min_reviewers must not be negative")
+	}
+	if s.MaxReviewers < 1 {
+		return fmt.Errorf("max_reviewers must be at least 1")
+	}
+	if s.MinReviewers > s.MaxReviewers {
+		return fmt.Errorf("min_reviewers must not exceed max_reviewers")
+	}
+	if s.MaxOpenReviews != nil && *s.MaxOpenReviews < 0 {
+		return fmt.Errorf("max_open_reviews must not be negative")
+	}
+	if s.MinSeniorReviewers < 0 || s.MinJuniorReviewers < 0 {
+		return fmt.Errorf("min_senior_reviewers and min_junior_reviewers must not be negative")
+	}
+	if s.MinSeniorReviewers+s.MinJuniorReviewers > s.MaxReviewers {
+		return fmt.Errorf("min_senior_reviewers + min_junior_reviewers must not exceed max_reviewers")
+	}
+	seen := make(map[string]struct{}, len(s.FallbackTeams))
+	for _, name := range s.FallbackTeams {
+		if name == "" {
+			return fmt.Errorf("fallback team name is empty")
+		}
+		if name == s.TeamName {
+			return fmt.Errorf("team cannot be its own fallback")
+		}
+		if _, dup := seen[name]; dup {
+			return fmt.Errorf("fallback team %s is listed twice", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
+
+// MinBySeniority возвращает сколько ревьюверов уровня level требует состав команды
+func (s *TeamSettings) MinBySeniority(level Seniority) int {
+	switch level {
+	case SenioritySenior:
+		return s.MinSeniorReviewers
+	case SeniorityJunior:
+		return s.MinJuniorReviewers
+	}
+	return 0
+}
+
+// CompositionLevels - уровни, для которых в настройках команды задаётся минимум ревьюверов
+var CompositionLevels = []Seniority{SenioritySenior, SeniorityJunior}
@@ -1,23 +1,78 @@
-// Package entity содержит основные сущности бизнес-логики
-package entity
-
-import "fmt"
-
-// User - участник команды
-type User struct {
-	ID       string
-	Username string
-	TeamName string
-	IsActive bool
-}
-
-// Validate проверяет минимальные требования к данным пользователя
-func (u *User) Validate() error {
-	if u.ID == "" {
-		return fmt.Errorf("user id is empty")
-	}
-	if u.Username == "" {
-		return fmt.Errorf("username is empty")
-	}
-	return nil
-}
+// Package entity содержит основные сущности бизнес-логики
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// User - участник команды
+type User struct {
+	ID       string
+	Username string
+	TeamName string
+	IsActive bool
+	// Seniority - уровень опыта, учитывается правилами состава ревьюверов команды
+	Seniority Seniority
+	// MaxOpenReviews - личный лимит открытых ревью, nil - берётся лимит команды
+	MaxOpenReviews *int
+	// Timezone - часовой пояс IANA, пустое значение - UTC
+	Timezone string
+	// Schedule - рабочие часы, nil - пользователь доступен в любое время
+	Schedule *WorkSchedule
+	// IsTrainee - стажёр, при создании PR может быть назначен теневым ревьювером
+	IsTrainee bool
+	// ReviewWeight - относительный вес в стратегии weighted_random, 0 - DefaultReviewWeight
+	ReviewWeight float64
+}
+
+// DefaultReviewWeight - вес пользователя в weighted_random по умолчанию
+const DefaultReviewWeight = 1.0
+
+// Validate проверяет минимальные требования к данным пользователя
+func (u *User) Validate() error {
+	if u.ID == "" {
+		return fmt.Errorf("user id is empty")
+	}
+	if u.Username == "" {
+		return fmt.Errorf("username is empty")
+	}
+	if u.ReviewWeight < 0 {
+		return fmt.Errorf("review weight must not be negative")
+	}
+	return u.Seniority.Validate()
+}
+
+// ReviewLimit возвращает действующий лимит открытых ревью пользователя
+// Второе значение false означает что лимита нет
+func (u *User) ReviewLimit(teamLimit *int) (int, bool) {
+	if u.MaxOpenReviews != nil {
+		return *u.MaxOpenReviews, true
+	}
+	if teamLimit != nil {
+		return *teamLimit, true
+	}
+	return 0, false
+}
+
+// EffectiveReviewWeight возвращает вес пользователя с учётом значения по умолчанию
+func (u *User) EffectiveReviewWeight() float64 {
+	if u.ReviewWeight <= 0 {
+		return DefaultReviewWeight
+	}
+	return u.ReviewWeight
+}
+
+// IsWorkingAt сообщает попадает ли момент t в рабочие часы пользователя
+func (u *User) IsWorkingAt(t time.Time) bool {
+	if u.Schedule == nil {
+		return true
+	}
+	loc := time.UTC
+	if u.Timezone != "" {
+		if l, err := time.LoadLocation(u.Timezone); err == nil {
+			loc = l
+		}
+	}
+	return u.Schedule.Contains(t.In(loc))
+}
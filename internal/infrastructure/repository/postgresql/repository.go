@@ -1,356 +1,1191 @@
-package postgresql
-
-import (
-	"context"
-	"errors"
-
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
-
-	"github.com/vandermeer0/pr-reviewer/internal/entity"
-	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
-)
-
-var (
-	_ repo.UserRepository        = (*UserRepository)(nil)
-	_ repo.TeamRepository        = (*TeamRepository)(nil)
-	_ repo.PullRequestRepository = (*PullRequestRepository)(nil)
-)
-
-// UserRepository реализует repo.UserRepository с использованием PostgreSQL
-type UserRepository struct {
-	pool *pgxpool.Pool
-}
-
-// NewUserRepository создает новый UserRepository
-func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
-	return &UserRepository{pool: pool}
-}
-
-// Save сохраняет или обновляет пользователя
-func (r *UserRepository) Save(ctx context.Context, user *entity.User) error {
-	if user == nil {
-		return errors.New("user is nil")
-	}
-
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO users (id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (id) DO UPDATE
-		SET username = EXCLUDED.username,
-		    team_name = EXCLUDED.team_name,
-		    is_active = EXCLUDED.is_active
-	`, user.ID, user.Username, user.TeamName, user.IsActive)
-	return err
-}
-
-// GetByID возвращает пользователя по идентификатору
-func (r *UserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, username, team_name, is_active
-		FROM users
-		WHERE id = $1
-	`, id)
-
-	var u entity.User
-	if err := row.Scan(&u.ID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repo.ErrNotFound
-		}
-		return nil, err
-	}
-
-	return &u, nil
-}
-
-// SaveBatch сохраняет или обновляет список пользователей
-func (r *UserRepository) SaveBatch(ctx context.Context, users []*entity.User) error {
-	for _, u := range users {
-		if err := r.Save(ctx, u); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// TeamRepository реализует repo.TeamRepository с использованием PostgreSQL
-type TeamRepository struct {
-	pool *pgxpool.Pool
-}
-
-// NewTeamRepository создает новый TeamRepository
-func NewTeamRepository(pool *pgxpool.Pool) *TeamRepository {
-	return &TeamRepository{pool: pool}
-}
-
-// Save создает новую команду
-func (r *TeamRepository) Save(ctx context.Context, team *entity.Team) error {
-	if team == nil {
-		return errors.New("team is nil")
-	}
-
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO teams (name)
-		VALUES ($1)
-	`, team.Name)
-	if err != nil {
-		if isUniqueViolation(err) {
-			return repo.ErrAlreadyExists
-		}
-		return err
-	}
-
-	return nil
-}
-
-// GetByName возвращает команду с участниками
-func (r *TeamRepository) GetByName(ctx context.Context, name string) (*entity.Team, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT name
-		FROM teams
-		WHERE name = $1
-	`, name)
-
-	var team entity.Team
-	if err := row.Scan(&team.Name); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repo.ErrNotFound
-		}
-		return nil, err
-	}
-
-	rows, err := r.pool.Query(ctx, `
-		SELECT id, username, team_name, is_active
-		FROM users
-		WHERE team_name = $1
-	`, name)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var u entity.User
-		if err := rows.Scan(&u.ID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
-			return nil, err
-		}
-		team.Members = append(team.Members, &u)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return &team, nil
-}
-
-// PullRequestRepository реализует repo.PullRequestRepository с использованием PostgreSQL
-type PullRequestRepository struct {
-	pool *pgxpool.Pool
-}
-
-// NewPullRequestRepository создает новый PullRequestRepository
-func NewPullRequestRepository(pool *pgxpool.Pool) *PullRequestRepository {
-	return &PullRequestRepository{pool: pool}
-}
-
-// Save создает новый PR и его ревьюверов
-func (r *PullRequestRepository) Save(ctx context.Context, pr *entity.PullRequest) error {
-	if pr == nil {
-		return errors.New("pull request is nil")
-	}
-
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback(ctx)
-		}
-	}()
-
-	_, err = tx.Exec(ctx, `
-                INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at)
-                VALUES ($1, $2, $3, $4, $5, $6)
-        `, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), pr.CreatedAt, pr.MergedAt)
-	if err != nil {
-		if isUniqueViolation(err) {
-			_ = tx.Rollback(ctx)
-			return repo.ErrAlreadyExists
-		}
-		_ = tx.Rollback(ctx)
-		return err
-	}
-
-	if len(pr.Reviewers) > 0 {
-		for _, reviewerID := range pr.Reviewers {
-			_, err = tx.Exec(ctx, `
-                        INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-                        VALUES ($1, $2)
-                `, pr.ID, reviewerID)
-			if err != nil {
-				_ = tx.Rollback(ctx)
-				return err
-			}
-		}
-	}
-
-	err = tx.Commit(ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// GetByID возвращает PR с ревьюверами
-func (r *PullRequestRepository) GetByID(ctx context.Context, id string) (*entity.PullRequest, error) {
-	row := r.pool.QueryRow(ctx, `
-                SELECT id, name, author_id, status, created_at, merged_at
-                FROM pull_requests
-                WHERE id = $1
-        `, id)
-
-	var pr entity.PullRequest
-	var status string
-	if err := row.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &status, &pr.CreatedAt, &pr.MergedAt); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repo.ErrNotFound
-		}
-		return nil, err
-	}
-	pr.Status = entity.PRStatus(status)
-
-	// Загружаем ID ревьюверов
-	rows, err := r.pool.Query(ctx, `
-                SELECT reviewer_id
-                FROM pr_reviewers
-                WHERE pull_request_id = $1
-        `, id)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
-			return nil, err
-		}
-		pr.Reviewers = append(pr.Reviewers, reviewerID)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return &pr, nil
-}
-
-// Update обновляет данные PR и его ревьюверов
-func (r *PullRequestRepository) Update(ctx context.Context, pr *entity.PullRequest) error {
-	if pr == nil {
-		return errors.New("pull request is nil")
-	}
-
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback(ctx)
-		}
-	}()
-
-	cmdTag, err := tx.Exec(ctx, `
-                UPDATE pull_requests
-                SET name = $2,
-                    author_id = $3,
-                    status = $4,
-                    created_at = $5,
-                    merged_at = $6
-                WHERE id = $1
-        `, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), pr.CreatedAt, pr.MergedAt)
-	if err != nil {
-		_ = tx.Rollback(ctx)
-		return err
-	}
-
-	if cmdTag.RowsAffected() == 0 {
-		_ = tx.Rollback(ctx)
-		return repo.ErrNotFound
-	}
-
-	_, err = tx.Exec(ctx, `
-                DELETE FROM pr_reviewers
-                WHERE pull_request_id = $1
-        `, pr.ID)
-	if err != nil {
-		_ = tx.Rollback(ctx)
-		return err
-	}
-
-	if len(pr.Reviewers) > 0 {
-		for _, reviewerID := range pr.Reviewers {
-			_, err = tx.Exec(ctx, `
-                        INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-                        VALUES ($1, $2)
-                `, pr.ID, reviewerID)
-			if err != nil {
-				_ = tx.Rollback(ctx)
-				return err
-			}
-		}
-	}
-
-	err = tx.Commit(ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// GetByReviewerID возвращает PR, где указанный пользователь назначен ревьювером
-func (r *PullRequestRepository) GetByReviewerID(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error) {
-	rows, err := r.pool.Query(ctx, `
-                SELECT p.id, p.name, p.author_id, p.status, p.created_at, p.merged_at
-                FROM pull_requests p
-                JOIN pr_reviewers rvr ON p.id = rvr.pull_request_id
-                WHERE rvr.reviewer_id = $1
-                ORDER BY p.created_at DESC
-        `, reviewerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var result []*entity.PullRequest
-
-	for rows.Next() {
-		var pr entity.PullRequest
-		var status string
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &status, &pr.CreatedAt, &pr.MergedAt); err != nil {
-			return nil, err
-		}
-		pr.Status = entity.PRStatus(status)
-		result = append(result, &pr)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
-func isUniqueViolation(err error) bool {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code == "23505"
-	}
-	return false
-}
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+var (
+	_ repo.UserRepository        = (*UserRepository)(nil)
+	_ repo.TeamRepository        = (*TeamRepository)(nil)
+	_ repo.PullRequestRepository = (*PullRequestRepository)(nil)
+	_ repo.OwnershipRepository   = (*OwnershipRepository)(nil)
+	_ repo.CodeOwnersRepository  = (*CodeOwnersRepository)(nil)
+	_ repo.ConflictRepository    = (*ConflictRepository)(nil)
+	_ repo.RotationRepository    = (*RotationRepository)(nil)
+	_ repo.ReviewRulesRepository = (*ReviewRulesRepository)(nil)
+)
+
+// UserRepository реализует repo.UserRepository с использованием PostgreSQL
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserRepository создает новый UserRepository
+func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{pool: pool}
+}
+
+// Save сохраняет или обновляет пользователя
+func (r *UserRepository) Save(ctx context.Context, user *entity.User) error {
+	if user == nil {
+		return errors.New("user is nil")
+	}
+
+	timezone := user.Timezone
+	if timezone == "" {
+		timezone = entity.DefaultTimezone
+	}
+
+	var workStart, workEnd *int
+	var workDays *int16
+	if user.Schedule != nil {
+		start, end, days := user.Schedule.Start, user.Schedule.End, int16(user.Schedule.Weekdays)
+		workStart, workEnd, workDays = &start, &end, &days
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO users (id, username, team_name, is_active, seniority, max_open_reviews,
+		                   timezone, work_start_minute, work_end_minute, work_days, review_weight, is_trainee)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE
+		SET username = EXCLUDED.username,
+		    team_name = EXCLUDED.team_name,
+		    is_active = EXCLUDED.is_active,
+		    seniority = EXCLUDED.seniority,
+		    max_open_reviews = EXCLUDED.max_open_reviews,
+		    timezone = EXCLUDED.timezone,
+		    work_start_minute = EXCLUDED.work_start_minute,
+		    work_end_minute = EXCLUDED.work_end_minute,
+		    work_days = EXCLUDED.work_days,
+		    review_weight = EXCLUDED.review_weight,
+		    is_trainee = EXCLUDED.is_trainee
+	`, user.ID, user.Username, user.TeamName, user.IsActive, string(user.Seniority), user.MaxOpenReviews,
+		timezone, workStart, workEnd, workDays, user.EffectiveReviewWeight(), user.IsTrainee)
+	return err
+}
+
+// GetByID возвращает пользователя по идентификатору
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+UserColumns+`
+		FROM users
+		WHERE id = $1
+	`, id)
+
+	u, err := ScanUser(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// GetByUsername возвращает пользователя по имени
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+UserColumns+`
+		FROM users
+		WHERE username = $1
+		ORDER BY id
+		LIMIT 1
+	`, username)
+
+	u, err := ScanUser(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// SaveBatch сохраняет или обновляет список пользователей
+func (r *UserRepository) SaveBatch(ctx context.Context, users []*entity.User) error {
+	for _, u := range users {
+		if err := r.Save(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddOutOfOffice сохраняет период отсутствия и заполняет его ID
+func (r *UserRepository) AddOutOfOffice(ctx context.Context, period *entity.OutOfOffice) error {
+	if period == nil {
+		return errors.New("out of office period is nil")
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO out_of_office (user_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, period.UserID, period.StartsAt, period.EndsAt, period.Reason)
+	return row.Scan(&period.ID)
+}
+
+// ListOutOfOffice возвращает периоды отсутствия пользователя
+func (r *UserRepository) ListOutOfOffice(ctx context.Context, userID string) ([]*entity.OutOfOffice, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, starts_at, ends_at, reason
+		FROM out_of_office
+		WHERE user_id = $1
+		ORDER BY starts_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.OutOfOffice
+	for rows.Next() {
+		var o entity.OutOfOffice
+		if err := rows.Scan(&o.ID, &o.UserID, &o.StartsAt, &o.EndsAt, &o.Reason); err != nil {
+			return nil, err
+		}
+		result = append(result, &o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteOutOfOffice удаляет период отсутствия пользователя
+func (r *UserRepository) DeleteOutOfOffice(ctx context.Context, userID string, id int64) error {
+	cmdTag, err := r.pool.Exec(ctx, `
+		DELETE FROM out_of_office
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// GetAwayUserIDs возвращает пользователей, отсутствующих в момент at
+func (r *UserRepository) GetAwayUserIDs(ctx context.Context, userIDs []string, at time.Time) (map[string]struct{}, error) {
+	away := make(map[string]struct{})
+	if len(userIDs) == 0 {
+		return away, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT user_id
+		FROM out_of_office
+		WHERE user_id = ANY($1)
+		    AND starts_at <= $2
+		    AND ends_at > $2
+	`, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		away[id] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return away, nil
+}
+
+// TeamRepository реализует repo.TeamRepository с использованием PostgreSQL
+type TeamRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTeamRepository создает новый TeamRepository
+func NewTeamRepository(pool *pgxpool.Pool) *TeamRepository {
+	return &TeamRepository{pool: pool}
+}
+
+// Save создает новую команду
+func (r *TeamRepository) Save(ctx context.Context, team *entity.Team) error {
+	if team == nil {
+		return errors.New("team is nil")
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO teams (name)
+		VALUES ($1)
+	`, team.Name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repo.ErrAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByName возвращает команду с участниками
+func (r *TeamRepository) GetByName(ctx context.Context, name string) (*entity.Team, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT name
+		FROM teams
+		WHERE name = $1
+	`, name)
+
+	var team entity.Team
+	if err := row.Scan(&team.Name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+UserColumns+`
+		FROM users
+		WHERE team_name = $1
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := ScanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		team.Members = append(team.Members, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// GetSettings возвращает сохранённые настройки команды
+func (r *TeamRepository) GetSettings(ctx context.Context, teamName string) (*entity.TeamSettings, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT team_name, min_reviewers, max_reviewers, max_open_reviews,
+		       min_senior_reviewers, min_junior_reviewers, fallback_teams
+		FROM team_settings
+		WHERE team_name = $1
+	`, teamName)
+
+	var st entity.TeamSettings
+	if err := row.Scan(
+		&st.TeamName, &st.MinReviewers, &st.MaxReviewers, &st.MaxOpenReviews,
+		&st.MinSeniorReviewers, &st.MinJuniorReviewers, &st.FallbackTeams,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+// SaveSettings сохраняет или обновляет настройки команды
+func (r *TeamRepository) SaveSettings(ctx context.Context, settings *entity.TeamSettings) error {
+	if settings == nil {
+		return errors.New("team settings is nil")
+	}
+
+	fallbackTeams := settings.FallbackTeams
+	if fallbackTeams == nil {
+		fallbackTeams = []string{}
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO team_settings (team_name, min_reviewers, max_reviewers, max_open_reviews,
+		                           min_senior_reviewers, min_junior_reviewers, fallback_teams)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (team_name) DO UPDATE
+		SET min_reviewers = EXCLUDED.min_reviewers,
+		    max_reviewers = EXCLUDED.max_reviewers,
+		    max_open_reviews = EXCLUDED.max_open_reviews,
+		    min_senior_reviewers = EXCLUDED.min_senior_reviewers,
+		    min_junior_reviewers = EXCLUDED.min_junior_reviewers,
+		    fallback_teams = EXCLUDED.fallback_teams
+	`, settings.TeamName, settings.MinReviewers, settings.MaxReviewers, settings.MaxOpenReviews,
+		settings.MinSeniorReviewers, settings.MinJuniorReviewers, fallbackTeams)
+	return err
+}
+
+// PullRequestRepository реализует repo.PullRequestRepository с использованием PostgreSQL
+type PullRequestRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPullRequestRepository создает новый PullRequestRepository
+func NewPullRequestRepository(pool *pgxpool.Pool) *PullRequestRepository {
+	return &PullRequestRepository{pool: pool}
+}
+
+// Save создает новый PR и его ревьюверов
+func (r *PullRequestRepository) Save(ctx context.Context, pr *entity.PullRequest) error {
+	return r.save(ctx, pr, nil)
+}
+
+// SaveWithTrace создает PR и сохраняет трассировку назначения в одной транзакции
+func (r *PullRequestRepository) SaveWithTrace(
+	ctx context.Context,
+	pr *entity.PullRequest,
+	trace *entity.AssignmentTrace,
+) error {
+	if trace == nil {
+		return errors.New("assignment trace is nil")
+	}
+	return r.save(ctx, pr, trace)
+}
+
+func (r *PullRequestRepository) save(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error {
+	if pr == nil {
+		return errors.New("pull request is nil")
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `
+                INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at, closed_at,
+                                           draft_changed_files, draft_requested_reviewers, draft_labels)
+                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        `, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), pr.TargetReviewers, pr.CreatedAt, pr.MergedAt, pr.ClosedAt,
+		textArray(pr.Draft.ChangedFiles), textArray(pr.Draft.RequestedReviewers), textArray(pr.Draft.Labels))
+	if err != nil {
+		if isUniqueViolation(err) {
+			_ = tx.Rollback(ctx)
+			return repo.ErrAlreadyExists
+		}
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	if len(pr.Reviewers) > 0 {
+		for _, reviewerID := range pr.Reviewers {
+			var requiredPattern *string
+			if pattern, ok := pr.RequiredPattern(reviewerID); ok {
+				requiredPattern = &pattern
+			}
+			_, err = tx.Exec(ctx, `
+                        INSERT INTO pr_reviewers (pull_request_id, reviewer_id, required_pattern, from_fallback, from_rule)
+                        VALUES ($1, $2, $3, $4, $5)
+                `, pr.ID, reviewerID, requiredPattern, pr.IsFallbackReviewer(reviewerID), pr.IsRuleReviewer(reviewerID))
+			if err != nil {
+				_ = tx.Rollback(ctx)
+				return err
+			}
+		}
+	}
+
+	for _, shadowID := range pr.ShadowReviewers {
+		_, err = tx.Exec(ctx, `
+                        INSERT INTO pr_shadow_reviewers (pull_request_id, reviewer_id)
+                        VALUES ($1, $2)
+                `, pr.ID, shadowID)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+	}
+
+	if trace != nil {
+		if err = insertAssignmentTrace(ctx, tx, trace); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByID возвращает PR с ревьюверами
+func (r *PullRequestRepository) GetByID(ctx context.Context, id string) (*entity.PullRequest, error) {
+	row := r.pool.QueryRow(ctx, `
+                SELECT id, name, author_id, status, target_reviewers, created_at, merged_at, closed_at,
+                       draft_changed_files, draft_requested_reviewers, draft_labels
+                FROM pull_requests
+                WHERE id = $1
+        `, id)
+
+	var pr entity.PullRequest
+	var status string
+	if err := row.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &status, &pr.TargetReviewers, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt,
+		&pr.Draft.ChangedFiles, &pr.Draft.RequestedReviewers, &pr.Draft.Labels); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	pr.Status = entity.PRStatus(status)
+
+	// Загружаем ID ревьюверов
+	rows, err := r.pool.Query(ctx, `
+                SELECT reviewer_id, required_pattern, from_fallback, from_rule
+                FROM pr_reviewers
+                WHERE pull_request_id = $1
+        `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewerID string
+		var requiredPattern *string
+		var fromFallback, fromRule bool
+		if err := rows.Scan(&reviewerID, &requiredPattern, &fromFallback, &fromRule); err != nil {
+			return nil, err
+		}
+		pr.Reviewers = append(pr.Reviewers, reviewerID)
+		if fromFallback {
+			pr.FallbackReviewers = append(pr.FallbackReviewers, reviewerID)
+		}
+		if fromRule {
+			pr.RuleReviewers = append(pr.RuleReviewers, reviewerID)
+		}
+		if requiredPattern != nil {
+			pr.RequiredReviewers = append(pr.RequiredReviewers, entity.RequiredReviewer{
+				UserID:  reviewerID,
+				Pattern: *requiredPattern,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	shadowRows, err := r.pool.Query(ctx, `
+                SELECT reviewer_id
+                FROM pr_shadow_reviewers
+                WHERE pull_request_id = $1
+                ORDER BY reviewer_id
+        `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer shadowRows.Close()
+
+	for shadowRows.Next() {
+		var shadowID string
+		if err := shadowRows.Scan(&shadowID); err != nil {
+			return nil, err
+		}
+		pr.ShadowReviewers = append(pr.ShadowReviewers, shadowID)
+	}
+
+	if err := shadowRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+// Update обновляет данные PR и его ревьюверов
+func (r *PullRequestRepository) Update(ctx context.Context, pr *entity.PullRequest) error {
+	return r.update(ctx, pr, nil)
+}
+
+// UpdateWithTrace обновляет PR и сохраняет трассировку назначения в одной транзакции
+func (r *PullRequestRepository) UpdateWithTrace(
+	ctx context.Context,
+	pr *entity.PullRequest,
+	trace *entity.AssignmentTrace,
+) error {
+	if trace == nil {
+		return errors.New("assignment trace is nil")
+	}
+	return r.update(ctx, pr, trace)
+}
+
+func (r *PullRequestRepository) update(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error {
+	if pr == nil {
+		return errors.New("pull request is nil")
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	cmdTag, err := tx.Exec(ctx, `
+                UPDATE pull_requests
+                SET name = $2,
+                    author_id = $3,
+                    status = $4,
+                    target_reviewers = $5,
+                    created_at = $6,
+                    merged_at = $7,
+                    closed_at = $8,
+                    draft_changed_files = $9,
+                    draft_requested_reviewers = $10,
+                    draft_labels = $11
+                WHERE id = $1
+        `, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), pr.TargetReviewers, pr.CreatedAt, pr.MergedAt, pr.ClosedAt,
+		textArray(pr.Draft.ChangedFiles), textArray(pr.Draft.RequestedReviewers), textArray(pr.Draft.Labels))
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		_ = tx.Rollback(ctx)
+		return repo.ErrNotFound
+	}
+
+	_, err = tx.Exec(ctx, `
+                DELETE FROM pr_reviewers
+                WHERE pull_request_id = $1
+        `, pr.ID)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+                DELETE FROM pr_shadow_reviewers
+                WHERE pull_request_id = $1
+        `, pr.ID)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	if len(pr.Reviewers) > 0 {
+		for _, reviewerID := range pr.Reviewers {
+			var requiredPattern *string
+			if pattern, ok := pr.RequiredPattern(reviewerID); ok {
+				requiredPattern = &pattern
+			}
+			_, err = tx.Exec(ctx, `
+                        INSERT INTO pr_reviewers (pull_request_id, reviewer_id, required_pattern, from_fallback, from_rule)
+                        VALUES ($1, $2, $3, $4, $5)
+                `, pr.ID, reviewerID, requiredPattern, pr.IsFallbackReviewer(reviewerID), pr.IsRuleReviewer(reviewerID))
+			if err != nil {
+				_ = tx.Rollback(ctx)
+				return err
+			}
+		}
+	}
+
+	for _, shadowID := range pr.ShadowReviewers {
+		_, err = tx.Exec(ctx, `
+                        INSERT INTO pr_shadow_reviewers (pull_request_id, reviewer_id)
+                        VALUES ($1, $2)
+                `, pr.ID, shadowID)
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+	}
+
+	if trace != nil {
+		if err = insertAssignmentTrace(ctx, tx, trace); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByReviewerID возвращает PR, где указанный пользователь назначен ревьювером или теневым ревьювером
+// У PR из теневых назначений ShadowReviewers содержит reviewerID, Reviewers не заполняется
+// Закрытые PR и черновики в очередь ревьювера не попадают
+func (r *PullRequestRepository) GetByReviewerID(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error) {
+	rows, err := r.pool.Query(ctx, `
+                SELECT p.id, p.name, p.author_id, p.status, p.target_reviewers, p.created_at, p.merged_at, p.closed_at, rvr.shadow
+                FROM pull_requests p
+                JOIN (
+                    SELECT pull_request_id, reviewer_id, FALSE AS shadow FROM pr_reviewers
+                    UNION ALL
+                    SELECT pull_request_id, reviewer_id, TRUE AS shadow FROM pr_shadow_reviewers
+                ) rvr ON p.id = rvr.pull_request_id
+                WHERE rvr.reviewer_id = $1
+                    AND p.status IN ('OPEN', 'MERGED')
+                ORDER BY p.created_at DESC
+        `, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.PullRequest
+
+	for rows.Next() {
+		var pr entity.PullRequest
+		var status string
+		var shadow bool
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &status, &pr.TargetReviewers, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &shadow); err != nil {
+			return nil, err
+		}
+		pr.Status = entity.PRStatus(status)
+		if shadow {
+			pr.ShadowReviewers = []string{reviewerID}
+		}
+		result = append(result, &pr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CountOpenReviews возвращает число открытых PR на ревью у каждого из пользователей
+func (r *PullRequestRepository) CountOpenReviews(ctx context.Context, reviewerIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(reviewerIDs))
+	if len(reviewerIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+                SELECT rvr.reviewer_id, COUNT(*)
+                FROM pr_reviewers rvr
+                JOIN pull_requests p ON p.id = rvr.pull_request_id
+                WHERE rvr.reviewer_id = ANY($1)
+                    AND p.status = 'OPEN'
+                GROUP BY rvr.reviewer_id
+        `, reviewerIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewerID string
+		var cnt int
+		if err := rows.Scan(&reviewerID, &cnt); err != nil {
+			return nil, err
+		}
+		counts[reviewerID] = cnt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetPairHistory возвращает сколько PR автора, созданных не раньше since,
+// ревьюил каждый из пользователей и когда был последний
+func (r *PullRequestRepository) GetPairHistory(
+	ctx context.Context,
+	authorID string,
+	reviewerIDs []string,
+	since time.Time,
+) (map[string]entity.ReviewPairHistory, error) {
+	out := make(map[string]entity.ReviewPairHistory, len(reviewerIDs))
+	if len(reviewerIDs) == 0 {
+		return out, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+                SELECT rvr.reviewer_id, COUNT(*), MAX(p.created_at)
+                FROM pr_reviewers rvr
+                JOIN pull_requests p ON p.id = rvr.pull_request_id
+                WHERE p.author_id = $1
+                    AND rvr.reviewer_id = ANY($2)
+                    AND p.created_at >= $3
+                    AND p.status IN ('OPEN', 'MERGED')
+                GROUP BY rvr.reviewer_id
+        `, authorID, reviewerIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewerID string
+		var h entity.ReviewPairHistory
+		if err := rows.Scan(&reviewerID, &h.Reviews, &h.LastAt); err != nil {
+			return nil, err
+		}
+		out[reviewerID] = h
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// SaveAssignmentTrace сохраняет трассировку назначения, шаги хранятся в JSONB
+func (r *PullRequestRepository) SaveAssignmentTrace(ctx context.Context, trace *entity.AssignmentTrace) error {
+	if trace == nil {
+		return errors.New("assignment trace is nil")
+	}
+
+	return insertAssignmentTrace(ctx, r.pool, trace)
+}
+
+// rowQuerier - пул или транзакция, в которых выполняется запрос с одной строкой результата
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertAssignmentTrace(ctx context.Context, q rowQuerier, trace *entity.AssignmentTrace) error {
+	reviewers := trace.Reviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	steps := trace.Steps
+	if steps == nil {
+		steps = []entity.AssignmentTraceStep{}
+	}
+
+	return q.QueryRow(ctx, `
+		INSERT INTO assignment_traces (pull_request_id, action, replaced_reviewer_id, reviewers, steps, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		RETURNING id
+	`, trace.PullRequestID, string(trace.Action), trace.ReplacedReviewerID, reviewers, steps, trace.CreatedAt).Scan(&trace.ID)
+}
+
+// ListAssignmentTraces возвращает трассировки назначений PR в порядке создания
+func (r *PullRequestRepository) ListAssignmentTraces(ctx context.Context, prID string) ([]*entity.AssignmentTrace, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, pull_request_id, action, COALESCE(replaced_reviewer_id, ''), reviewers, steps, created_at
+		FROM assignment_traces
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.AssignmentTrace
+	for rows.Next() {
+		var t entity.AssignmentTrace
+		if err := rows.Scan(
+			&t.ID, &t.PullRequestID, &t.Action, &t.ReplacedReviewerID, &t.Reviewers, &t.Steps, &t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// OwnershipRepository реализует repo.OwnershipRepository с использованием PostgreSQL
+type OwnershipRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOwnershipRepository создает новый OwnershipRepository
+func NewOwnershipRepository(pool *pgxpool.Pool) *OwnershipRepository {
+	return &OwnershipRepository{pool: pool}
+}
+
+// Save сохраняет правило владения и заполняет его ID
+func (r *OwnershipRepository) Save(ctx context.Context, rule *entity.OwnershipRule) error {
+	if rule == nil {
+		return errors.New("ownership rule is nil")
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO ownership_rules (pattern, user_id, team_name)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING id
+	`, rule.Pattern, rule.UserID, rule.TeamName)
+	return row.Scan(&rule.ID)
+}
+
+// List возвращает все правила владения в порядке добавления
+func (r *OwnershipRepository) List(ctx context.Context) ([]*entity.OwnershipRule, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, pattern, COALESCE(user_id, ''), COALESCE(team_name, '')
+		FROM ownership_rules
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.OwnershipRule
+	for rows.Next() {
+		var rule entity.OwnershipRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.UserID, &rule.TeamName); err != nil {
+			return nil, err
+		}
+		result = append(result, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete удаляет правило владения
+func (r *OwnershipRepository) Delete(ctx context.Context, id int64) error {
+	cmdTag, err := r.pool.Exec(ctx, `
+		DELETE FROM ownership_rules
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// CodeOwnersRepository реализует repo.CodeOwnersRepository с использованием PostgreSQL
+type CodeOwnersRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCodeOwnersRepository создает новый CodeOwnersRepository
+func NewCodeOwnersRepository(pool *pgxpool.Pool) *CodeOwnersRepository {
+	return &CodeOwnersRepository{pool: pool}
+}
+
+// Replace заменяет все правила CODEOWNERS в одной транзакции
+func (r *CodeOwnersRepository) Replace(ctx context.Context, rules []*entity.CodeOwnersRule) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM codeowners_rules`); err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		owners := rule.Owners
+		if owners == nil {
+			owners = []string{}
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO codeowners_rules (line, pattern, owners)
+			VALUES ($1, $2, $3)
+		`, rule.Line, rule.Pattern, owners); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// List возвращает правила CODEOWNERS в порядке строк файла
+func (r *CodeOwnersRepository) List(ctx context.Context) ([]*entity.CodeOwnersRule, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT line, pattern, owners
+		FROM codeowners_rules
+		ORDER BY line
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.CodeOwnersRule
+	for rows.Next() {
+		var rule entity.CodeOwnersRule
+		if err := rows.Scan(&rule.Line, &rule.Pattern, &rule.Owners); err != nil {
+			return nil, err
+		}
+		result = append(result, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UserColumns - колонки users в порядке, который ожидает ScanUser
+const UserColumns = `id, username, team_name, is_active, seniority, max_open_reviews,
+		       timezone, work_start_minute, work_end_minute, work_days, review_weight, is_trainee`
+
+// ScanUser читает пользователя из строки с колонками UserColumns, подходит и для pgx.Rows в транзакции
+func ScanUser(row pgx.Row) (*entity.User, error) {
+	var u entity.User
+	var workStart, workEnd *int
+	var workDays *int16
+	if err := row.Scan(
+		&u.ID, &u.Username, &u.TeamName, &u.IsActive, &u.Seniority, &u.MaxOpenReviews,
+		&u.Timezone, &workStart, &workEnd, &workDays, &u.ReviewWeight, &u.IsTrainee,
+	); err != nil {
+		return nil, err
+	}
+	if workStart != nil && workEnd != nil && workDays != nil {
+		u.Schedule = &entity.WorkSchedule{
+			Start:    *workStart,
+			End:      *workEnd,
+			Weekdays: uint8(*workDays),
+		}
+	}
+	return &u, nil
+}
+
+// ConflictRepository реализует repo.ConflictRepository с использованием PostgreSQL
+type ConflictRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewConflictRepository создает новый ConflictRepository
+func NewConflictRepository(pool *pgxpool.Pool) *ConflictRepository {
+	return &ConflictRepository{pool: pool}
+}
+
+// Save сохраняет пару и заполняет её ID
+func (r *ConflictRepository) Save(ctx context.Context, conflict *entity.ReviewConflict) error {
+	if conflict == nil {
+		return errors.New("review conflict is nil")
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO review_conflicts (user_id, other_user_id, reason)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, conflict.UserID, conflict.OtherUserID, conflict.Reason)
+	if err := row.Scan(&conflict.ID); err != nil {
+		if isUniqueViolation(err) {
+			return repo.ErrAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+// List возвращает все пары в порядке добавления
+func (r *ConflictRepository) List(ctx context.Context) ([]*entity.ReviewConflict, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, other_user_id, reason
+		FROM review_conflicts
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*entity.ReviewConflict
+	for rows.Next() {
+		var c entity.ReviewConflict
+		if err := rows.Scan(&c.ID, &c.UserID, &c.OtherUserID, &c.Reason); err != nil {
+			return nil, err
+		}
+		result = append(result, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete удаляет пару
+func (r *ConflictRepository) Delete(ctx context.Context, id int64) error {
+	cmdTag, err := r.pool.Exec(ctx, `
+		DELETE FROM review_conflicts
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// ConflictingUserIDs возвращает вторых участников всех пар с userID
+func (r *ConflictRepository) ConflictingUserIDs(ctx context.Context, userID string) (map[string]struct{}, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT other_user_id FROM review_conflicts WHERE user_id = $1
+		UNION
+		SELECT user_id FROM review_conflicts WHERE other_user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result[id] = struct{}{}
+	}
+
+	return result, rows.Err()
+}
+
+// RotationRepository реализует repo.RotationRepository с использованием PostgreSQL
+type RotationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRotationRepository создает новый RotationRepository
+func NewRotationRepository(pool *pgxpool.Pool) *RotationRepository {
+	return &RotationRepository{pool: pool}
+}
+
+// Last возвращает курсор команды, "" - если его ещё нет
+func (r *RotationRepository) Last(ctx context.Context, teamName string) (string, error) {
+	var last string
+	err := r.pool.QueryRow(ctx, `
+		SELECT last_user_id
+		FROM round_robin_cursors
+		WHERE team_name = $1
+	`, teamName).Scan(&last)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return last, err
+}
+
+// Save сохраняет курсоры команд в одной транзакции
+func (r *RotationRepository) Save(ctx context.Context, cursors map[string]string) error {
+	if len(cursors) == 0 {
+		return nil
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for teamName, last := range cursors {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO round_robin_cursors (team_name, last_user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (team_name) DO UPDATE
+			SET last_user_id = EXCLUDED.last_user_id, updated_at = NOW()
+		`, teamName, last); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReviewRulesRepository реализует repo.ReviewRulesRepository с использованием PostgreSQL
+type ReviewRulesRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewReviewRulesRepository создает новый ReviewRulesRepository
+func NewReviewRulesRepository(pool *pgxpool.Pool) *ReviewRulesRepository {
+	return &ReviewRulesRepository{pool: pool}
+}
+
+// Get возвращает исходный текст правил команды, разбирает его вызывающий
+func (r *ReviewRulesRepository) Get(ctx context.Context, teamName string) (*entity.TeamReviewRules, error) {
+	rules := &entity.TeamReviewRules{TeamName: teamName}
+	if err := r.pool.QueryRow(ctx, `
+		SELECT content
+		FROM team_review_rules
+		WHERE team_name = $1
+	`, teamName).Scan(&rules.Content); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Save сохраняет или заменяет текст правил команды
+func (r *ReviewRulesRepository) Save(ctx context.Context, rules *entity.TeamReviewRules) error {
+	if rules == nil {
+		return errors.New("review rules is nil")
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO team_review_rules (team_name, content)
+		VALUES ($1, $2)
+		ON CONFLICT (team_name) DO UPDATE
+		SET content = EXCLUDED.content,
+		    updated_at = NOW()
+	`, rules.TeamName, rules.Content)
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return false
+}
+
+// textArray заменяет nil на пустой срез: колонки TEXT[] объявлены NOT NULL
+func textArray(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -23,7 +23,8 @@ func newTestServer(t *testing.T) *httptest.Server {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	t.Cleanup(cancel)
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	require.NoError(t, err)
 
 	pool, err := postgresql.NewPool(ctx, cfg.DB.ConnString())
 	require.NoError(t, err, "failed to create pgx pool")
@@ -34,14 +35,28 @@ func newTestServer(t *testing.T) *httptest.Server {
 	userRepo := postgresql.NewUserRepository(pool)
 	teamRepo := postgresql.NewTeamRepository(pool)
 	prRepo := postgresql.NewPullRequestRepository(pool)
+	ownershipRepo := postgresql.NewOwnershipRepository(pool)
+	codeOwnersRepo := postgresql.NewCodeOwnersRepository(pool)
+	conflictRepo := postgresql.NewConflictRepository(pool)
+	reviewRulesRepo := postgresql.NewReviewRulesRepository(pool)
 
 	teamSvc := usecase.NewTeamService(userRepo, teamRepo)
 	userSvc := usecase.NewUserService(userRepo)
-	prSvc := usecase.NewPullRequestService(prRepo, userRepo, teamRepo)
+	prSvc := usecase.NewPullRequestService(
+		prRepo, userRepo, teamRepo,
+		usecase.WithOwnershipRules(ownershipRepo),
+		usecase.WithCodeOwners(codeOwnersRepo),
+		usecase.WithConflicts(conflictRepo),
+		usecase.WithReviewRules(reviewRulesRepo),
+	)
 	statsSvc := usecase.NewStatsService(pool)
 	teamMaintSvc := usecase.NewTeamMaintenanceService(pool)
+	ownershipSvc := usecase.NewOwnershipService(ownershipRepo, userRepo, teamRepo)
+	codeOwnersSvc := usecase.NewCodeOwnersService(codeOwnersRepo, userRepo, teamRepo)
+	conflictSvc := usecase.NewConflictService(conflictRepo, userRepo)
+	reviewRulesSvc := usecase.NewReviewRulesService(reviewRulesRepo, userRepo, teamRepo)
 
-	apiServer := httpapi.NewServer(teamSvc, userSvc, prSvc, statsSvc, teamMaintSvc)
+	apiServer := httpapi.NewServer(teamSvc, userSvc, prSvc, statsSvc, teamMaintSvc, ownershipSvc, codeOwnersSvc, conflictSvc, reviewRulesSvc)
 	mux := http.NewServeMux()
 	apiServer.RegisterRoutes(mux)
 
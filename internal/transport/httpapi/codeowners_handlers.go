@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (s *Server) handleCodeOwners(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleCodeOwnersList(w, r)
+	case http.MethodPost:
+		s.handleCodeOwnersImport(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleCodeOwnersList(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.codeOwnersService.List(r.Context())
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Rules []CodeOwnersRuleDTO `json:"rules"`
+	}{
+		Rules: codeOwnersRulesToDTO(rules),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleCodeOwnersImport(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req codeOwnersImportRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	res, err := s.codeOwnersService.Import(r.Context(), req.Content)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	unknown := append([]string{}, res.UnknownOwners...)
+	resp := struct {
+		Rules         []CodeOwnersRuleDTO `json:"rules"`
+		UnknownOwners []string            `json:"unknown_owners"`
+	}{
+		Rules:         codeOwnersRulesToDTO(res.Rules),
+		UnknownOwners: unknown,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vandermeer0/pr-reviewer/internal/usecase"
+)
+
+func (s *Server) handleConflicts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleConflictList(w, r)
+	case http.MethodPost:
+		s.handleConflictCreate(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleConflictList(w http.ResponseWriter, r *http.Request) {
+	conflicts, err := s.conflictService.ListConflicts(r.Context())
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	dtos := make([]ReviewConflictDTO, 0, len(conflicts))
+	for _, c := range conflicts {
+		if c == nil {
+			continue
+		}
+		dtos = append(dtos, *reviewConflictToDTO(c))
+	}
+
+	resp := struct {
+		Conflicts []ReviewConflictDTO `json:"conflicts"`
+	}{
+		Conflicts: dtos,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleConflictCreate(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req reviewConflictCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" || req.OtherUserID == "" {
+		http.Error(w, "user_id and other_user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	conflict, err := s.conflictService.AddConflict(r.Context(), usecase.ReviewConflictInput{
+		UserID:      req.UserID,
+		OtherUserID: req.OtherUserID,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Conflict *ReviewConflictDTO `json:"conflict"`
+	}{
+		Conflict: reviewConflictToDTO(conflict),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleConflictDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req reviewConflictDeleteRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ID == 0 {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.conflictService.DeleteConflict(r.Context(), req.ID); err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/vandermeer0/pr-reviewer/internal/entity"
@@ -8,9 +9,11 @@ import (
 
 // TeamMemberDTO представляет участника команды в HTTP JSON
 type TeamMemberDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	IsActive  bool   `json:"is_active"`
+	Seniority string `json:"seniority,omitempty"`
+	IsTrainee bool   `json:"is_trainee,omitempty"`
 }
 
 // TeamDTO представляет команду и её участников в HTTP JSON
@@ -21,21 +24,83 @@ type TeamDTO struct {
 
 // UserDTO представляет пользователя в HTTP JSON
 type UserDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string           `json:"user_id"`
+	Username       string           `json:"username"`
+	TeamName       string           `json:"team_name"`
+	IsActive       bool             `json:"is_active"`
+	Seniority      string           `json:"seniority,omitempty"`
+	MaxOpenReviews *int             `json:"max_open_reviews,omitempty"`
+	Timezone       string           `json:"timezone,omitempty"`
+	WorkingHours   *WorkingHoursDTO `json:"working_hours,omitempty"`
+	ReviewWeight   float64          `json:"review_weight"`
+	IsTrainee      bool             `json:"is_trainee,omitempty"`
+}
+
+// WorkingHoursDTO представляет рабочие часы пользователя в HTTP JSON
+type WorkingHoursDTO struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days"`
 }
 
 // PullRequestDTO представляет PR со списком ревьюверов в HTTP JSON
 type PullRequestDTO struct {
-	PullRequestID     string     `json:"pull_request_id"`
-	PullRequestName   string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         time.Time  `json:"createdAt"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID     string                `json:"pull_request_id"`
+	PullRequestName   string                `json:"pull_request_name"`
+	AuthorID          string                `json:"author_id"`
+	Status            string                `json:"status"`
+	AssignedReviewers []string              `json:"assigned_reviewers"`
+	RequiredReviewers []RequiredReviewerDTO `json:"required_reviewers,omitempty"`
+	FallbackReviewers []string              `json:"fallback_reviewers,omitempty"`
+	RuleReviewers     []string              `json:"rule_reviewers,omitempty"`
+	ShadowReviewers   []string              `json:"shadow_reviewers,omitempty"`
+	TargetReviewers   int                   `json:"target_reviewers"`
+	Understaffed      bool                  `json:"understaffed"`
+	CreatedAt         time.Time             `json:"createdAt"`
+	MergedAt          *time.Time            `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time            `json:"closedAt,omitempty"`
+}
+
+// RequiredReviewerDTO представляет обязательного ревьювера и правило CODEOWNERS
+type RequiredReviewerDTO struct {
+	UserID  string `json:"user_id"`
+	Pattern string `json:"pattern"`
+}
+
+// AssignmentTraceDTO представляет трассировку назначения ревьюверов в HTTP JSON
+type AssignmentTraceDTO struct {
+	ID                 int64                    `json:"id"`
+	Action             string                   `json:"action"`
+	ReplacedReviewerID string                   `json:"replaced_reviewer_id,omitempty"`
+	Reviewers          []string                 `json:"reviewers"`
+	Steps              []AssignmentTraceStepDTO `json:"steps"`
+	CreatedAt          time.Time                `json:"createdAt"`
+}
+
+// AssignmentTraceStepDTO представляет один подбор из группы кандидатов
+type AssignmentTraceStepDTO struct {
+	Stage      string              `json:"stage"`
+	Scope      string              `json:"scope,omitempty"`
+	TeamName   string              `json:"team_name"`
+	Strategy   string              `json:"strategy,omitempty"`
+	Seeds      []int64             `json:"seeds,omitempty"`
+	Candidates []TraceCandidateDTO `json:"candidates"`
+	Excluded   []TraceExclusionDTO `json:"excluded"`
+	Selected   []string            `json:"selected"`
+}
+
+// TraceCandidateDTO представляет кандидата, прошедшего фильтры
+type TraceCandidateDTO struct {
+	UserID       string `json:"user_id"`
+	OpenReviews  int    `json:"open_reviews"`
+	WorkingHours bool   `json:"working_hours"`
+	PairReviews  int    `json:"pair_reviews,omitempty"`
+}
+
+// TraceExclusionDTO представляет отсеянного пользователя и причину
+type TraceExclusionDTO struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
 }
 
 // PullRequestShortDTO представляет краткие данные о PR для ревьювера
@@ -44,13 +109,124 @@ type PullRequestShortDTO struct {
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
 	Status          string `json:"status"`
+	// ReviewerAway - ревьювер сейчас в отпуске, открытое ревью стоит переназначить
+	ReviewerAway bool `json:"reviewer_away,omitempty"`
+	// Shadow - пользователь назначен теневым ревьювером и только наблюдает
+	Shadow bool `json:"shadow,omitempty"`
+}
+
+// OutOfOfficeDTO представляет период отсутствия пользователя в HTTP JSON
+type OutOfOfficeDTO struct {
+	ID       int64     `json:"id"`
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// OwnershipRuleDTO представляет правило владения путями в HTTP JSON
+type OwnershipRuleDTO struct {
+	ID       int64  `json:"id"`
+	Pattern  string `json:"pattern"`
+	UserID   string `json:"user_id,omitempty"`
+	TeamName string `json:"team_name,omitempty"`
+}
+
+// ReviewConflictDTO представляет пару пользователей в конфликте интересов в HTTP JSON
+type ReviewConflictDTO struct {
+	ID          int64  `json:"id"`
+	UserID      string `json:"user_id"`
+	OtherUserID string `json:"other_user_id"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// CodeOwnersRuleDTO представляет строку CODEOWNERS в HTTP JSON
+type CodeOwnersRuleDTO struct {
+	Line    int      `json:"line"`
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// TeamReviewRulesDTO представляет правила команды в HTTP JSON
+type TeamReviewRulesDTO struct {
+	TeamName string          `json:"team_name"`
+	Content  string          `json:"content"`
+	Rules    []ReviewRuleDTO `json:"rules"`
+}
+
+// ReviewRuleDTO представляет разобранное правило команды, поля как в YAML
+type ReviewRuleDTO struct {
+	Line int                    `json:"line"`
+	Name string                 `json:"name"`
+	When ReviewRuleConditionDTO `json:"when"`
+	Then ReviewRuleActionDTO    `json:"then"`
+}
+
+// ReviewRuleConditionDTO представляет условия правила
+type ReviewRuleConditionDTO struct {
+	Label           string `json:"label,omitempty"`
+	NamePrefix      string `json:"name_prefix,omitempty"`
+	AuthorSeniority string `json:"author_seniority,omitempty"`
+	ChangedPath     string `json:"changed_path,omitempty"`
+}
+
+// ReviewRuleActionDTO представляет действия правила
+type ReviewRuleActionDTO struct {
+	Reviewers          *int                   `json:"reviewers,omitempty"`
+	MinSeniorReviewers int                    `json:"min_senior_reviewers,omitempty"`
+	MinJuniorReviewers int                    `json:"min_junior_reviewers,omitempty"`
+	AddFromTeam        *ReviewRuleAdditionDTO `json:"add_from_team,omitempty"`
+}
+
+// ReviewRuleAdditionDTO представляет ревьюверов, добавляемых правилом из другой команды
+type ReviewRuleAdditionDTO struct {
+	Rule     string `json:"rule,omitempty"`
+	TeamName string `json:"team"`
+	Count    int    `json:"count"`
+}
+
+// ReviewRuleErrorDTO представляет ошибку в тексте правил
+type ReviewRuleErrorDTO struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
 }
 
 // ReviewerStatDTO представляет статистику ревьювера в HTTP JSON
 type ReviewerStatDTO struct {
 	UserID      string `json:"user_id"`
 	Username    string `json:"username"`
+	TeamName    string `json:"team_name"`
+	IsActive    bool   `json:"is_active"`
+	IsTrainee   bool   `json:"is_trainee"`
 	Assignments int64  `json:"assignments"`
+	// ShadowAssignments - теневые назначения, в assignments и доли не входят
+	ShadowAssignments int64   `json:"shadow_assignments"`
+	ReviewWeight      float64 `json:"review_weight"`
+	ExpectedShare     float64 `json:"expected_share"`
+	ActualShare       float64 `json:"actual_share"`
+}
+
+// TeamSettingsDTO представляет настройки команды в HTTP JSON
+type TeamSettingsDTO struct {
+	TeamName           string   `json:"team_name"`
+	MinReviewers       int      `json:"min_reviewers"`
+	MaxReviewers       int      `json:"max_reviewers"`
+	MaxOpenReviews     *int     `json:"max_open_reviews"`
+	MinSeniorReviewers int      `json:"min_senior_reviewers"`
+	MinJuniorReviewers int      `json:"min_junior_reviewers"`
+	FallbackTeams      []string `json:"fallback_teams"`
+}
+
+// teamSettingsUpdateRequest описывает запрос на изменение настроек команды,
+// отсутствующие поля не меняются, max_open_reviews: null снимает лимит
+type teamSettingsUpdateRequest struct {
+	TeamName           string          `json:"team_name"`
+	MinReviewers       *int            `json:"min_reviewers"`
+	MaxReviewers       *int            `json:"max_reviewers"`
+	MaxOpenReviews     json.RawMessage `json:"max_open_reviews"`
+	MinSeniorReviewers *int            `json:"min_senior_reviewers"`
+	MinJuniorReviewers *int            `json:"min_junior_reviewers"`
+	FallbackTeams      *[]string       `json:"fallback_teams"`
 }
 
 // teamDeactivateMembersRequest описывает запрос на массовую деактивацию
@@ -67,26 +243,214 @@ type teamDeactivateMembersResponse struct {
 	AffectedPullRequests int    `json:"affected_pull_requests"`
 }
 
+// teamRebalanceRequest описывает запрос на выравнивание нагрузки команды
+// TopUpPullRequestDTO - PR, в который при доборе назначены новые ревьюверы, в HTTP JSON
+type TopUpPullRequestDTO struct {
+	PullRequestID string   `json:"pull_request_id"`
+	NewReviewers  []string `json:"new_reviewers"`
+}
+
+// teamAddMembersResponse описывает результат добавления участников в команду
+type teamAddMembersResponse struct {
+	TeamName       string                `json:"team_name"`
+	Members        []TeamMemberDTO       `json:"members"`
+	NewAssignments int64                 `json:"new_assignments"`
+	PullRequests   []TopUpPullRequestDTO `json:"pull_requests"`
+}
+
+// userActivateResponse описывает результат активации пользователя с добором ревьюверов
+type userActivateResponse struct {
+	User           *UserDTO              `json:"user"`
+	NewAssignments int64                 `json:"new_assignments"`
+	PullRequests   []TopUpPullRequestDTO `json:"pull_requests"`
+}
+
+type teamRebalanceRequest struct {
+	TeamName string `json:"team_name"`
+	// Tolerance - допустимый разброс, nil - usecase.DefaultRebalanceTolerance
+	Tolerance *int `json:"tolerance"`
+	DryRun    bool `json:"dry_run"`
+}
+
+// RebalanceMoveDTO - перенос назначения ревью в HTTP JSON
+type RebalanceMoveDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+	FromUserID    string `json:"from_user_id"`
+	ToUserID      string `json:"to_user_id"`
+	// FromOpenReviews, ToOpenReviews - открытые ревью участников перед переносом
+	FromOpenReviews int `json:"from_open_reviews"`
+	ToOpenReviews   int `json:"to_open_reviews"`
+}
+
+// teamRebalanceResponse описывает результат выравнивания нагрузки
+type teamRebalanceResponse struct {
+	TeamName     string             `json:"team_name"`
+	DryRun       bool               `json:"dry_run"`
+	SpreadBefore int                `json:"spread_before"`
+	SpreadAfter  int                `json:"spread_after"`
+	Moves        []RebalanceMoveDTO `json:"moves"`
+}
+
 type setIsActiveRequest struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
+	// ReleaseReviews - при деактивации снять пользователя с открытых PR и добрать замену
+	ReleaseReviews bool `json:"release_reviews"`
+}
+
+// userDeactivateResponse описывает результат деактивации пользователя с перераспределением ревью
+type userDeactivateResponse struct {
+	User                 *UserDTO `json:"user"`
+	RemovedAssignments   int64    `json:"removed_assignments"`
+	NewAssignments       int64    `json:"new_assignments"`
+	AffectedPullRequests int      `json:"affected_pull_requests"`
+}
+
+type setMaxOpenReviewsRequest struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type setReviewWeightRequest struct {
+	UserID       string   `json:"user_id"`
+	ReviewWeight *float64 `json:"review_weight"`
+}
+
+type setTraineeRequest struct {
+	UserID    string `json:"user_id"`
+	IsTrainee bool   `json:"is_trainee"`
+}
+
+type setSeniorityRequest struct {
+	UserID    string `json:"user_id"`
+	Seniority string `json:"seniority"`
+}
+
+type userHandoffRequest struct {
+	UserID       string `json:"user_id"`
+	TargetUserID string `json:"target_user_id"`
+	Deactivate   bool   `json:"deactivate"`
+}
+
+// HandoffPullRequestDTO - итог передачи ревью одного PR в HTTP JSON
+type HandoffPullRequestDTO struct {
+	PullRequestID string     `json:"pull_request_id"`
+	NewReviewerID string     `json:"new_reviewer_id,omitempty"`
+	Error         *errorBody `json:"error,omitempty"`
+}
+
+type setScheduleRequest struct {
+	UserID       string           `json:"user_id"`
+	Timezone     string           `json:"timezone"`
+	WorkingHours *WorkingHoursDTO `json:"working_hours"`
+}
+
+type outOfOfficeCreateRequest struct {
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+type outOfOfficeDeleteRequest struct {
+	UserID string `json:"user_id"`
+	ID     int64  `json:"id"`
+}
+
+type ownershipRuleCreateRequest struct {
+	Pattern  string `json:"pattern"`
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+}
+
+type ownershipRuleDeleteRequest struct {
+	ID int64 `json:"id"`
+}
+
+type reviewConflictCreateRequest struct {
+	UserID      string `json:"user_id"`
+	OtherUserID string `json:"other_user_id"`
+	Reason      string `json:"reason"`
+}
+
+type reviewConflictDeleteRequest struct {
+	ID int64 `json:"id"`
+}
+
+type codeOwnersImportRequest struct {
+	Content string `json:"content"`
+}
+
+type teamRulesSaveRequest struct {
+	TeamName string `json:"team_name"`
+	Content  string `json:"content"`
+}
+
+type teamRulesValidateRequest struct {
+	Content string `json:"content"`
+}
+
+type teamRulesValidateResponse struct {
+	Valid  bool                 `json:"valid"`
+	Errors []ReviewRuleErrorDTO `json:"errors"`
+	Rules  []ReviewRuleDTO      `json:"rules"`
+}
+
+// teamRulesTestRequest - гипотетический PR, content - непроверенный текст правил вместо сохранённых
+type teamRulesTestRequest struct {
+	AuthorID        string   `json:"author_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	Labels          []string `json:"labels"`
+	ChangedFiles    []string `json:"changed_files"`
+	Content         *string  `json:"content"`
+}
+
+type teamRulesTestResponse struct {
+	TeamName     string                  `json:"team_name"`
+	MatchedRules []string                `json:"matched_rules"`
+	Settings     *TeamSettingsDTO        `json:"settings"`
+	Additions    []ReviewRuleAdditionDTO `json:"additions"`
 }
 
 type pullRequestCreateRequest struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID      string   `json:"pull_request_id"`
+	PullRequestName    string   `json:"pull_request_name"`
+	AuthorID           string   `json:"author_id"`
+	ChangedFiles       []string `json:"changed_files"`
+	RequestedReviewers []string `json:"requested_reviewers"`
+	Labels             []string `json:"labels"`
+	Draft              bool     `json:"draft"`
+}
+
+type pullRequestReadyRequest struct {
+	PullRequestID      string   `json:"pull_request_id"`
+	ChangedFiles       []string `json:"changed_files"`
+	RequestedReviewers []string `json:"requested_reviewers"`
+	Labels             []string `json:"labels"`
 }
 
 type pullRequestMergeRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
 
+type pullRequestCloseRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
+type pullRequestReopenRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
 type pullRequestReassignRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldUserID     string `json:"old_user_id"`
 }
 
+type pullRequestReviewerRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+}
+
 func teamToDTO(team *entity.Team) *TeamDTO {
 	if team == nil {
 		return nil
@@ -97,9 +461,11 @@ func teamToDTO(team *entity.Team) *TeamDTO {
 			continue
 		}
 		members = append(members, TeamMemberDTO{
-			UserID:   m.ID,
-			Username: m.Username,
-			IsActive: m.IsActive,
+			UserID:    m.ID,
+			Username:  m.Username,
+			IsActive:  m.IsActive,
+			Seniority: string(m.Seniority),
+			IsTrainee: m.IsTrainee,
 		})
 	}
 	return &TeamDTO{
@@ -108,15 +474,97 @@ func teamToDTO(team *entity.Team) *TeamDTO {
 	}
 }
 
+func teamSettingsToDTO(st *entity.TeamSettings) *TeamSettingsDTO {
+	if st == nil {
+		return nil
+	}
+	return &TeamSettingsDTO{
+		TeamName:           st.TeamName,
+		MinReviewers:       st.MinReviewers,
+		MaxReviewers:       st.MaxReviewers,
+		MaxOpenReviews:     st.MaxOpenReviews,
+		MinSeniorReviewers: st.MinSeniorReviewers,
+		MinJuniorReviewers: st.MinJuniorReviewers,
+		FallbackTeams:      append([]string{}, st.FallbackTeams...),
+	}
+}
+
 func userToDTO(u *entity.User) *UserDTO {
 	if u == nil {
 		return nil
 	}
 	return &UserDTO{
-		UserID:   u.ID,
-		Username: u.Username,
-		TeamName: u.TeamName,
-		IsActive: u.IsActive,
+		UserID:         u.ID,
+		Username:       u.Username,
+		TeamName:       u.TeamName,
+		IsActive:       u.IsActive,
+		Seniority:      string(u.Seniority),
+		MaxOpenReviews: u.MaxOpenReviews,
+		Timezone:       u.Timezone,
+		WorkingHours:   workingHoursToDTO(u.Schedule),
+		ReviewWeight:   u.EffectiveReviewWeight(),
+		IsTrainee:      u.IsTrainee,
+	}
+}
+
+func workingHoursToDTO(w *entity.WorkSchedule) *WorkingHoursDTO {
+	if w == nil {
+		return nil
+	}
+	days := make([]string, 0, 7)
+	for _, d := range w.Days() {
+		days = append(days, d.String()[:3])
+	}
+	return &WorkingHoursDTO{
+		Start: entity.FormatClock(w.Start),
+		End:   entity.FormatClock(w.End),
+		Days:  days,
+	}
+}
+
+// workingHoursFromDTO разбирает рабочие часы из запроса, пустой список дней - пн-пт
+func workingHoursFromDTO(dto *WorkingHoursDTO) (*entity.WorkSchedule, error) {
+	if dto == nil {
+		return nil, nil
+	}
+	start, err := entity.ParseClock(dto.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := entity.ParseClock(dto.End)
+	if err != nil {
+		return nil, err
+	}
+
+	days := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	if len(dto.Days) > 0 {
+		days = days[:0]
+		for _, name := range dto.Days {
+			d, err := entity.ParseWeekday(name)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, d)
+		}
+	}
+
+	return &entity.WorkSchedule{
+		Start:    start,
+		End:      end,
+		Weekdays: entity.WeekdayMask(days...),
+	}, nil
+}
+
+func outOfOfficeToDTO(o *entity.OutOfOffice) *OutOfOfficeDTO {
+	if o == nil {
+		return nil
+	}
+	return &OutOfOfficeDTO{
+		ID:       o.ID,
+		UserID:   o.UserID,
+		StartsAt: o.StartsAt,
+		EndsAt:   o.EndsAt,
+		Reason:   o.Reason,
 	}
 }
 
@@ -128,13 +576,160 @@ func pullRequestToDTO(pr *entity.PullRequest) *PullRequestDTO {
 	reviewers := make([]string, 0, len(pr.Reviewers))
 	reviewers = append(reviewers, pr.Reviewers...)
 
+	var required []RequiredReviewerDTO
+	for _, r := range pr.RequiredReviewers {
+		required = append(required, RequiredReviewerDTO{UserID: r.UserID, Pattern: r.Pattern})
+	}
+
 	return &PullRequestDTO{
 		PullRequestID:     pr.ID,
 		PullRequestName:   pr.Name,
 		AuthorID:          pr.AuthorID,
 		Status:            string(pr.Status),
 		AssignedReviewers: reviewers,
+		RequiredReviewers: required,
+		FallbackReviewers: append([]string(nil), pr.FallbackReviewers...),
+		RuleReviewers:     append([]string(nil), pr.RuleReviewers...),
+		ShadowReviewers:   append([]string(nil), pr.ShadowReviewers...),
+		TargetReviewers:   pr.TargetReviewers,
+		Understaffed:      pr.IsUnderstaffed(),
 		CreatedAt:         pr.CreatedAt,
 		MergedAt:          pr.MergedAt,
+		ClosedAt:          pr.ClosedAt,
+	}
+}
+
+func ownershipRuleToDTO(rule *entity.OwnershipRule) *OwnershipRuleDTO {
+	if rule == nil {
+		return nil
+	}
+	return &OwnershipRuleDTO{
+		ID:       rule.ID,
+		Pattern:  rule.Pattern,
+		UserID:   rule.UserID,
+		TeamName: rule.TeamName,
+	}
+}
+
+func reviewConflictToDTO(conflict *entity.ReviewConflict) *ReviewConflictDTO {
+	if conflict == nil {
+		return nil
+	}
+	return &ReviewConflictDTO{
+		ID:          conflict.ID,
+		UserID:      conflict.UserID,
+		OtherUserID: conflict.OtherUserID,
+		Reason:      conflict.Reason,
+	}
+}
+
+func codeOwnersRulesToDTO(rules []*entity.CodeOwnersRule) []CodeOwnersRuleDTO {
+	dtos := make([]CodeOwnersRuleDTO, 0, len(rules))
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		owners := append([]string{}, rule.Owners...)
+		dtos = append(dtos, CodeOwnersRuleDTO{
+			Line:    rule.Line,
+			Pattern: rule.Pattern,
+			Owners:  owners,
+		})
+	}
+	return dtos
+}
+
+func teamReviewRulesToDTO(rules *entity.TeamReviewRules) *TeamReviewRulesDTO {
+	if rules == nil {
+		return nil
+	}
+	return &TeamReviewRulesDTO{
+		TeamName: rules.TeamName,
+		Content:  rules.Content,
+		Rules:    reviewRulesToDTO(rules.Rules),
+	}
+}
+
+func reviewRulesToDTO(rules []*entity.ReviewRule) []ReviewRuleDTO {
+	dtos := make([]ReviewRuleDTO, 0, len(rules))
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		dto := ReviewRuleDTO{
+			Line: rule.Line,
+			Name: rule.Name,
+			When: ReviewRuleConditionDTO{
+				Label:           rule.When.Label,
+				NamePrefix:      rule.When.NamePrefix,
+				AuthorSeniority: string(rule.When.AuthorSeniority),
+				ChangedPath:     rule.When.ChangedPath,
+			},
+			Then: ReviewRuleActionDTO{
+				Reviewers:          rule.Then.Reviewers,
+				MinSeniorReviewers: rule.Then.MinSeniorReviewers,
+				MinJuniorReviewers: rule.Then.MinJuniorReviewers,
+			},
+		}
+		if add := rule.Then.AddFromTeam; add != nil {
+			dto.Then.AddFromTeam = &ReviewRuleAdditionDTO{TeamName: add.TeamName, Count: add.Count}
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos
+}
+
+func reviewRuleErrorsToDTO(errs []entity.ReviewRuleError) []ReviewRuleErrorDTO {
+	dtos := make([]ReviewRuleErrorDTO, 0, len(errs))
+	for _, e := range errs {
+		dtos = append(dtos, ReviewRuleErrorDTO{Line: e.Line, Message: e.Message})
+	}
+	return dtos
+}
+
+func reviewRuleAdditionsToDTO(additions []entity.ReviewRuleAddition) []ReviewRuleAdditionDTO {
+	dtos := make([]ReviewRuleAdditionDTO, 0, len(additions))
+	for _, add := range additions {
+		dtos = append(dtos, ReviewRuleAdditionDTO{Rule: add.Rule, TeamName: add.TeamName, Count: add.Count})
+	}
+	return dtos
+}
+
+func assignmentTracesToDTO(traces []*entity.AssignmentTrace) []AssignmentTraceDTO {
+	dtos := make([]AssignmentTraceDTO, 0, len(traces))
+	for _, t := range traces {
+		if t == nil {
+			continue
+		}
+		steps := make([]AssignmentTraceStepDTO, 0, len(t.Steps))
+		for _, st := range t.Steps {
+			candidates := make([]TraceCandidateDTO, 0, len(st.Candidates))
+			for _, c := range st.Candidates {
+				candidates = append(candidates, TraceCandidateDTO(c))
+			}
+			excluded := make([]TraceExclusionDTO, 0, len(st.Excluded))
+			for _, e := range st.Excluded {
+				excluded = append(excluded, TraceExclusionDTO(e))
+			}
+			steps = append(steps, AssignmentTraceStepDTO{
+				Stage:      st.Stage,
+				Scope:      st.Scope,
+				TeamName:   st.TeamName,
+				Strategy:   st.Strategy,
+				Seeds:      append([]int64(nil), st.Seeds...),
+				Candidates: candidates,
+				Excluded:   excluded,
+				Selected:   append([]string{}, st.Selected...),
+			})
+		}
+		dtos = append(dtos, AssignmentTraceDTO{
+			ID:                 t.ID,
+			Action:             string(t.Action),
+			ReplacedReviewerID: t.ReplacedReviewerID,
+			Reviewers:          append([]string{}, t.Reviewers...),
+			Steps:              steps,
+			CreatedAt:          t.CreatedAt,
+		})
 	}
+	return dtos
 }
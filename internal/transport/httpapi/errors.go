@@ -9,8 +9,14 @@ import (
 )
 
 type errorBody struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details []errorDetailBody `json:"details,omitempty"`
+}
+
+type errorDetailBody struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
 }
 
 type errorResponse struct {
@@ -30,14 +36,23 @@ func (s *Server) handleError(w http.ResponseWriter, err error) {
 
 func httpStatusForCode(code usecase.ErrorCode) int {
 	switch code {
-	case usecase.ErrorCodeTeamExists:
+	case usecase.ErrorCodeTeamExists,
+		usecase.ErrorCodeInvalidInput,
+		usecase.ErrorCodeInvalidReviewers:
 		return http.StatusBadRequest
 	case usecase.ErrorCodeNotFound:
 		return http.StatusNotFound
 	case usecase.ErrorCodePRExists,
 		usecase.ErrorCodePRMerged,
+		usecase.ErrorCodePRClosed,
+		usecase.ErrorCodePRDraft,
 		usecase.ErrorCodeNotAssigned,
-		usecase.ErrorCodeNoCandidate:
+		usecase.ErrorCodeAlreadyAssigned,
+		usecase.ErrorCodeUserInactive,
+		usecase.ErrorCodeReviewerRequired,
+		usecase.ErrorCodeConflictOfInterest,
+		usecase.ErrorCodeNoCandidate,
+		usecase.ErrorCodeNoCapacity:
 		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
@@ -47,13 +62,19 @@ func httpStatusForCode(code usecase.ErrorCode) int {
 func writeDomainError(w http.ResponseWriter, status int, de *usecase.DomainError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	resp := errorResponse{
-		Error: errorBody{
-			Code:    string(de.Code),
-			Message: de.Message,
-		},
-	}
+	resp := errorResponse{Error: errorBodyFor(de)}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, "failed to encode error response", http.StatusInternalServerError)
 	}
 }
+
+func errorBodyFor(de *usecase.DomainError) errorBody {
+	body := errorBody{
+		Code:    string(de.Code),
+		Message: de.Message,
+	}
+	for _, d := range de.Details {
+		body.Details = append(body.Details, errorDetailBody(d))
+	}
+	return body
+}
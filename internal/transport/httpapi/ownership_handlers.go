@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vandermeer0/pr-reviewer/internal/usecase"
+)
+
+func (s *Server) handleOwnership(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleOwnershipList(w, r)
+	case http.MethodPost:
+		s.handleOwnershipCreate(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleOwnershipList(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.ownershipService.ListRules(r.Context())
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	dtos := make([]OwnershipRuleDTO, 0, len(rules))
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		dtos = append(dtos, *ownershipRuleToDTO(rule))
+	}
+
+	resp := struct {
+		Rules []OwnershipRuleDTO `json:"rules"`
+	}{
+		Rules: dtos,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleOwnershipCreate(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req ownershipRuleCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Pattern == "" {
+		http.Error(w, "pattern is required", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := s.ownershipService.AddRule(r.Context(), usecase.OwnershipRuleInput{
+		Pattern:  req.Pattern,
+		UserID:   req.UserID,
+		TeamName: req.TeamName,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Rule *OwnershipRuleDTO `json:"rule"`
+	}{
+		Rule: ownershipRuleToDTO(rule),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleOwnershipDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req ownershipRuleDeleteRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ID == 0 {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.ownershipService.DeleteRule(r.Context(), req.ID); err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
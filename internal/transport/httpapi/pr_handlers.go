@@ -24,9 +24,13 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 	}
 
 	input := usecase.PullRequestCreateInput{
-		ID:       req.PullRequestID,
-		Name:     req.PullRequestName,
-		AuthorID: req.AuthorID,
+		ID:                 req.PullRequestID,
+		Name:               req.PullRequestName,
+		AuthorID:           req.AuthorID,
+		ChangedFiles:       req.ChangedFiles,
+		RequestedReviewers: req.RequestedReviewers,
+		Labels:             req.Labels,
+		Draft:              req.Draft,
 	}
 
 	pr, err := s.prService.Create(r.Context(), input)
@@ -48,6 +52,45 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (s *Server) handlePullRequestReadyForReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req pullRequestReadyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PullRequestID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.prService.ReadyForReview(r.Context(), usecase.PullRequestReadyInput{
+		ID:                 req.PullRequestID,
+		ChangedFiles:       req.ChangedFiles,
+		RequestedReviewers: req.RequestedReviewers,
+		Labels:             req.Labels,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR *PullRequestDTO `json:"pr"`
+	}{
+		PR: pullRequestToDTO(pr),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) handlePullRequestMerge(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -82,6 +125,74 @@ func (s *Server) handlePullRequestMerge(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (s *Server) handlePullRequestClose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req pullRequestCloseRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PullRequestID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.prService.Close(r.Context(), req.PullRequestID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR *PullRequestDTO `json:"pr"`
+	}{
+		PR: pullRequestToDTO(pr),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handlePullRequestReopen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req pullRequestReopenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PullRequestID == "" {
+		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.prService.Reopen(r.Context(), req.PullRequestID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR *PullRequestDTO `json:"pr"`
+	}{
+		PR: pullRequestToDTO(pr),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -117,3 +228,103 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+func (s *Server) handlePullRequestAddReviewer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req pullRequestReviewerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PullRequestID == "" || req.UserID == "" {
+		http.Error(w, "pull_request_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.prService.AddReviewer(r.Context(), req.PullRequestID, req.UserID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR *PullRequestDTO `json:"pr"`
+	}{
+		PR: pullRequestToDTO(pr),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handlePullRequestRemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req pullRequestReviewerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PullRequestID == "" || req.UserID == "" {
+		http.Error(w, "pull_request_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := s.prService.RemoveReviewer(r.Context(), req.PullRequestID, req.UserID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR *PullRequestDTO `json:"pr"`
+	}{
+		PR: pullRequestToDTO(pr),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handlePullRequestAssignmentTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		http.Error(w, "pull_request_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	traces, err := s.prService.GetAssignmentTraces(r.Context(), prID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		PullRequestID string               `json:"pull_request_id"`
+		Traces        []AssignmentTraceDTO `json:"traces"`
+	}{
+		PullRequestID: prID,
+		Traces:        assignmentTracesToDTO(traces),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,146 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vandermeer0/pr-reviewer/internal/usecase"
+)
+
+func (s *Server) handleTeamRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleTeamRulesGet(w, r)
+	case http.MethodPost:
+		s.handleTeamRulesSave(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTeamRulesGet(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		http.Error(w, "team_name query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := s.reviewRulesService.GetRules(r.Context(), teamName)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Rules *TeamReviewRulesDTO `json:"rules"`
+	}{
+		Rules: teamReviewRulesToDTO(rules),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeamRulesSave(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req teamRulesSaveRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.TeamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := s.reviewRulesService.SaveRules(r.Context(), req.TeamName, req.Content)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Rules *TeamReviewRulesDTO `json:"rules"`
+	}{
+		Rules: teamReviewRulesToDTO(rules),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleTeamRulesValidate отвечает 200 и для некорректного текста, ошибки перечисляются в теле
+func (s *Server) handleTeamRulesValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req teamRulesValidateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	res, err := s.reviewRulesService.Validate(r.Context(), req.Content)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := teamRulesValidateResponse{
+		Valid:  len(res.Errors) == 0,
+		Errors: reviewRuleErrorsToDTO(res.Errors),
+		Rules:  reviewRulesToDTO(res.Rules),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeamRulesTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req teamRulesTestRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.AuthorID == "" {
+		http.Error(w, "author_id is required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.reviewRulesService.Test(r.Context(), usecase.ReviewRulesTestInput{
+		AuthorID:     req.AuthorID,
+		Name:         req.PullRequestName,
+		Labels:       req.Labels,
+		ChangedFiles: req.ChangedFiles,
+		Content:      req.Content,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := teamRulesTestResponse{
+		TeamName:     res.TeamName,
+		MatchedRules: append([]string{}, res.Matched...),
+		Settings:     teamSettingsToDTO(res.Settings),
+		Additions:    reviewRuleAdditionsToDTO(res.Additions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
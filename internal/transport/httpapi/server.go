@@ -14,6 +14,10 @@ type Server struct {
 	prService              usecase.PullRequestService
 	statsService           usecase.StatsService
 	teamMaintenanceService usecase.TeamMaintenanceService
+	ownershipService       usecase.OwnershipService
+	codeOwnersService      usecase.CodeOwnersService
+	conflictService        usecase.ConflictService
+	reviewRulesService     usecase.ReviewRulesService
 }
 
 // NewServer conсоздаёт HTTP-сервер с переданными доменными сервисами
@@ -23,6 +27,10 @@ func NewServer(
 	prSvc usecase.PullRequestService,
 	statsSvc usecase.StatsService,
 	teamMaintSvc usecase.TeamMaintenanceService,
+	ownershipSvc usecase.OwnershipService,
+	codeOwnersSvc usecase.CodeOwnersService,
+	conflictSvc usecase.ConflictService,
+	reviewRulesSvc usecase.ReviewRulesService,
 ) *Server {
 	return &Server{
 		teamService:            teamSvc,
@@ -30,6 +38,10 @@ func NewServer(
 		prService:              prSvc,
 		statsService:           statsSvc,
 		teamMaintenanceService: teamMaintSvc,
+		ownershipService:       ownershipSvc,
+		codeOwnersService:      codeOwnersSvc,
+		conflictService:        conflictSvc,
+		reviewRulesService:     reviewRulesSvc,
 	}
 }
 
@@ -40,14 +52,40 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 
 	mux.HandleFunc("/team/add", s.handleTeamAdd)
 	mux.HandleFunc("/team/get", s.handleTeamGet)
+	mux.HandleFunc("/team/addMembers", s.handleTeamAddMembers)
 	mux.HandleFunc("/team/deactivateMembers", s.handleTeamDeactivateMembers)
+	mux.HandleFunc("/team/settings", s.handleTeamSettings)
+	mux.HandleFunc("/team/rebalance", s.handleTeamRebalance)
+	mux.HandleFunc("/team/rules", s.handleTeamRules)
+	mux.HandleFunc("/team/rules/validate", s.handleTeamRulesValidate)
+	mux.HandleFunc("/team/rules/test", s.handleTeamRulesTest)
 
 	mux.HandleFunc("/users/setIsActive", s.handleSetIsActive)
+	mux.HandleFunc("/users/setMaxOpenReviews", s.handleSetMaxOpenReviews)
+	mux.HandleFunc("/users/setReviewWeight", s.handleSetReviewWeight)
+	mux.HandleFunc("/users/setTrainee", s.handleSetTrainee)
+	mux.HandleFunc("/users/setSeniority", s.handleSetSeniority)
+	mux.HandleFunc("/users/setSchedule", s.handleSetSchedule)
+	mux.HandleFunc("/users/handoff", s.handleUserHandoff)
 	mux.HandleFunc("/users/getReview", s.handleGetUserReview)
+	mux.HandleFunc("/users/outOfOffice", s.handleOutOfOffice)
+	mux.HandleFunc("/users/outOfOffice/delete", s.handleOutOfOfficeDelete)
 
 	mux.HandleFunc("/pullRequest/create", s.handlePullRequestCreate)
+	mux.HandleFunc("/pullRequest/readyForReview", s.handlePullRequestReadyForReview)
 	mux.HandleFunc("/pullRequest/merge", s.handlePullRequestMerge)
+	mux.HandleFunc("/pullRequest/close", s.handlePullRequestClose)
+	mux.HandleFunc("/pullRequest/reopen", s.handlePullRequestReopen)
 	mux.HandleFunc("/pullRequest/reassign", s.handlePullRequestReassign)
+	mux.HandleFunc("/pullRequest/addReviewer", s.handlePullRequestAddReviewer)
+	mux.HandleFunc("/pullRequest/removeReviewer", s.handlePullRequestRemoveReviewer)
+	mux.HandleFunc("/pullRequest/assignmentTrace", s.handlePullRequestAssignmentTrace)
+
+	mux.HandleFunc("/ownership", s.handleOwnership)
+	mux.HandleFunc("/ownership/delete", s.handleOwnershipDelete)
+	mux.HandleFunc("/repos/codeowners", s.handleCodeOwners)
+	mux.HandleFunc("/conflicts", s.handleConflicts)
+	mux.HandleFunc("/conflicts/delete", s.handleConflictDelete)
 
 	mux.HandleFunc("/stats/reviewers", s.handleStatsReviewers)
 }
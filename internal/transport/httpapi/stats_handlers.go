@@ -20,9 +20,16 @@ func (s *Server) handleStatsReviewers(w http.ResponseWriter, r *http.Request) {
 	reviewers := make([]ReviewerStatDTO, 0, len(stats))
 	for _, st := range stats {
 		reviewers = append(reviewers, ReviewerStatDTO{
-			UserID:      st.UserID,
-			Username:    st.Username,
-			Assignments: st.Assignments,
+			UserID:            st.UserID,
+			Username:          st.Username,
+			TeamName:          st.TeamName,
+			IsActive:          st.IsActive,
+			IsTrainee:         st.IsTrainee,
+			Assignments:       st.Assignments,
+			ShadowAssignments: st.ShadowAssignments,
+			ReviewWeight:      st.ReviewWeight,
+			ExpectedShare:     st.ExpectedShare,
+			ActualShare:       st.ActualShare,
 		})
 	}
 
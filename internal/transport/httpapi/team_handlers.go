@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
 	"github.com/vandermeer0/pr-reviewer/internal/usecase"
 )
 
@@ -23,16 +24,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members := make([]usecase.CreateTeamMemberInput, 0, len(dto.Members))
-	for _, m := range dto.Members {
-		members = append(members, usecase.CreateTeamMemberInput{
-			UserID:   m.UserID,
-			Username: m.Username,
-			IsActive: m.IsActive,
-		})
-	}
-
-	team, err := s.teamService.CreateTeam(r.Context(), dto.TeamName, members)
+	team, err := s.teamService.CreateTeam(r.Context(), dto.TeamName, teamMembersFromDTO(dto.Members))
 	if err != nil {
 		s.handleError(w, err)
 		return
@@ -51,6 +43,68 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleTeamAddMembers добавляет участников в существующую команду
+// и добирает их в недоукомплектованные открытые PR команды
+func (s *Server) handleTeamAddMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var dto TeamDTO
+	if !decodeJSON(w, r, &dto) {
+		return
+	}
+	if dto.TeamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.teamMaintenanceService.AddTeamMembers(r.Context(), dto.TeamName, teamMembersFromDTO(dto.Members))
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := teamAddMembersResponse{
+		TeamName:       res.TeamName,
+		Members:        teamToDTO(&entity.Team{Name: res.TeamName, Members: res.Members}).Members,
+		NewAssignments: res.NewAssignments,
+		PullRequests:   topUpPullRequestsToDTO(res.PullRequests),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func teamMembersFromDTO(members []TeamMemberDTO) []usecase.CreateTeamMemberInput {
+	out := make([]usecase.CreateTeamMemberInput, 0, len(members))
+	for _, m := range members {
+		out = append(out, usecase.CreateTeamMemberInput{
+			UserID:    m.UserID,
+			Username:  m.Username,
+			IsActive:  m.IsActive,
+			Seniority: entity.Seniority(m.Seniority),
+			IsTrainee: m.IsTrainee,
+		})
+	}
+	return out
+}
+
+func topUpPullRequestsToDTO(prs []usecase.TopUpPullRequest) []TopUpPullRequestDTO {
+	out := make([]TopUpPullRequestDTO, 0, len(prs))
+	for _, pr := range prs {
+		out = append(out, TopUpPullRequestDTO{
+			PullRequestID: pr.PullRequestID,
+			NewReviewers:  append([]string{}, pr.NewReviewers...),
+		})
+	}
+	return out
+}
+
 func (s *Server) handleTeamGet(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -112,3 +166,137 @@ func (s *Server) handleTeamDeactivateMembers(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+func (s *Server) handleTeamRebalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req teamRebalanceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.TeamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	opts := usecase.RebalanceOptions{
+		Tolerance: usecase.DefaultRebalanceTolerance,
+		DryRun:    req.DryRun,
+	}
+	if req.Tolerance != nil {
+		opts.Tolerance = *req.Tolerance
+	}
+
+	res, err := s.teamMaintenanceService.RebalanceTeam(r.Context(), req.TeamName, opts)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := teamRebalanceResponse{
+		TeamName:     res.TeamName,
+		DryRun:       res.DryRun,
+		SpreadBefore: res.SpreadBefore,
+		SpreadAfter:  res.SpreadAfter,
+		Moves:        make([]RebalanceMoveDTO, 0, len(res.Moves)),
+	}
+	for _, m := range res.Moves {
+		resp.Moves = append(resp.Moves, RebalanceMoveDTO(m))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeamSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleTeamSettingsGet(w, r)
+	case http.MethodPost:
+		s.handleTeamSettingsUpdate(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTeamSettingsGet(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		http.Error(w, "team_name query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := s.teamService.GetSettings(r.Context(), teamName)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Settings *TeamSettingsDTO `json:"settings"`
+	}{
+		Settings: teamSettingsToDTO(settings),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleTeamSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req teamSettingsUpdateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.TeamName == "" {
+		http.Error(w, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	input := usecase.TeamSettingsInput{
+		TeamName:           req.TeamName,
+		MinReviewers:       req.MinReviewers,
+		MaxReviewers:       req.MaxReviewers,
+		MinSeniorReviewers: req.MinSeniorReviewers,
+		MinJuniorReviewers: req.MinJuniorReviewers,
+		FallbackTeams:      req.FallbackTeams,
+	}
+	switch {
+	case len(req.MaxOpenReviews) == 0:
+	case string(req.MaxOpenReviews) == "null":
+		input.ClearMaxOpenReviews = true
+	default:
+		var limit int
+		if err := json.Unmarshal(req.MaxOpenReviews, &limit); err != nil {
+			http.Error(w, "max_open_reviews must be an integer or null", http.StatusBadRequest)
+			return
+		}
+		input.MaxOpenReviews = &limit
+	}
+
+	settings, err := s.teamService.UpdateSettings(r.Context(), input)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		Settings *TeamSettingsDTO `json:"settings"`
+	}{
+		Settings: teamSettingsToDTO(settings),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
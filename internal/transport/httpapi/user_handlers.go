@@ -3,6 +3,9 @@ package httpapi
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase"
 )
 
 func (s *Server) handleSetIsActive(w http.ResponseWriter, r *http.Request) {
@@ -20,6 +23,18 @@ func (s *Server) handleSetIsActive(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "user_id is required", http.StatusBadRequest)
 		return
 	}
+	if req.ReleaseReviews {
+		if req.IsActive {
+			http.Error(w, "release_reviews requires is_active=false", http.StatusBadRequest)
+			return
+		}
+		s.handleDeactivateUser(w, r, req.UserID)
+		return
+	}
+	if req.IsActive {
+		s.handleActivateUser(w, r, req.UserID)
+		return
+	}
 
 	user, err := s.userService.SetIsActive(r.Context(), req.UserID, req.IsActive)
 	if err != nil {
@@ -39,6 +54,288 @@ func (s *Server) handleSetIsActive(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleDeactivateUser деактивирует пользователя и перераспределяет его открытые ревью
+func (s *Server) handleDeactivateUser(w http.ResponseWriter, r *http.Request, userID string) {
+	res, err := s.teamMaintenanceService.DeactivateUser(r.Context(), userID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := userDeactivateResponse{
+		User:                 userToDTO(res.User),
+		RemovedAssignments:   res.RemovedAssignments,
+		NewAssignments:       res.NewAssignments,
+		AffectedPullRequests: res.AffectedPullRequests,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleActivateUser активирует пользователя и добирает его в недоукомплектованные PR команды
+func (s *Server) handleActivateUser(w http.ResponseWriter, r *http.Request, userID string) {
+	res, err := s.teamMaintenanceService.ActivateUser(r.Context(), userID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := userActivateResponse{
+		User:           userToDTO(res.User),
+		NewAssignments: res.NewAssignments,
+		PullRequests:   topUpPullRequestsToDTO(res.PullRequests),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSetMaxOpenReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req setMaxOpenReviewsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.SetMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		User *UserDTO `json:"user"`
+	}{
+		User: userToDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSetReviewWeight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req setReviewWeightRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+	if req.ReviewWeight == nil {
+		http.Error(w, "review_weight is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.SetReviewWeight(r.Context(), req.UserID, *req.ReviewWeight)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		User *UserDTO `json:"user"`
+	}{
+		User: userToDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSetTrainee(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req setTraineeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.SetTrainee(r.Context(), req.UserID, req.IsTrainee)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		User *UserDTO `json:"user"`
+	}{
+		User: userToDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSetSeniority(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req setSeniorityRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.SetSeniority(r.Context(), req.UserID, entity.Seniority(req.Seniority))
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		User *UserDTO `json:"user"`
+	}{
+		User: userToDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleUserHandoff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req userHandoffRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.teamMaintenanceService.HandoffReviews(r.Context(), usecase.ReviewHandoffInput{
+		UserID:       req.UserID,
+		TargetUserID: req.TargetUserID,
+		Deactivate:   req.Deactivate,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	results := make([]HandoffPullRequestDTO, 0, len(res.PullRequests))
+	for _, pr := range res.PullRequests {
+		dto := HandoffPullRequestDTO{
+			PullRequestID: pr.PullRequestID,
+			NewReviewerID: pr.NewReviewerID,
+		}
+		if pr.Err != nil {
+			body := errorBodyFor(pr.Err)
+			dto.Error = &body
+		}
+		results = append(results, dto)
+	}
+
+	resp := struct {
+		UserID              string                  `json:"user_id"`
+		Deactivated         bool                    `json:"deactivated"`
+		DeactivationSkipped bool                    `json:"deactivation_skipped"`
+		PullRequests        []HandoffPullRequestDTO `json:"pull_requests"`
+	}{
+		UserID:              res.UserID,
+		Deactivated:         res.Deactivated,
+		DeactivationSkipped: res.DeactivationSkipped,
+		PullRequests:        results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSetSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req setScheduleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := workingHoursFromDTO(req.WorkingHours)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.SetSchedule(r.Context(), usecase.UserScheduleInput{
+		UserID:   req.UserID,
+		Timezone: req.Timezone,
+		Schedule: schedule,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		User *UserDTO `json:"user"`
+	}{
+		User: userToDTO(user),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) handleGetUserReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -57,6 +354,12 @@ func (s *Server) handleGetUserReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	away, err := s.userService.CurrentOutOfOffice(r.Context(), userID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
 	short := make([]PullRequestShortDTO, 0, len(prs))
 	for _, pr := range prs {
 		if pr == nil {
@@ -67,14 +370,18 @@ func (s *Server) handleGetUserReview(w http.ResponseWriter, r *http.Request) {
 			PullRequestName: pr.Name,
 			AuthorID:        pr.AuthorID,
 			Status:          string(pr.Status),
+			ReviewerAway:    away != nil && pr.Status == entity.StatusOpen,
+			Shadow:          pr.HasShadowReviewer(userID),
 		})
 	}
 
 	resp := struct {
 		UserID       string                `json:"user_id"`
+		OutOfOffice  *OutOfOfficeDTO       `json:"out_of_office,omitempty"`
 		PullRequests []PullRequestShortDTO `json:"pull_requests"`
 	}{
 		UserID:       userID,
+		OutOfOffice:  outOfOfficeToDTO(away),
 		PullRequests: short,
 	}
 
@@ -83,3 +390,109 @@ func (s *Server) handleGetUserReview(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+func (s *Server) handleOutOfOffice(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleOutOfOfficeList(w, r)
+	case http.MethodPost:
+		s.handleOutOfOfficeCreate(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleOutOfOfficeList(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	periods, err := s.userService.ListOutOfOffice(r.Context(), userID)
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	dtos := make([]OutOfOfficeDTO, 0, len(periods))
+	for _, p := range periods {
+		if p == nil {
+			continue
+		}
+		dtos = append(dtos, *outOfOfficeToDTO(p))
+	}
+
+	resp := struct {
+		UserID      string           `json:"user_id"`
+		OutOfOffice []OutOfOfficeDTO `json:"out_of_office"`
+	}{
+		UserID:      userID,
+		OutOfOffice: dtos,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleOutOfOfficeCreate(w http.ResponseWriter, r *http.Request) {
+	defer closeRequestBody(r)
+
+	var req outOfOfficeCreateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	period, err := s.userService.AddOutOfOffice(r.Context(), usecase.OutOfOfficeInput{
+		UserID:   req.UserID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	resp := struct {
+		OutOfOffice *OutOfOfficeDTO `json:"out_of_office"`
+	}{
+		OutOfOffice: outOfOfficeToDTO(period),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleOutOfOfficeDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer closeRequestBody(r)
+
+	var req outOfOfficeDeleteRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" || req.ID == 0 {
+		http.Error(w, "user_id and id are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.userService.DeleteOutOfOffice(r.Context(), req.UserID, req.ID); err != nil {
+		s.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+// TopUpPullRequest - PR, в который при доборе назначены новые ревьюверы
+type TopUpPullRequest struct {
+	PullRequestID string
+	NewReviewers  []string
+}
+
+// UserActivationResult описывает результат активации пользователя с добором ревьюверов
+type UserActivationResult struct {
+	User           *entity.User
+	NewAssignments int64
+	PullRequests   []TopUpPullRequest
+}
+
+// TeamMembersAddResult описывает результат добавления участников в команду
+type TeamMembersAddResult struct {
+	TeamName       string
+	Members        []*entity.User
+	NewAssignments int64
+	PullRequests   []TopUpPullRequest
+}
+
+// ActivateUser активирует пользователя и назначает его в недоукомплектованные
+// открытые PR авторов его команды в той же транзакции
+func (s *teamMaintenanceServiceImpl) ActivateUser(ctx context.Context, userID string) (res UserActivationResult, err error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var teamName string
+	if err = tx.QueryRow(ctx, `
+			UPDATE users
+			SET is_active = TRUE
+			WHERE id = $1
+			RETURNING team_name
+	`, userID).Scan(&teamName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, NewNotFoundError("user not found")
+		}
+		return res, err
+	}
+
+	res.NewAssignments, res.PullRequests, err = s.topUpTeamTx(ctx, tx, teamName, map[string]struct{}{userID: {}})
+	if err != nil {
+		return res, err
+	}
+
+	users, err := loadUsersTx(ctx, tx, []string{userID})
+	if err != nil {
+		return res, err
+	}
+	res.User = users[userID]
+
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// AddTeamMembers добавляет пользователей в существующую команду и назначает активных
+// из них в недоукомплектованные открытые PR авторов команды
+// Пользователь из другой команды переходит в эту, его лимиты, вес и расписание сохраняются
+func (s *teamMaintenanceServiceImpl) AddTeamMembers(
+	ctx context.Context,
+	teamName string,
+	members []CreateTeamMemberInput,
+) (res TeamMembersAddResult, err error) {
+	res.TeamName = teamName
+	if len(members) == 0 {
+		return res, NewInvalidInputError("members are required")
+	}
+
+	users := make([]*entity.User, 0, len(members))
+	seen := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		u := &entity.User{
+			ID:        m.UserID,
+			Username:  m.Username,
+			TeamName:  teamName,
+			IsActive:  m.IsActive,
+			Seniority: m.Seniority,
+			IsTrainee: m.IsTrainee,
+		}
+		if err := u.Validate(); err != nil {
+			return res, NewInvalidInputError(err.Error())
+		}
+		if _, dup := seen[u.ID]; dup {
+			return res, NewInvalidInputError("duplicate member " + u.ID)
+		}
+		seen[u.ID] = struct{}{}
+		users = append(users, u)
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var exists bool
+	if err = tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM teams WHERE name = $1)
+	`, teamName).Scan(&exists); err != nil {
+		return res, err
+	}
+	if !exists {
+		return res, NewNotFoundError("team not found")
+	}
+
+	ids := make([]string, 0, len(users))
+	available := make(map[string]struct{})
+	for _, u := range users {
+		if _, err = tx.Exec(ctx, `
+				INSERT INTO users (id, username, team_name, is_active, seniority, is_trainee)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (id) DO UPDATE
+				SET username = EXCLUDED.username,
+				    team_name = EXCLUDED.team_name,
+				    is_active = EXCLUDED.is_active,
+				    seniority = EXCLUDED.seniority,
+				    is_trainee = EXCLUDED.is_trainee
+		`, u.ID, u.Username, u.TeamName, u.IsActive, string(u.Seniority), u.IsTrainee); err != nil {
+			return res, err
+		}
+		ids = append(ids, u.ID)
+		if u.IsActive {
+			available[u.ID] = struct{}{}
+		}
+	}
+
+	if len(available) > 0 {
+		res.NewAssignments, res.PullRequests, err = s.topUpTeamTx(ctx, tx, teamName, available)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	saved, err := loadUsersTx(ctx, tx, ids)
+	if err != nil {
+		return res, err
+	}
+	for _, id := range ids {
+		res.Members = append(res.Members, saved[id])
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// topUpTeamTx добирает ревьюверов из only в открытые PR авторов команды,
+// где назначено меньше target_reviewers
+func (s *teamMaintenanceServiceImpl) topUpTeamTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	teamName string,
+	only map[string]struct{},
+) (int64, []TopUpPullRequest, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT pr.id
+			FROM pull_requests pr
+			JOIN users author ON author.id = pr.author_id
+			WHERE pr.status = 'OPEN'
+				AND author.team_name = $1
+				AND pr.target_reviewers > (
+					SELECT COUNT(*) FROM pr_reviewers prr WHERE prr.pull_request_id = pr.id AND NOT prr.from_rule
+				)
+			ORDER BY pr.created_at, pr.id
+			FOR UPDATE OF pr
+	`, teamName)
+	if err != nil {
+		return 0, nil, err
+	}
+	var prIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		prIDs = append(prIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(prIDs) == 0 {
+		return 0, nil, nil
+	}
+
+	// подбор только из новых участников - подмножество команды, курсор команды не сдвигается
+	return s.topUpReviewers(ctx, tx, prIDs, only, nil)
+}
@@ -0,0 +1,552 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// AssignmentOption настраивает подбор ревьюверов в сервисах
+type AssignmentOption func(*reviewerAssigner)
+
+// WithReviewerSelectors задаёт стратегии выбора ревьюверов по командам
+func WithReviewerSelectors(selectors *ReviewerSelectors) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		if selectors != nil {
+			a.selectors = selectors
+		}
+	}
+}
+
+// DefaultPairHistoryWindow - за какой период учитываются пары автор/ревьювер
+const DefaultPairHistoryWindow = 30 * 24 * time.Hour
+
+// WithPairHistoryWindow задаёт окно истории пар автор/ревьювер, 0 - история не учитывается
+func WithPairHistoryWindow(window time.Duration) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		if window >= 0 {
+			a.pairWindow = window
+		}
+	}
+}
+
+// reviewerAssigner содержит общую для всех сценариев логику подбора:
+// фильтрацию кандидатов и вызов стратегии команды
+type reviewerAssigner struct {
+	selectors *ReviewerSelectors
+	clock     Clock
+	// pairWindow - окно истории пар автор/ревьювер
+	pairWindow time.Duration
+	// ownership - правила владения путями, nil - владельцы не учитываются
+	ownership repo.OwnershipRepository
+	// codeOwners - импортированный CODEOWNERS, nil - обязательных ревьюверов нет
+	codeOwners repo.CodeOwnersRepository
+	// conflicts - пары в конфликте интересов, nil - конфликты не учитываются
+	conflicts repo.ConflictRepository
+	// reviewRules - правила команд по атрибутам PR, nil - правила не применяются
+	reviewRules repo.ReviewRulesRepository
+	// shadowSelector - стратегия выбора теневых ревьюверов, общая для всех команд
+	shadowSelector ReviewerSelector
+}
+
+func newReviewerAssigner(opts []AssignmentOption) *reviewerAssigner {
+	a := &reviewerAssigner{
+		selectors:      defaultReviewerSelectors(),
+		clock:          systemClock{},
+		pairWindow:     DefaultPairHistoryWindow,
+		shadowSelector: NewRandomSelector(time.Now().UnixNano()),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// ExclusionReason - причина по которой пользователь не попал в кандидаты
+type ExclusionReason string
+
+const (
+	// ExclusionInactive - пользователь неактивен
+	ExclusionInactive ExclusionReason = "inactive"
+	// ExclusionAuthor - пользователь автор PR
+	ExclusionAuthor ExclusionReason = "author"
+	// ExclusionConflict - пользователь в конфликте интересов с автором
+	ExclusionConflict ExclusionReason = "conflict"
+	// ExclusionAlreadyAssigned - пользователь уже ревьювер этого PR
+	ExclusionAlreadyAssigned ExclusionReason = "already_assigned"
+	// ExclusionTrainee - стажёр, автоматически назначается только теневым ревьювером
+	ExclusionTrainee ExclusionReason = "trainee"
+	// ExclusionOutOfOffice - у пользователя сейчас период отсутствия
+	ExclusionOutOfOffice ExclusionReason = "out_of_office"
+	// ExclusionOverCapacity - пользователь исчерпал лимит открытых ревью
+	ExclusionOverCapacity ExclusionReason = "over_capacity"
+)
+
+// ExcludedCandidate - пользователь отсеянный при подборе и причина
+type ExcludedCandidate struct {
+	UserID string
+	Reason ExclusionReason
+}
+
+// assignmentRequest - данные для одного подбора ревьюверов
+type assignmentRequest struct {
+	TeamName string
+	// PoolTeam - команда, из которой взяты Members, пусто - TeamName
+	PoolTeam string
+	AuthorID string
+	Members  []*entity.User
+	// Exclude - пользователи которых нельзя выбирать (уже назначены или заменяются)
+	Exclude map[string]struct{}
+	// Conflicts - пользователи в конфликте интересов с автором
+	Conflicts map[string]struct{}
+	// Away - пользователи в периоде отсутствия
+	Away map[string]struct{}
+	// Loads - количество открытых ревью у пользователей
+	Loads map[string]int
+	// TeamReviewLimit - лимит открытых ревью команды, nil - без лимита
+	TeamReviewLimit *int
+	// OtherTeamLimits - лимиты команд для кандидатов не из TeamName
+	OtherTeamLimits map[string]*int
+	// PairHistory - история ревью PR автора кандидатами за окно
+	PairHistory map[string]entity.ReviewPairHistory
+	Count       int
+	// Selector - стратегия выбора, nil - стратегия команды TeamName
+	Selector ReviewerSelector
+	// Shadow - подбор теневых ревьюверов, стажёры не отсеиваются
+	Shadow bool
+	// Stage, Scope - подпись шага в трассировке назначения
+	Stage string
+	Scope string
+	// Cursors - курсоры round_robin операции, ещё не сохранённые: подбор из пула команды
+	// читает и сдвигает здесь курсор PoolTeam; nil - подмножество команды (владельцы,
+	// уровень опыта, правила), такой подбор курсор не сдвигает
+	Cursors map[string]string
+}
+
+// assignmentResult - итог подбора
+type assignmentResult struct {
+	Reviewers []string
+	Excluded  []ExcludedCandidate
+	// Fallback - ревьюверы из Reviewers, взятые из запасных команд
+	Fallback []string
+	// Trace - шаги подбора для трассировки назначения
+	Trace []entity.AssignmentTraceStep
+}
+
+// merge добавляет к результату итог следующего подбора
+func (r *assignmentResult) merge(other assignmentResult) {
+	r.Reviewers = append(r.Reviewers, other.Reviewers...)
+	r.Excluded = append(r.Excluded, other.Excluded...)
+	r.Fallback = append(r.Fallback, other.Fallback...)
+	r.Trace = append(r.Trace, other.Trace...)
+}
+
+// hasExclusion сообщает был ли кто-то отсеян по указанной причине
+func (r assignmentResult) hasExclusion(reason ExclusionReason) bool {
+	for _, e := range r.Excluded {
+		if e.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// shortageError возвращает доменную ошибку для ситуации когда кандидатов не хватило
+func (r assignmentResult) shortageError(msg string) *DomainError {
+	if r.hasExclusion(ExclusionOverCapacity) {
+		return NewNoCapacityError(msg + ": candidates reached their open review limit")
+	}
+	return NewNoCandidateError(msg)
+}
+
+// pick подбирает ревьюверов, их может оказаться меньше чем Count
+// Сначала стратегия выбирает среди тех у кого сейчас рабочее время,
+// недостающих добирает из остальных
+func (a *reviewerAssigner) pick(ctx context.Context, req assignmentRequest) (assignmentResult, error) {
+	var res assignmentResult
+	if req.Count <= 0 {
+		return res, nil
+	}
+
+	step := entity.AssignmentTraceStep{
+		Stage:      req.Stage,
+		Scope:      req.Scope,
+		TeamName:   req.TeamName,
+		Candidates: []entity.TraceCandidate{},
+		Excluded:   []entity.TraceExclusion{},
+		Selected:   []string{},
+	}
+
+	now := a.clock.Now()
+	var working, offHours []ReviewerCandidate
+	for _, m := range req.Members {
+		if m == nil {
+			continue
+		}
+		if reason, excluded := exclusionReason(req, m); excluded {
+			res.Excluded = append(res.Excluded, ExcludedCandidate{UserID: m.ID, Reason: reason})
+			step.Excluded = append(step.Excluded, entity.TraceExclusion{UserID: m.ID, Reason: string(reason)})
+			continue
+		}
+		c := ReviewerCandidate{
+			User:        m,
+			OpenReviews: req.Loads[m.ID],
+		}
+		if h, ok := req.PairHistory[m.ID]; ok && h.Reviews > 0 {
+			c.PairReviews = h.Reviews
+			c.PairFreshness = a.pairFreshness(now, h.LastAt)
+		}
+		isWorking := m.IsWorkingAt(now)
+		if isWorking {
+			working = append(working, c)
+		} else {
+			offHours = append(offHours, c)
+		}
+		step.Candidates = append(step.Candidates, entity.TraceCandidate{
+			UserID:       m.ID,
+			OpenReviews:  c.OpenReviews,
+			WorkingHours: isWorking,
+			PairReviews:  c.PairReviews,
+		})
+	}
+
+	selector := req.Selector
+	if selector == nil {
+		selector = a.selectors.ForTeam(req.TeamName)
+	}
+	poolTeam := req.PoolTeam
+	if poolTeam == "" {
+		poolTeam = req.TeamName
+	}
+
+	// несохранённый курсор этой операции важнее сохранённого
+	var cursor string
+	if req.Cursors != nil {
+		cursor = req.Cursors[poolTeam]
+	}
+
+	// стратегия вызывается один раз, чтобы курсор round_robin сдвигался один раз за подбор:
+	// если работающих не хватает, они берутся все, а стратегия выбирает из остальных
+	pool := working
+	if len(working) < req.Count && len(offHours) > 0 {
+		for _, c := range working {
+			res.Reviewers = append(res.Reviewers, c.User.ID)
+		}
+		pool = offHours
+	}
+	if need := req.Count - len(res.Reviewers); need > 0 && len(pool) > 0 {
+		selected, err := selector.Select(ctx, ReviewerSelectionInput{
+			TeamName:   poolTeam,
+			AuthorID:   req.AuthorID,
+			Candidates: pool,
+			Count:      need,
+			Cursor:     cursor,
+		})
+		if err != nil {
+			return res, err
+		}
+		if req.Cursors != nil && selected.Cursor != "" {
+			req.Cursors[poolTeam] = selected.Cursor
+		}
+		step.Strategy = string(selected.Strategy)
+		if selected.Seed != 0 {
+			step.Seeds = append(step.Seeds, selected.Seed)
+		}
+		for _, c := range selected.Reviewers {
+			res.Reviewers = append(res.Reviewers, c.User.ID)
+		}
+	}
+
+	step.Selected = append(step.Selected, res.Reviewers...)
+	res.Trace = append(res.Trace, step)
+	return res, nil
+}
+
+// saveCursors сохраняет курсоры round_robin после того, как назначение записано,
+// поэтому неудавшийся или откаченный подбор очередь не сдвигает
+// Курсор задаёт только очерёдность: если сохранить его не удалось, назначение остаётся в силе,
+// а следующий подбор начнёт с прежней позиции
+func (a *reviewerAssigner) saveCursors(ctx context.Context, cursors map[string]string) {
+	if len(cursors) == 0 {
+		return
+	}
+	_ = a.selectors.rotation.Save(ctx, cursors)
+}
+
+// pairFreshness переводит время последнего совместного ревью в долю окна: 1 - сейчас, 0 - на границе окна
+func (a *reviewerAssigner) pairFreshness(now, lastAt time.Time) float64 {
+	if a.pairWindow <= 0 {
+		return 0
+	}
+	age := now.Sub(lastAt)
+	if age <= 0 {
+		return 1
+	}
+	if age >= a.pairWindow {
+		return 0
+	}
+	return 1 - float64(age)/float64(a.pairWindow)
+}
+
+// pairHistorySince возвращает начало окна истории пар, false - история не учитывается
+func (a *reviewerAssigner) pairHistorySince() (time.Time, bool) {
+	if a.pairWindow <= 0 {
+		return time.Time{}, false
+	}
+	return a.clock.Now().Add(-a.pairWindow), true
+}
+
+// teamPool - участники запасной команды
+type teamPool struct {
+	TeamName string
+	Members  []*entity.User
+}
+
+// pickWithFallbacks подбирает ревьюверов из req.Members, а недостающих
+// добирает из запасных команд в заданном порядке
+func (a *reviewerAssigner) pickWithFallbacks(
+	ctx context.Context,
+	req assignmentRequest,
+	fallbacks []teamPool,
+) (assignmentResult, error) {
+	res, err := a.pick(ctx, req)
+	if err != nil {
+		return res, err
+	}
+
+	for _, pool := range fallbacks {
+		need := req.Count - len(res.Reviewers)
+		if need <= 0 {
+			break
+		}
+
+		exclude := idSet(res.Reviewers)
+		for id := range req.Exclude {
+			exclude[id] = struct{}{}
+		}
+
+		r := req
+		r.Members = pool.Members
+		r.Exclude = exclude
+		r.Count = need
+		r.PoolTeam = pool.TeamName
+		r.Stage = "fallback"
+		r.Scope = pool.TeamName
+		sub, err := a.pick(ctx, r)
+		if err != nil {
+			return res, err
+		}
+		sub.Fallback = sub.Reviewers
+		res.merge(sub)
+	}
+
+	return res, nil
+}
+
+func exclusionReason(req assignmentRequest, m *entity.User) (ExclusionReason, bool) {
+	if m.ID == req.AuthorID {
+		return ExclusionAuthor, true
+	}
+	if _, conflict := req.Conflicts[m.ID]; conflict {
+		return ExclusionConflict, true
+	}
+	if _, excluded := req.Exclude[m.ID]; excluded {
+		return ExclusionAlreadyAssigned, true
+	}
+	if !m.IsActive {
+		return ExclusionInactive, true
+	}
+	if m.IsTrainee && !req.Shadow {
+		return ExclusionTrainee, true
+	}
+	if _, away := req.Away[m.ID]; away {
+		return ExclusionOutOfOffice, true
+	}
+	teamLimit := req.TeamReviewLimit
+	if m.TeamName != req.TeamName {
+		if l, ok := req.OtherTeamLimits[m.TeamName]; ok {
+			teamLimit = l
+		}
+	}
+	if limit, ok := m.ReviewLimit(teamLimit); ok && req.Loads[m.ID] >= limit {
+		return ExclusionOverCapacity, true
+	}
+	return "", false
+}
+
+func memberIDs(members []*entity.User) []string {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		if m == nil {
+			continue
+		}
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func containsAnyUser(ids []string, users []*entity.User) bool {
+	set := idSet(ids)
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		if _, ok := set[u.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// firstAssigned возвращает первого из users, кто уже есть в ids
+func firstAssigned(ids []string, users []*entity.User) (string, bool) {
+	set := idSet(ids)
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		if _, ok := set[u.ID]; ok {
+			return u.ID, true
+		}
+	}
+	return "", false
+}
+
+// countAssigned считает сколько из users уже среди ids
+func countAssigned(ids []string, users []*entity.User) int {
+	set := idSet(ids)
+	n := 0
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		if _, ok := set[u.ID]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+func idSet(ids []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// withoutIDs возвращает ids без входящих в drop, порядок сохраняется
+func withoutIDs(ids []string, drop map[string]struct{}) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := drop[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// otherTeamLimits возвращает лимиты открытых ревью команд кандидатов не из teamName
+func otherTeamLimits(
+	ctx context.Context,
+	teamRepo repo.TeamRepository,
+	teamName string,
+	members []*entity.User,
+) (map[string]*int, error) {
+	limits := make(map[string]*int)
+	for _, m := range members {
+		if m == nil || m.TeamName == teamName {
+			continue
+		}
+		if _, ok := limits[m.TeamName]; ok {
+			continue
+		}
+		settings, err := teamSettingsOrDefault(ctx, teamRepo, m.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		limits[m.TeamName] = settings.MaxOpenReviews
+	}
+	return limits, nil
+}
+
+// loadPairHistory загружает историю пар автор/ревьювер за окно назначателя
+func loadPairHistory(
+	ctx context.Context,
+	a *reviewerAssigner,
+	prRepo repo.PullRequestRepository,
+	authorID string,
+	reviewerIDs []string,
+) (map[string]entity.ReviewPairHistory, error) {
+	since, ok := a.pairHistorySince()
+	if !ok {
+		return nil, nil
+	}
+	return prRepo.GetPairHistory(ctx, authorID, reviewerIDs, since)
+}
+
+// fallbackPools загружает участников запасных команд, несуществующие команды пропускаются
+func fallbackPools(ctx context.Context, teamRepo repo.TeamRepository, teamNames []string) ([]teamPool, error) {
+	pools := make([]teamPool, 0, len(teamNames))
+	for _, name := range teamNames {
+		team, err := teamRepo.GetByName(ctx, name)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		pools = append(pools, teamPool{TeamName: team.Name, Members: team.Members})
+	}
+	return pools, nil
+}
+
+func poolMembers(pools []teamPool) []*entity.User {
+	var out []*entity.User
+	for _, p := range pools {
+		out = append(out, p.Members...)
+	}
+	return out
+}
+
+// withSeniority оставляет пользователей указанного уровня
+func withSeniority(users []*entity.User, level entity.Seniority) []*entity.User {
+	var out []*entity.User
+	for _, u := range users {
+		if u != nil && u.Seniority == level {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func fallbackPoolsWithSeniority(pools []teamPool, level entity.Seniority) []teamPool {
+	out := make([]teamPool, 0, len(pools))
+	for _, p := range pools {
+		out = append(out, teamPool{TeamName: p.TeamName, Members: withSeniority(p.Members, level)})
+	}
+	return out
+}
+
+func seniorityIndex(users []*entity.User) map[string]entity.Seniority {
+	out := make(map[string]entity.Seniority, len(users))
+	for _, u := range users {
+		if u != nil {
+			out[u.ID] = u.Seniority
+		}
+	}
+	return out
+}
+
+func countSeniority(ids []string, seniority map[string]entity.Seniority, level entity.Seniority) int {
+	n := 0
+	for _, id := range ids {
+		if seniority[id] == level {
+			n++
+		}
+	}
+	return n
+}
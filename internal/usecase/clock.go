@@ -0,0 +1,23 @@
+package usecase
+
+import "time"
+
+// Clock - источник текущего времени, в тестах подменяется фиксированным
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// WithClock задаёт источник времени для подбора ревьюверов
+func WithClock(clock Clock) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		if clock != nil {
+			a.clock = clock
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// WithCodeOwners включает обязательных ревьюверов по импортированному CODEOWNERS
+func WithCodeOwners(codeOwnersRepo repo.CodeOwnersRepository) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		a.codeOwners = codeOwnersRepo
+	}
+}
+
+// CodeOwnersImportResult - итог импорта CODEOWNERS
+type CodeOwnersImportResult struct {
+	Rules []*entity.CodeOwnersRule
+	// UnknownOwners - владельцы из файла, которых нет среди пользователей и команд
+	UnknownOwners []string
+}
+
+type codeOwnersService struct {
+	codeOwnersRepo repo.CodeOwnersRepository
+	userRepo       repo.UserRepository
+	teamRepo       repo.TeamRepository
+}
+
+// NewCodeOwnersService создаёт реализацию CodeOwnersService
+func NewCodeOwnersService(
+	codeOwnersRepo repo.CodeOwnersRepository,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+) CodeOwnersService {
+	return &codeOwnersService{
+		codeOwnersRepo: codeOwnersRepo,
+		userRepo:       userRepo,
+		teamRepo:       teamRepo,
+	}
+}
+
+func (s *codeOwnersService) Import(ctx context.Context, content string) (*CodeOwnersImportResult, error) {
+	rules, err := entity.ParseCodeOwners(content)
+	if err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	if err := s.codeOwnersRepo.Replace(ctx, rules); err != nil {
+		return nil, err
+	}
+
+	res := &CodeOwnersImportResult{Rules: rules}
+	seen := make(map[string]struct{})
+	for _, rule := range rules {
+		for _, raw := range rule.Owners {
+			owner, ok := entity.ParseCodeOwner(raw)
+			if !ok {
+				continue
+			}
+			users, err := resolveCodeOwner(ctx, repoCodeOwnerLookup{userRepo: s.userRepo, teamRepo: s.teamRepo}, owner)
+			if err != nil {
+				return nil, err
+			}
+			if len(users) > 0 {
+				continue
+			}
+			if _, ok := seen[raw]; ok {
+				continue
+			}
+			seen[raw] = struct{}{}
+			res.UnknownOwners = append(res.UnknownOwners, raw)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *codeOwnersService) List(ctx context.Context) ([]*entity.CodeOwnersRule, error) {
+	return s.codeOwnersRepo.List(ctx)
+}
+
+// codeOwnerGroup - правило CODEOWNERS, подошедшее под пути PR, и его владельцы
+type codeOwnerGroup struct {
+	Pattern string
+	Owners  []*entity.User
+}
+
+// codeOwnerGroups возвращает правила, выигравшие хотя бы для одного из путей
+// Автор из владельцев исключается, правила без других владельцев пропускаются
+func codeOwnerGroups(
+	ctx context.Context,
+	codeOwnersRepo repo.CodeOwnersRepository,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+	paths []string,
+	authorID string,
+) ([]codeOwnerGroup, error) {
+	if codeOwnersRepo == nil || len(paths) == 0 {
+		return nil, nil
+	}
+
+	rules, err := codeOwnersRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []codeOwnerGroup
+	seen := make(map[int]struct{})
+	for _, path := range paths {
+		rule := entity.MatchCodeOwners(rules, path)
+		if rule == nil {
+			continue
+		}
+		if _, ok := seen[rule.Line]; ok {
+			continue
+		}
+		seen[rule.Line] = struct{}{}
+
+		owners, err := codeOwnersOf(ctx, userRepo, teamRepo, rule)
+		if err != nil {
+			return nil, err
+		}
+		owners = withoutUser(owners, authorID)
+		if len(owners) == 0 {
+			continue
+		}
+		groups = append(groups, codeOwnerGroup{Pattern: rule.Pattern, Owners: owners})
+	}
+
+	return groups, nil
+}
+
+// codeOwnerRuleByPattern возвращает последнее правило с таким шаблоном или nil
+func codeOwnerRuleByPattern(
+	ctx context.Context,
+	codeOwnersRepo repo.CodeOwnersRepository,
+	pattern string,
+) (*entity.CodeOwnersRule, error) {
+	if codeOwnersRepo == nil {
+		return nil, nil
+	}
+
+	rules, err := codeOwnersRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Pattern == pattern {
+			return rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// codeOwnerLookup - где ищутся пользователи и команды из CODEOWNERS: репозитории или транзакция
+type codeOwnerLookup interface {
+	// userByIDOrName ищет пользователя по ID, затем по имени с меньшим ID, nil - не найден
+	userByIDOrName(ctx context.Context, name string) (*entity.User, error)
+	// teamMembers возвращает участников команды, nil - команды нет
+	teamMembers(ctx context.Context, teamName string) ([]*entity.User, error)
+}
+
+// repoCodeOwnerLookup ищет владельцев через репозитории
+type repoCodeOwnerLookup struct {
+	userRepo repo.UserRepository
+	teamRepo repo.TeamRepository
+}
+
+func (l repoCodeOwnerLookup) userByIDOrName(ctx context.Context, name string) (*entity.User, error) {
+	u, err := l.userRepo.GetByID(ctx, name)
+	if errors.Is(err, repo.ErrNotFound) {
+		u, err = l.userRepo.GetByUsername(ctx, name)
+	}
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (l repoCodeOwnerLookup) teamMembers(ctx context.Context, teamName string) ([]*entity.User, error) {
+	team, err := l.teamRepo.GetByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return team.Members, nil
+}
+
+// codeOwnersOf раскрывает владельцев правила в пользователей
+func codeOwnersOf(
+	ctx context.Context,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+	rule *entity.CodeOwnersRule,
+) ([]*entity.User, error) {
+	return resolveCodeOwners(ctx, repoCodeOwnerLookup{userRepo: userRepo, teamRepo: teamRepo}, rule)
+}
+
+// resolveCodeOwners раскрывает владельцев правила без повторов в порядке из файла
+func resolveCodeOwners(ctx context.Context, lookup codeOwnerLookup, rule *entity.CodeOwnersRule) ([]*entity.User, error) {
+	var out []*entity.User
+	seen := make(map[string]struct{})
+	for _, owner := range rule.ParsedOwners() {
+		users, err := resolveCodeOwner(ctx, lookup, owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if _, ok := seen[u.ID]; ok {
+				continue
+			}
+			seen[u.ID] = struct{}{}
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+// resolveCodeOwner ищет @user по ID, затем по имени, @org/team - по имени команды
+func resolveCodeOwner(ctx context.Context, lookup codeOwnerLookup, owner entity.CodeOwner) ([]*entity.User, error) {
+	if owner.TeamName != "" {
+		return lookup.teamMembers(ctx, owner.TeamName)
+	}
+
+	u, err := lookup.userByIDOrName(ctx, owner.Username)
+	if err != nil || u == nil {
+		return nil, err
+	}
+	return []*entity.User{u}, nil
+}
+
+func withoutUser(users []*entity.User, userID string) []*entity.User {
+	out := make([]*entity.User, 0, len(users))
+	for _, u := range users {
+		if u != nil && u.ID != userID {
+			out = append(out, u)
+		}
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+type inMemoryCodeOwnersRepo struct {
+	rules []*entity.CodeOwnersRule
+}
+
+func (r *inMemoryCodeOwnersRepo) Replace(_ context.Context, rules []*entity.CodeOwnersRule) error {
+	r.rules = append([]*entity.CodeOwnersRule(nil), rules...)
+	return nil
+}
+
+func (r *inMemoryCodeOwnersRepo) List(_ context.Context) ([]*entity.CodeOwnersRule, error) {
+	return append([]*entity.CodeOwnersRule(nil), r.rules...), nil
+}
+
+func TestParseCodeOwners_LastMatchWins(t *testing.T) {
+	t.Parallel()
+
+	rules, err := entity.ParseCodeOwners(`
+# default owners
+*                   @acme/platform
+*.sql               @dba   # inline comment
+/internal/billing/  @acme/billing ops@example.com
+docs/*              @writer
+/internal/billing/legacy/
+`)
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+	require.Equal(t, []string{"@dba"}, rules[1].Owners)
+	require.Equal(t, []entity.CodeOwner{{TeamName: "billing"}}, rules[2].ParsedOwners())
+
+	owner := func(path string) string {
+		rule := entity.MatchCodeOwners(rules, path)
+		if rule == nil {
+			return ""
+		}
+		return rule.Pattern
+	}
+	require.Equal(t, "*", owner("cmd/app/main.go"))
+	require.Equal(t, "*.sql", owner("migrations/0001_init.up.sql"))
+	require.Equal(t, "/internal/billing/", owner("internal/billing/tax/vat.go"))
+	require.Equal(t, "/internal/billing/legacy/", owner("internal/billing/legacy/old.go"))
+	require.Equal(t, "docs/*", owner("docs/intro.md"))
+	require.Equal(t, "*", owner("docs/guides/intro.md"))
+
+	_, err = entity.ParseCodeOwners("!secret.txt @alice")
+	require.Error(t, err)
+	_, err = entity.ParseCodeOwners("*.go alice")
+	require.Error(t, err)
+}
+
+func TestPullRequestService_CodeOwnersRequiredReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+	cr := &inMemoryCodeOwnersRepo{}
+
+	author := &entity.User{ID: "a", Username: "author", TeamName: "backend", IsActive: true}
+	b1 := &entity.User{ID: "b1", Username: "b1", TeamName: "backend", IsActive: true}
+	b2 := &entity.User{ID: "b2", Username: "b2", TeamName: "backend", IsActive: true}
+	pay1 := &entity.User{ID: "pay1", Username: "pay1", TeamName: "billing", IsActive: true}
+	pay2 := &entity.User{ID: "pay2", Username: "pay2", TeamName: "billing", IsActive: true}
+	dba := &entity.User{ID: "u-dba", Username: "dba", TeamName: "platform", IsActive: true}
+	for _, u := range []*entity.User{author, b1, b2, pay1, pay2, dba} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "backend", Members: []*entity.User{author, b1, b2}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "billing", Members: []*entity.User{pay1, pay2}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "platform", Members: []*entity.User{dba}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "backend", MaxReviewers: 3}))
+
+	res, err := NewCodeOwnersService(cr, ur, tr).Import(ctx, `
+internal/billing/** @acme/billing
+*.sql               @dba @ghost
+`)
+	require.NoError(t, err)
+	require.Len(t, res.Rules, 2)
+	require.Equal(t, []string{"@ghost"}, res.UnknownOwners)
+
+	svc := NewPullRequestService(prr, ur, tr, WithCodeOwners(cr))
+	pr, err := svc.Create(ctx, PullRequestCreateInput{
+		ID: "pr", Name: "Billing", AuthorID: author.ID,
+		ChangedFiles: []string{"internal/billing/invoice.go", "migrations/0008_x.up.sql"},
+	})
+	require.NoError(t, err)
+	require.Len(t, pr.Reviewers, 3)
+
+	billingOwner, ok := "", false
+	for _, r := range pr.RequiredReviewers {
+		if r.Pattern == "internal/billing/**" {
+			billingOwner, ok = r.UserID, true
+		}
+	}
+	require.True(t, ok)
+	require.Contains(t, []string{pay1.ID, pay2.ID}, billingOwner)
+	pattern, ok := pr.RequiredPattern(dba.ID)
+	require.True(t, ok)
+	require.Equal(t, "*.sql", pattern)
+
+	t.Run("required owner replaced by owner of the same rule", func(t *testing.T) {
+		updated, replacedBy, err := svc.ReassignReviewer(ctx, "pr", billingOwner)
+		require.NoError(t, err)
+		require.Contains(t, []string{pay1.ID, pay2.ID}, replacedBy)
+		require.NotEqual(t, billingOwner, replacedBy)
+
+		pattern, ok := updated.RequiredPattern(replacedBy)
+		require.True(t, ok)
+		require.Equal(t, "internal/billing/**", pattern)
+	})
+
+	t.Run("sole owner cannot be replaced", func(t *testing.T) {
+		_, _, err := svc.ReassignReviewer(ctx, "pr", dba.ID)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("unavailable owner blocks create", func(t *testing.T) {
+		inactive := *dba
+		inactive.IsActive = false
+		require.NoError(t, ur.Save(ctx, &inactive))
+
+		_, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-sql", Name: "SQL", AuthorID: author.ID,
+			ChangedFiles: []string{"schema.sql"},
+		})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+}
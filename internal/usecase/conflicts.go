@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// WithConflicts включает учёт конфликтов интересов при подборе ревьюверов
+func WithConflicts(conflictRepo repo.ConflictRepository) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		a.conflicts = conflictRepo
+	}
+}
+
+// conflictsOf возвращает пользователей, которые не могут ревьюить PR автора
+func (a *reviewerAssigner) conflictsOf(ctx context.Context, authorID string) (map[string]struct{}, error) {
+	if a.conflicts == nil {
+		return nil, nil
+	}
+	return a.conflicts.ConflictingUserIDs(ctx, authorID)
+}
+
+type conflictService struct {
+	conflictRepo repo.ConflictRepository
+	userRepo     repo.UserRepository
+}
+
+// NewConflictService создаёт реализацию ConflictService
+func NewConflictService(conflictRepo repo.ConflictRepository, userRepo repo.UserRepository) ConflictService {
+	return &conflictService{
+		conflictRepo: conflictRepo,
+		userRepo:     userRepo,
+	}
+}
+
+func (s *conflictService) AddConflict(ctx context.Context, input ReviewConflictInput) (*entity.ReviewConflict, error) {
+	conflict := &entity.ReviewConflict{
+		UserID:      input.UserID,
+		OtherUserID: input.OtherUserID,
+		Reason:      input.Reason,
+	}
+	if err := conflict.Validate(); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	for _, id := range []string{conflict.UserID, conflict.OtherUserID} {
+		if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, NewNotFoundError("user " + id + " not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.conflictRepo.Save(ctx, conflict); err != nil {
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			return nil, NewInvalidInputError("conflict between these users already exists")
+		}
+		return nil, err
+	}
+	return conflict, nil
+}
+
+func (s *conflictService) ListConflicts(ctx context.Context) ([]*entity.ReviewConflict, error) {
+	return s.conflictRepo.List(ctx)
+}
+
+func (s *conflictService) DeleteConflict(ctx context.Context, id int64) error {
+	if err := s.conflictRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return NewNotFoundError("review conflict not found")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+type inMemoryConflictRepo struct {
+	conflicts []*entity.ReviewConflict
+	nextID    int64
+}
+
+func (r *inMemoryConflictRepo) Save(_ context.Context, conflict *entity.ReviewConflict) error {
+	for _, c := range r.conflicts {
+		if c.Involves(conflict.UserID) && c.Involves(conflict.OtherUserID) {
+			return repo.ErrAlreadyExists
+		}
+	}
+	r.nextID++
+	conflict.ID = r.nextID
+	conflictCopy := *conflict
+	r.conflicts = append(r.conflicts, &conflictCopy)
+	return nil
+}
+
+func (r *inMemoryConflictRepo) List(_ context.Context) ([]*entity.ReviewConflict, error) {
+	out := make([]*entity.ReviewConflict, 0, len(r.conflicts))
+	for _, c := range r.conflicts {
+		conflictCopy := *c
+		out = append(out, &conflictCopy)
+	}
+	return out, nil
+}
+
+func (r *inMemoryConflictRepo) Delete(_ context.Context, id int64) error {
+	for i, c := range r.conflicts {
+		if c.ID == id {
+			r.conflicts = append(r.conflicts[:i], r.conflicts[i+1:]...)
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (r *inMemoryConflictRepo) ConflictingUserIDs(_ context.Context, userID string) (map[string]struct{}, error) {
+	out := make(map[string]struct{})
+	for _, c := range r.conflicts {
+		switch userID {
+		case c.UserID:
+			out[c.OtherUserID] = struct{}{}
+		case c.OtherUserID:
+			out[c.UserID] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+func TestReviewConflicts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+	cr := &inMemoryConflictRepo{}
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	manager := &entity.User{ID: "boss", Username: "Boss", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	for _, u := range []*entity.User{author, manager, r1, r2} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, manager, r1, r2}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 3}))
+
+	conflicts := NewConflictService(cr, ur)
+	// пара задана в обратном порядке: запрет действует в обе стороны
+	conflict, err := conflicts.AddConflict(ctx, ReviewConflictInput{
+		UserID: manager.ID, OtherUserID: author.ID, Reason: "promotion review",
+	})
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(prr, ur, tr, WithConflicts(cr))
+
+	t.Run("create skips blocked candidate and traces it", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "PR", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{r1.ID, r2.ID}, pr.Reviewers)
+
+		traces, err := svc.GetAssignmentTraces(ctx, "pr-1")
+		require.NoError(t, err)
+		require.Contains(t, traces[0].Steps[0].Excluded, entity.TraceExclusion{UserID: manager.ID, Reason: "conflict"})
+	})
+
+	t.Run("blocked requested reviewer", func(t *testing.T) {
+		_, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-2", Name: "PR", AuthorID: author.ID, RequestedReviewers: []string{manager.ID},
+		})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidReviewers, de.Code)
+		require.Equal(t, []ErrorDetail{{UserID: manager.ID, Reason: "conflict"}}, de.Details)
+	})
+
+	t.Run("reassign never picks blocked user", func(t *testing.T) {
+		_, _, err := svc.ReassignReviewer(ctx, "pr-1", r1.ID)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("manual add is rejected", func(t *testing.T) {
+		_, err := svc.AddReviewer(ctx, "pr-1", manager.ID)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeConflictOfInterest, de.Code)
+	})
+
+	t.Run("deleted conflict no longer applies", func(t *testing.T) {
+		require.NoError(t, conflicts.DeleteConflict(ctx, conflict.ID))
+
+		pr, err := svc.AddReviewer(ctx, "pr-1", manager.ID)
+		require.NoError(t, err)
+		require.Contains(t, pr.Reviewers, manager.ID)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var de *DomainError
+
+		_, err := conflicts.AddConflict(ctx, ReviewConflictInput{UserID: r1.ID, OtherUserID: r1.ID})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+		_, err = conflicts.AddConflict(ctx, ReviewConflictInput{UserID: r1.ID, OtherUserID: "ghost"})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+
+		_, err = conflicts.AddConflict(ctx, ReviewConflictInput{UserID: r1.ID, OtherUserID: r2.ID})
+		require.NoError(t, err)
+		_, err = conflicts.AddConflict(ctx, ReviewConflictInput{UserID: r2.ID, OtherUserID: r1.ID})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+		err = conflicts.DeleteConflict(ctx, 100)
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+}
@@ -1,79 +1,178 @@
-// Package usecase содержит доменные ошибки
-package usecase
-
-// ErrorCode - доменный код ошибки
-type ErrorCode string
-
-const (
-	// ErrorCodeTeamExists возвращается когда команда с таким именем уже существует
-	ErrorCodeTeamExists ErrorCode = "TEAM_EXISTS"
-	// ErrorCodePRExists возвращается когда PR с таким идентификатором уже существует
-	ErrorCodePRExists ErrorCode = "PR_EXISTS"
-	// ErrorCodePRMerged возвращается когда операция запрещена для слитого PR
-	ErrorCodePRMerged ErrorCode = "PR_MERGED"
-	// ErrorCodeNotAssigned возвращается когда пользователь не назначен ревьювером этого PR
-	ErrorCodeNotAssigned ErrorCode = "NOT_ASSIGNED"
-	// ErrorCodeNoCandidate возвращается когда нет подходящего кандидата на замену ревьювера
-	ErrorCodeNoCandidate ErrorCode = "NO_CANDIDATE"
-	// ErrorCodeNotFound возвращается когда сущность не найдена
-	ErrorCodeNotFound ErrorCode = "NOT_FOUND"
-)
-
-// DomainError представляет доменную ошибку с кодом и сообщением
-type DomainError struct {
-	Code    ErrorCode
-	Message string
-}
-
-// Error реализует интерфейс error
-func (e *DomainError) Error() string {
-	return string(e.Code) + ": " + e.Message
-}
-
-// NewTeamExistsError создаёт ошибку с кодом ErrorCodeTeamExists
-func NewTeamExistsError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodeTeamExists,
-		Message: msg,
-	}
-}
-
-// NewPRExistsError создаёт ошибку с кодом ErrorCodePRExists
-func NewPRExistsError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodePRExists,
-		Message: msg,
-	}
-}
-
-// NewPRMergedError создаёт ошибку с кодом ErrorCodePRMerged
-func NewPRMergedError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodePRMerged,
-		Message: msg,
-	}
-}
-
-// NewNotAssignedError создаёт ошибку с кодом ErrorCodeNotAssigned
-func NewNotAssignedError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodeNotAssigned,
-		Message: msg,
-	}
-}
-
-// NewNoCandidateError создаёт ошибку с кодом ErrorCodeNoCandidate
-func NewNoCandidateError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodeNoCandidate,
-		Message: msg,
-	}
-}
-
-// NewNotFoundError создаёт ошибку с кодом ErrorCodeNotFound
-func NewNotFoundError(msg string) *DomainError {
-	return &DomainError{
-		Code:    ErrorCodeNotFound,
-		Message: msg,
-	}
-}
+// Package usecase содержит доменные ошибки
+package usecase
+
+// ErrorCode - доменный код ошибки
+type ErrorCode string
+
+const (
+	// ErrorCodeTeamExists возвращается когда команда с таким именем уже существует
+	ErrorCodeTeamExists ErrorCode = "TEAM_EXISTS"
+	// ErrorCodePRExists возвращается когда PR с таким идентификатором уже существует
+	ErrorCodePRExists ErrorCode = "PR_EXISTS"
+	// ErrorCodePRMerged возвращается когда операция запрещена для слитого PR
+	ErrorCodePRMerged ErrorCode = "PR_MERGED"
+	// ErrorCodePRClosed возвращается когда операция запрещена для закрытого PR
+	ErrorCodePRClosed ErrorCode = "PR_CLOSED"
+	// ErrorCodePRDraft возвращается когда операция запрещена для черновика
+	ErrorCodePRDraft ErrorCode = "PR_DRAFT"
+	// ErrorCodeNotAssigned возвращается когда пользователь не назначен ревьювером этого PR
+	ErrorCodeNotAssigned ErrorCode = "NOT_ASSIGNED"
+	// ErrorCodeAlreadyAssigned возвращается когда пользователь уже ревьювер этого PR
+	ErrorCodeAlreadyAssigned ErrorCode = "ALREADY_ASSIGNED"
+	// ErrorCodeUserInactive возвращается когда ревьювером назначают неактивного пользователя
+	ErrorCodeUserInactive ErrorCode = "USER_INACTIVE"
+	// ErrorCodeReviewerRequired возвращается когда ревьювера нельзя снять без замены
+	ErrorCodeReviewerRequired ErrorCode = "REVIEWER_REQUIRED"
+	// ErrorCodeConflictOfInterest возвращается когда ревьювер в конфликте интересов с автором
+	ErrorCodeConflictOfInterest ErrorCode = "CONFLICT_OF_INTEREST"
+	// ErrorCodeNoCandidate возвращается когда нет подходящего кандидата на замену ревьювера
+	ErrorCodeNoCandidate ErrorCode = "NO_CANDIDATE"
+	// ErrorCodeNoCapacity возвращается когда все кандидаты исчерпали лимит открытых ревью
+	ErrorCodeNoCapacity ErrorCode = "NO_CAPACITY"
+	// ErrorCodeNotFound возвращается когда сущность не найдена
+	ErrorCodeNotFound ErrorCode = "NOT_FOUND"
+	// ErrorCodeInvalidInput возвращается когда входные данные нарушают доменные правила
+	ErrorCodeInvalidInput ErrorCode = "INVALID_INPUT"
+	// ErrorCodeInvalidReviewers возвращается когда запрошенных автором ревьюверов нельзя назначить
+	ErrorCodeInvalidReviewers ErrorCode = "INVALID_REVIEWERS"
+)
+
+// DomainError представляет доменную ошибку с кодом и сообщением
+type DomainError struct {
+	Code    ErrorCode
+	Message string
+	// Details - ошибки по отдельным пользователям, если их несколько
+	Details []ErrorDetail
+}
+
+// ErrorDetail - причина, по которой конкретный пользователь не подошёл
+type ErrorDetail struct {
+	UserID string
+	Reason string
+}
+
+// Error реализует интерфейс error
+func (e *DomainError) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// NewTeamExistsError создаёт ошибку с кодом ErrorCodeTeamExists
+func NewTeamExistsError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeTeamExists,
+		Message: msg,
+	}
+}
+
+// NewPRExistsError создаёт ошибку с кодом ErrorCodePRExists
+func NewPRExistsError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodePRExists,
+		Message: msg,
+	}
+}
+
+// NewPRMergedError создаёт ошибку с кодом ErrorCodePRMerged
+func NewPRMergedError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodePRMerged,
+		Message: msg,
+	}
+}
+
+// NewPRClosedError создаёт ошибку с кодом ErrorCodePRClosed
+func NewPRClosedError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodePRClosed,
+		Message: msg,
+	}
+}
+
+// NewPRDraftError создаёт ошибку с кодом ErrorCodePRDraft
+func NewPRDraftError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodePRDraft,
+		Message: msg,
+	}
+}
+
+// NewNotAssignedError создаёт ошибку с кодом ErrorCodeNotAssigned
+func NewNotAssignedError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeNotAssigned,
+		Message: msg,
+	}
+}
+
+// NewAlreadyAssignedError создаёт ошибку с кодом ErrorCodeAlreadyAssigned
+func NewAlreadyAssignedError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeAlreadyAssigned,
+		Message: msg,
+	}
+}
+
+// NewUserInactiveError создаёт ошибку с кодом ErrorCodeUserInactive
+func NewUserInactiveError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeUserInactive,
+		Message: msg,
+	}
+}
+
+// NewReviewerRequiredError создаёт ошибку с кодом ErrorCodeReviewerRequired
+func NewReviewerRequiredError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeReviewerRequired,
+		Message: msg,
+	}
+}
+
+// NewConflictOfInterestError создаёт ошибку с кодом ErrorCodeConflictOfInterest
+func NewConflictOfInterestError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeConflictOfInterest,
+		Message: msg,
+	}
+}
+
+// NewNoCandidateError создаёт ошибку с кодом ErrorCodeNoCandidate
+func NewNoCandidateError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeNoCandidate,
+		Message: msg,
+	}
+}
+
+// NewNoCapacityError создаёт ошибку с кодом ErrorCodeNoCapacity
+func NewNoCapacityError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeNoCapacity,
+		Message: msg,
+	}
+}
+
+// NewNotFoundError создаёт ошибку с кодом ErrorCodeNotFound
+func NewNotFoundError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeNotFound,
+		Message: msg,
+	}
+}
+
+// NewInvalidInputError создаёт ошибку с кодом ErrorCodeInvalidInput
+func NewInvalidInputError(msg string) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeInvalidInput,
+		Message: msg,
+	}
+}
+
+// NewInvalidReviewersError создаёт ошибку с кодом ErrorCodeInvalidReviewers и причинами по каждому ревьюверу
+func NewInvalidReviewersError(msg string, details []ErrorDetail) *DomainError {
+	return &DomainError{
+		Code:    ErrorCodeInvalidReviewers,
+		Message: msg,
+		Details: details,
+	}
+}
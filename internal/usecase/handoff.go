@@ -0,0 +1,517 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/infrastructure/repository/postgresql"
+)
+
+// ReviewHandoffInput - чьи открытые ревью передать и кому
+type ReviewHandoffInput struct {
+	UserID string
+	// TargetUserID - кому передать все ревью, пусто - замена подбирается стратегией
+	TargetUserID string
+	// Deactivate - деактивировать пользователя после передачи, если переданы все ревью
+	Deactivate bool
+}
+
+// ReviewHandoffResult - итог передачи открытых ревью
+type ReviewHandoffResult struct {
+	UserID      string
+	Deactivated bool
+	// DeactivationSkipped - деактивацию запросили, но часть ревью осталась за пользователем
+	DeactivationSkipped bool
+	PullRequests        []HandoffPullRequestResult
+}
+
+// HandoffPullRequestResult - итог передачи ревью одного PR
+type HandoffPullRequestResult struct {
+	PullRequestID string
+	// NewReviewerID - кому передано ревью, пусто если передать не удалось
+	NewReviewerID string
+	// Err - почему ревью осталось за пользователем, nil - ревью передано
+	Err *DomainError
+}
+
+// handoffAssignment - открытый PR, где пользователь ревьювер
+type handoffAssignment struct {
+	prID            string
+	authorID        string
+	authorTeam      string
+	requiredPattern string
+	fromFallback    bool
+}
+
+// handoffState - всё что нужно для подбора замен, прочитанное внутри транзакции
+type handoffState struct {
+	user      *entity.User
+	target    *entity.User
+	current   map[string][]string
+	members   map[string][]*entity.User
+	settings  map[string]*entity.TeamSettings
+	loads     map[string]int
+	away      map[string]struct{}
+	conflicts map[string]map[string]struct{}
+	history   map[string]map[string]entity.ReviewPairHistory
+	// owners - владельцы действующих правил CODEOWNERS по шаблону
+	owners map[string][]*entity.User
+	// cursors - курсоры round_robin замен из всей команды, сохраняются после Commit
+	cursors map[string]string
+}
+
+// HandoffReviews передаёт ревьюверство пользователя во всех открытых PR
+// Замена подбирается по тем же правилам что в ReassignReviewer или берётся TargetUserID,
+// PR, для которых замены не нашлось, остаются за пользователем и попадают в результат с ошибкой
+func (s *teamMaintenanceServiceImpl) HandoffReviews(
+	ctx context.Context,
+	input ReviewHandoffInput,
+) (res ReviewHandoffResult, err error) {
+	res.UserID = input.UserID
+	res.PullRequests = []HandoffPullRequestResult{}
+	if input.TargetUserID == input.UserID {
+		return res, NewInvalidInputError("target_user_id must differ from user_id")
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	ids := []string{input.UserID}
+	if input.TargetUserID != "" {
+		ids = append(ids, input.TargetUserID)
+	}
+	users, err := loadUsersTx(ctx, tx, ids)
+	if err != nil {
+		return res, err
+	}
+	user, ok := users[input.UserID]
+	if !ok {
+		return res, NewNotFoundError("user not found")
+	}
+	var target *entity.User
+	if input.TargetUserID != "" {
+		if target, ok = users[input.TargetUserID]; !ok {
+			return res, NewNotFoundError("target user not found")
+		}
+		if !target.IsActive {
+			return res, NewUserInactiveError("target user is not active")
+		}
+	}
+
+	assignments, err := loadOpenAssignmentsTx(ctx, tx, user.ID)
+	if err != nil {
+		return res, err
+	}
+
+	var state *handoffState
+	if len(assignments) > 0 {
+		state, err = s.loadHandoffState(ctx, tx, user, target, assignments)
+		if err != nil {
+			return res, err
+		}
+		for _, a := range assignments {
+			var r HandoffPullRequestResult
+			r, err = s.handoffOne(ctx, tx, state, a)
+			if err != nil {
+				return res, err
+			}
+			res.PullRequests = append(res.PullRequests, r)
+		}
+	}
+
+	// неактивный пользователь не должен оставаться ревьювером непереданных PR
+	pending := false
+	for _, r := range res.PullRequests {
+		if r.Err != nil {
+			pending = true
+			break
+		}
+	}
+
+	if input.Deactivate && pending {
+		res.DeactivationSkipped = true
+	}
+	if input.Deactivate && !pending {
+		if _, err = tx.Exec(ctx, `
+				UPDATE users
+				SET is_active = FALSE
+				WHERE id = $1
+		`, user.ID); err != nil {
+			return res, err
+		}
+		res.Deactivated = true
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+	if state != nil {
+		s.assigner.saveCursors(ctx, state.cursors)
+	}
+	return res, nil
+}
+
+func (s *teamMaintenanceServiceImpl) loadHandoffState(
+	ctx context.Context,
+	tx pgx.Tx,
+	user *entity.User,
+	target *entity.User,
+	assignments []handoffAssignment,
+) (*handoffState, error) {
+	st := &handoffState{user: user, target: target, cursors: make(map[string]string)}
+
+	prIDs := make([]string, 0, len(assignments))
+	authorSet := make(map[string]struct{})
+	patternSet := make(map[string]struct{})
+	teamSet := map[string]struct{}{user.TeamName: {}}
+	teamNames := []string{user.TeamName}
+	addTeam := func(name string) {
+		if _, ok := teamSet[name]; ok {
+			return
+		}
+		teamSet[name] = struct{}{}
+		teamNames = append(teamNames, name)
+	}
+	for _, a := range assignments {
+		prIDs = append(prIDs, a.prID)
+		authorSet[a.authorID] = struct{}{}
+		if a.requiredPattern != "" {
+			patternSet[a.requiredPattern] = struct{}{}
+		}
+		addTeam(a.authorTeam)
+	}
+
+	var err error
+	if st.current, err = loadReviewersTx(ctx, tx, prIDs); err != nil {
+		return nil, err
+	}
+	if st.conflicts, err = loadConflictsTx(ctx, tx, authorSet); err != nil {
+		return nil, err
+	}
+	if st.history, err = s.loadPairHistoryTx(ctx, tx, authorSet); err != nil {
+		return nil, err
+	}
+
+	patterns := make([]string, 0, len(patternSet))
+	for p := range patternSet {
+		patterns = append(patterns, p)
+	}
+	if st.owners, err = loadRequiredOwnersTx(ctx, tx, patterns); err != nil {
+		return nil, err
+	}
+
+	// кандидаты - команда пользователя, её запасные команды и владельцы обязательных правил
+	own, err := loadTeamSettingsTx(ctx, tx, []string{user.TeamName})
+	if err != nil {
+		return nil, err
+	}
+	for _, fb := range own[user.TeamName].FallbackTeams {
+		addTeam(fb)
+	}
+	for _, owners := range st.owners {
+		for _, u := range owners {
+			addTeam(u.TeamName)
+		}
+	}
+
+	if st.settings, err = loadTeamSettingsTx(ctx, tx, teamNames); err != nil {
+		return nil, err
+	}
+	if st.members, err = loadTeamMembersTx(ctx, tx, teamNames); err != nil {
+		return nil, err
+	}
+	if st.loads, err = loadOpenReviewCountsTx(ctx, tx, teamNames); err != nil {
+		return nil, err
+	}
+	if st.away, err = loadAwayUsersTx(ctx, tx, teamNames, s.assigner.clock.Now()); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// handoffOne передаёт ревью одного PR, доменная ошибка возвращается в результате
+func (s *teamMaintenanceServiceImpl) handoffOne(
+	ctx context.Context,
+	tx pgx.Tx,
+	st *handoffState,
+	a handoffAssignment,
+) (HandoffPullRequestResult, error) {
+	res := HandoffPullRequestResult{PullRequestID: a.prID}
+	user := st.user
+
+	owners, isRequired := st.owners[a.requiredPattern]
+	sameLevel := st.settings[a.authorTeam].MinBySeniority(user.Seniority) > 0
+
+	var newReviewerID string
+	var fromFallback bool
+	var steps []entity.AssignmentTraceStep
+	if st.target != nil {
+		if domainErr := checkHandoffTarget(st, a, owners, isRequired, sameLevel); domainErr != nil {
+			res.Err = domainErr
+			return res, nil
+		}
+		newReviewerID = st.target.ID
+	} else {
+		candidates := st.members[user.TeamName]
+		var fallbacks []teamPool
+		for _, fb := range st.settings[user.TeamName].FallbackTeams {
+			fallbacks = append(fallbacks, teamPool{TeamName: fb, Members: st.members[fb]})
+		}
+		scope := ""
+		cursors := st.cursors
+		if isRequired || sameLevel {
+			// замена из владельцев или одного уровня - подмножество команды, курсор не сдвигается
+			cursors = nil
+		}
+		if isRequired {
+			candidates = owners
+			fallbacks = nil
+			scope = a.requiredPattern
+		}
+		if sameLevel {
+			candidates = withSeniority(candidates, user.Seniority)
+			fallbacks = fallbackPoolsWithSeniority(fallbacks, user.Seniority)
+		}
+
+		otherLimits := make(map[string]*int)
+		for _, m := range append(append([]*entity.User(nil), candidates...), poolMembers(fallbacks)...) {
+			if m.TeamName != user.TeamName {
+				otherLimits[m.TeamName] = st.settings[m.TeamName].MaxOpenReviews
+			}
+		}
+
+		picked, err := s.assigner.pickWithFallbacks(ctx, assignmentRequest{
+			TeamName:        user.TeamName,
+			AuthorID:        a.authorID,
+			Members:         candidates,
+			Exclude:         idSet(st.current[a.prID]),
+			Conflicts:       st.conflicts[a.authorID],
+			Away:            st.away,
+			Loads:           st.loads,
+			TeamReviewLimit: st.settings[user.TeamName].MaxOpenReviews,
+			OtherTeamLimits: otherLimits,
+			PairHistory:     st.history[a.authorID],
+			Count:           1,
+			Stage:           "handoff",
+			Scope:           scope,
+			Cursors:         cursors,
+		}, fallbacks)
+		if err != nil {
+			return res, err
+		}
+		if len(picked.Reviewers) == 0 {
+			switch {
+			case isRequired:
+				res.Err = picked.shortageError("no available code owner of " + a.requiredPattern + " to replace required reviewer")
+			case sameLevel:
+				res.Err = picked.shortageError("no available " + string(user.Seniority) + " replacement to keep team composition")
+			default:
+				res.Err = picked.shortageError("no active replacement candidate in team")
+			}
+			return res, nil
+		}
+		newReviewerID = picked.Reviewers[0]
+		fromFallback = len(picked.Fallback) > 0
+		steps = picked.Trace
+	}
+
+	// правило убрали из CODEOWNERS - замена становится обычным ревьювером
+	requiredPattern := ""
+	if isRequired {
+		requiredPattern = a.requiredPattern
+	}
+	if _, err := tx.Exec(ctx, `
+			UPDATE pr_reviewers
+			SET reviewer_id = $3, required_pattern = NULLIF($4, ''), from_fallback = $5
+			WHERE pull_request_id = $1
+				AND reviewer_id = $2
+	`, a.prID, user.ID, newReviewerID, requiredPattern, fromFallback || a.fromFallback); err != nil {
+		return res, err
+	}
+
+	// теневой ревьювер, ставший обычным, перестаёт быть теневым, как в AddReviewer
+	if _, err := tx.Exec(ctx, `
+			DELETE FROM pr_shadow_reviewers
+			WHERE pull_request_id = $1
+				AND reviewer_id = $2
+	`, a.prID, newReviewerID); err != nil {
+		return res, err
+	}
+
+	if err := saveAssignmentTraceTx(ctx, tx, &entity.AssignmentTrace{
+		PullRequestID:      a.prID,
+		Action:             entity.TraceActionReassign,
+		ReplacedReviewerID: user.ID,
+		Reviewers:          []string{newReviewerID},
+		Steps:              steps,
+		CreatedAt:          s.assigner.clock.Now(),
+	}); err != nil {
+		return res, err
+	}
+
+	st.loads[newReviewerID]++
+	res.NewReviewerID = newReviewerID
+	return res, nil
+}
+
+// checkHandoffTarget проверяет что заданный получатель может заменить пользователя в PR
+// Лимиты открытых ревью и отсутствие не проверяются, как при ручном добавлении
+func checkHandoffTarget(
+	st *handoffState,
+	a handoffAssignment,
+	owners []*entity.User,
+	isRequired bool,
+	sameLevel bool,
+) *DomainError {
+	target := st.target
+	if target.ID == a.authorID {
+		return NewInvalidInputError("author cannot review own pull request")
+	}
+	if _, ok := idSet(st.current[a.prID])[target.ID]; ok {
+		return NewAlreadyAssignedError("target user is already a reviewer of this pull request")
+	}
+	if _, conflict := st.conflicts[a.authorID][target.ID]; conflict {
+		return NewConflictOfInterestError("target user has a conflict of interest with the pull request author")
+	}
+	if isRequired {
+		if _, ok := idSet(memberIDs(owners))[target.ID]; !ok {
+			return NewReviewerRequiredError("target user is not a code owner of " + a.requiredPattern)
+		}
+	}
+	if sameLevel && target.Seniority != st.user.Seniority {
+		return NewReviewerRequiredError("team composition requires " + string(st.user.Seniority) + " reviewers")
+	}
+	return nil
+}
+
+// loadOpenAssignmentsTx возвращает открытые PR, где пользователь ревьювер
+func loadOpenAssignmentsTx(ctx context.Context, tx pgx.Tx, userID string) ([]handoffAssignment, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT pr.id, pr.author_id, author.team_name,
+			       COALESCE(prr.required_pattern, ''), prr.from_fallback
+			FROM pr_reviewers prr
+			JOIN pull_requests pr ON pr.id = prr.pull_request_id
+			JOIN users author ON author.id = pr.author_id
+			WHERE prr.reviewer_id = $1
+				AND pr.status = 'OPEN'
+			ORDER BY pr.id
+			FOR UPDATE OF prr
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []handoffAssignment
+	for rows.Next() {
+		var a handoffAssignment
+		if err := rows.Scan(&a.prID, &a.authorID, &a.authorTeam, &a.requiredPattern, &a.fromFallback); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func loadUsersTx(ctx context.Context, tx pgx.Tx, ids []string) (map[string]*entity.User, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT `+postgresql.UserColumns+`
+			FROM users
+			WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]*entity.User, len(ids))
+	for rows.Next() {
+		u, err := postgresql.ScanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[u.ID] = u
+	}
+	return out, rows.Err()
+}
+
+// loadRequiredOwnersTx раскрывает владельцев правил CODEOWNERS с указанными шаблонами
+// Для каждого шаблона берётся последнее правило, шаблонов которых уже нет в файле в результате нет
+func loadRequiredOwnersTx(ctx context.Context, tx pgx.Tx, patterns []string) (map[string][]*entity.User, error) {
+	out := make(map[string][]*entity.User)
+	if len(patterns) == 0 {
+		return out, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+			SELECT line, pattern, owners
+			FROM codeowners_rules
+			WHERE pattern = ANY($1)
+			ORDER BY line
+	`, patterns)
+	if err != nil {
+		return nil, err
+	}
+	rules := make(map[string]*entity.CodeOwnersRule)
+	for rows.Next() {
+		var rule entity.CodeOwnersRule
+		if err := rows.Scan(&rule.Line, &rule.Pattern, &rule.Owners); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rules[rule.Pattern] = &rule
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for pattern, rule := range rules {
+		owners, err := resolveCodeOwners(ctx, txCodeOwnerLookup{tx: tx}, rule)
+		if err != nil {
+			return nil, err
+		}
+		out[pattern] = owners
+	}
+	return out, nil
+}
+
+// txCodeOwnerLookup ищет владельцев CODEOWNERS внутри транзакции
+type txCodeOwnerLookup struct {
+	tx pgx.Tx
+}
+
+func (l txCodeOwnerLookup) userByIDOrName(ctx context.Context, name string) (*entity.User, error) {
+	u, err := postgresql.ScanUser(l.tx.QueryRow(ctx, `
+			SELECT `+postgresql.UserColumns+`
+			FROM users
+			WHERE id = $1 OR username = $1
+			ORDER BY id = $1 DESC, id
+			LIMIT 1
+	`, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (l txCodeOwnerLookup) teamMembers(ctx context.Context, teamName string) ([]*entity.User, error) {
+	members, err := loadTeamMembersTx(ctx, l.tx, []string{teamName})
+	if err != nil {
+		return nil, err
+	}
+	return members[teamName], nil
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// WithOwnershipRules включает подбор владельцев изменённых путей при создании PR
+func WithOwnershipRules(ownershipRepo repo.OwnershipRepository) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		a.ownership = ownershipRepo
+	}
+}
+
+type ownershipService struct {
+	ownershipRepo repo.OwnershipRepository
+	userRepo      repo.UserRepository
+	teamRepo      repo.TeamRepository
+}
+
+// NewOwnershipService создаёт реализацию OwnershipService
+func NewOwnershipService(
+	ownershipRepo repo.OwnershipRepository,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+) OwnershipService {
+	return &ownershipService{
+		ownershipRepo: ownershipRepo,
+		userRepo:      userRepo,
+		teamRepo:      teamRepo,
+	}
+}
+
+func (s *ownershipService) AddRule(ctx context.Context, input OwnershipRuleInput) (*entity.OwnershipRule, error) {
+	rule := &entity.OwnershipRule{
+		Pattern:  input.Pattern,
+		UserID:   input.UserID,
+		TeamName: input.TeamName,
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	if rule.UserID != "" {
+		if _, err := s.userRepo.GetByID(ctx, rule.UserID); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, NewNotFoundError("user not found")
+			}
+			return nil, err
+		}
+	} else {
+		if _, err := s.teamRepo.GetByName(ctx, rule.TeamName); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, NewNotFoundError("team not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.ownershipRepo.Save(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *ownershipService) ListRules(ctx context.Context) ([]*entity.OwnershipRule, error) {
+	return s.ownershipRepo.List(ctx)
+}
+
+func (s *ownershipService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.ownershipRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return NewNotFoundError("ownership rule not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// pathOwners возвращает пользователей владеющих хотя бы одним из путей
+// Владельцы-команды раскрываются в состав команды, порядок - по порядку правил
+func pathOwners(
+	ctx context.Context,
+	ownershipRepo repo.OwnershipRepository,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+	paths []string,
+) ([]*entity.User, error) {
+	if ownershipRepo == nil || len(paths) == 0 {
+		return nil, nil
+	}
+
+	rules, err := ownershipRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []*entity.User
+	seen := make(map[string]struct{})
+	add := func(u *entity.User) {
+		if u == nil {
+			return
+		}
+		if _, ok := seen[u.ID]; ok {
+			return
+		}
+		seen[u.ID] = struct{}{}
+		owners = append(owners, u)
+	}
+
+	for _, rule := range rules {
+		if !matchesAny(rule, paths) {
+			continue
+		}
+
+		if rule.UserID != "" {
+			u, err := userRepo.GetByID(ctx, rule.UserID)
+			if err != nil {
+				if errors.Is(err, repo.ErrNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			add(u)
+			continue
+		}
+
+		team, err := teamRepo.GetByName(ctx, rule.TeamName)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		for _, m := range team.Members {
+			add(m)
+		}
+	}
+
+	return owners, nil
+}
+
+func matchesAny(rule *entity.OwnershipRule, paths []string) bool {
+	for _, p := range paths {
+		if rule.Matches(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+type inMemoryOwnershipRepo struct {
+	rules  []*entity.OwnershipRule
+	nextID int64
+}
+
+func (r *inMemoryOwnershipRepo) Save(_ context.Context, rule *entity.OwnershipRule) error {
+	r.nextID++
+	rule.ID = r.nextID
+	ruleCopy := *rule
+	r.rules = append(r.rules, &ruleCopy)
+	return nil
+}
+
+func (r *inMemoryOwnershipRepo) List(_ context.Context) ([]*entity.OwnershipRule, error) {
+	out := make([]*entity.OwnershipRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		ruleCopy := *rule
+		out = append(out, &ruleCopy)
+	}
+	return out, nil
+}
+
+func (r *inMemoryOwnershipRepo) Delete(_ context.Context, id int64) error {
+	for i, rule := range r.rules {
+		if rule.ID == id {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"internal/billing/**", "internal/billing/invoice.go", true},
+		{"internal/billing/**", "internal/billing/tax/vat.go", true},
+		{"internal/billing/**", "internal/billingx/a.go", false},
+		{"internal/billing/", "internal/billing/tax/vat.go", true},
+		{"**/*.sql", "migrations/0001_init.up.sql", true},
+		{"**/*.sql", "init.sql", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*/main.go", "cmd/app/main.go", true},
+		{"/docs/?.md", "docs/a.md", true},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.want, entity.MatchGlob(c.pattern, c.path), "%s vs %s", c.pattern, c.path)
+	}
+}
+
+func TestPullRequestService_Create_PicksPathOwner(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	or := &inMemoryOwnershipRepo{}
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "backend", IsActive: true}
+	b1 := &entity.User{ID: "b1", Username: "B1", TeamName: "backend", IsActive: true}
+	b2 := &entity.User{ID: "b2", Username: "B2", TeamName: "backend", IsActive: true}
+	b3 := &entity.User{ID: "b3", Username: "B3", TeamName: "backend", IsActive: true}
+	payer := &entity.User{ID: "pay", Username: "Pay", TeamName: "billing", IsActive: true}
+	dba := &entity.User{ID: "dba", Username: "Dba", TeamName: "platform", IsActive: true}
+	for _, u := range []*entity.User{author, b1, b2, b3, payer, dba} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "backend", Members: []*entity.User{author, b1, b2, b3}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "billing", Members: []*entity.User{payer}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "platform", Members: []*entity.User{dba}}))
+
+	ownership := NewOwnershipService(or, ur, tr)
+	_, err := ownership.AddRule(ctx, OwnershipRuleInput{Pattern: "internal/billing/**", TeamName: "billing"})
+	require.NoError(t, err)
+	_, err = ownership.AddRule(ctx, OwnershipRuleInput{Pattern: "migrations/**", UserID: "dba"})
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(newInMemoryPRRepo(), ur, tr, WithOwnershipRules(or))
+
+	t.Run("owner first, rest from author team", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-1", Name: "Billing", AuthorID: author.ID,
+			ChangedFiles: []string{"internal/billing/invoice.go", "README.md"},
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+		require.Equal(t, payer.ID, pr.Reviewers[0])
+		require.Contains(t, []string{b1.ID, b2.ID, b3.ID}, pr.Reviewers[1])
+	})
+
+	t.Run("unowned paths use author team only", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-2", Name: "Docs", AuthorID: author.ID,
+			ChangedFiles: []string{"docs/readme.md"},
+		})
+		require.NoError(t, err)
+		for _, id := range pr.Reviewers {
+			require.Contains(t, []string{b1.ID, b2.ID, b3.ID}, id)
+		}
+	})
+
+	t.Run("unavailable owner falls back to team", func(t *testing.T) {
+		inactive := *dba
+		inactive.IsActive = false
+		require.NoError(t, ur.Save(ctx, &inactive))
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-3", Name: "Migration", AuthorID: author.ID,
+			ChangedFiles: []string{"migrations/0007_x.up.sql"},
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+		require.NotContains(t, pr.Reviewers, dba.ID)
+	})
+
+	t.Run("rule validation", func(t *testing.T) {
+		var de *DomainError
+
+		_, err := ownership.AddRule(ctx, OwnershipRuleInput{Pattern: "x/**", UserID: "dba", TeamName: "platform"})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+		_, err = ownership.AddRule(ctx, OwnershipRuleInput{Pattern: "x/**", TeamName: "ghosts"})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+
+		err = ownership.DeleteRule(ctx, 100)
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+
+	t.Run("owner kept when composition fills every slot", func(t *testing.T) {
+		b1.Seniority = entity.SenioritySenior
+		require.NoError(t, ur.Save(ctx, b1))
+		require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{
+			TeamName: "backend", MaxReviewers: 1, MinSeniorReviewers: 1,
+		}))
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-4", Name: "Billing", AuthorID: author.ID,
+			ChangedFiles: []string{"internal/billing/invoice.go"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{payer.ID, b1.ID}, pr.Reviewers)
+	})
+}
@@ -0,0 +1,365 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+// DefaultRebalanceTolerance - допустимый разброс открытых ревью по умолчанию
+const DefaultRebalanceTolerance = 1
+
+// RebalanceOptions - параметры выравнивания нагрузки команды
+type RebalanceOptions struct {
+	// Tolerance - допустимая разница открытых ревью между самым и наименее загруженным участником
+	Tolerance int
+	// DryRun - только посчитать перемещения, ничего не менять
+	DryRun bool
+}
+
+// RebalanceMove - перенос одного назначения ревью
+type RebalanceMove struct {
+	PullRequestID string
+	FromUserID    string
+	ToUserID      string
+	// FromOpenReviews, ToOpenReviews - открытые ревью участников перед переносом
+	FromOpenReviews int
+	ToOpenReviews   int
+}
+
+// TeamRebalanceResult - итог выравнивания нагрузки
+type TeamRebalanceResult struct {
+	TeamName string
+	DryRun   bool
+	Moves    []RebalanceMove
+	// SpreadBefore, SpreadAfter - разница открытых ревью самого и наименее загруженного участника
+	SpreadBefore int
+	SpreadAfter  int
+}
+
+// rebalanceAssignment - назначение участника команды в открытом PR
+type rebalanceAssignment struct {
+	prID       string
+	reviewerID string
+	authorID   string
+	authorTeam string
+}
+
+// RebalanceTeam переносит назначения в открытых PR с самых загруженных активных участников
+// на наименее загруженных, пока разброс больше Tolerance
+// Участники в периоде отсутствия и стажёры не учитываются, обязательные по CODEOWNERS назначения не переносятся
+func (s *teamMaintenanceServiceImpl) RebalanceTeam(
+	ctx context.Context,
+	teamName string,
+	opts RebalanceOptions,
+) (TeamRebalanceResult, error) {
+	res := TeamRebalanceResult{TeamName: teamName, DryRun: opts.DryRun, Moves: []RebalanceMove{}}
+	if opts.Tolerance < 0 {
+		return res, NewInvalidInputError("tolerance must be non-negative")
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, err
+	}
+	// после Commit откат ничего не делает, в dry-run откатывается всё
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM teams WHERE name = $1)
+	`, teamName).Scan(&exists); err != nil {
+		return res, err
+	}
+	if !exists {
+		return res, NewNotFoundError("team not found")
+	}
+
+	members, err := loadTeamMembersTx(ctx, tx, []string{teamName})
+	if err != nil {
+		return res, err
+	}
+
+	away, err := loadAwayUsersTx(ctx, tx, []string{teamName}, s.assigner.clock.Now())
+	if err != nil {
+		return res, err
+	}
+	var participants []*entity.User
+	for _, m := range members[teamName] {
+		if _, isAway := away[m.ID]; m.IsActive && !m.IsTrainee && !isAway {
+			participants = append(participants, m)
+		}
+	}
+	if len(participants) < 2 {
+		return res, nil
+	}
+
+	loads, err := loadOpenReviewCountsTx(ctx, tx, []string{teamName})
+	if err != nil {
+		return res, err
+	}
+	res.SpreadBefore = loadSpread(participants, loads)
+
+	assignments, err := loadMovableAssignmentsTx(ctx, tx, teamName)
+	if err != nil {
+		return res, err
+	}
+
+	prSet := make(map[string]struct{})
+	authorSet := make(map[string]struct{})
+	teamNames := []string{teamName}
+	for _, a := range assignments {
+		prSet[a.prID] = struct{}{}
+		if _, ok := authorSet[a.authorID]; !ok {
+			authorSet[a.authorID] = struct{}{}
+			teamNames = append(teamNames, a.authorTeam)
+		}
+	}
+	prIDs := make([]string, 0, len(prSet))
+	for id := range prSet {
+		prIDs = append(prIDs, id)
+	}
+	current, err := loadReviewersTx(ctx, tx, prIDs)
+	if err != nil {
+		return res, err
+	}
+	conflicts, err := loadConflictsTx(ctx, tx, authorSet)
+	if err != nil {
+		return res, err
+	}
+	settings, err := loadTeamSettingsTx(ctx, tx, teamNames)
+	if err != nil {
+		return res, err
+	}
+
+	seniority := seniorityIndex(participants)
+	teamLimit := settings[teamName].MaxOpenReviews
+
+	// allowed - ограничения сверх автора и дублей: конфликты, лимиты, состав по опыту
+	allowed := func(a rebalanceAssignment, to *entity.User) bool {
+		if _, conflict := conflicts[a.authorID][to.ID]; conflict {
+			return false
+		}
+		if limit, ok := to.ReviewLimit(teamLimit); ok && loads[to.ID] >= limit {
+			return false
+		}
+		level := seniority[a.reviewerID]
+		return settings[a.authorTeam].MinBySeniority(level) == 0 || to.Seniority == level
+	}
+
+	res.Moves = planRebalance(participants, loads, current, assignments, allowed, opts.Tolerance)
+	res.SpreadAfter = loadSpread(participants, loads)
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	authorTeams := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		authorTeams[a.prID] = a.authorTeam
+	}
+	byID := make(map[string]*entity.User, len(participants))
+	for _, p := range participants {
+		byID[p.ID] = p
+	}
+
+	now := s.assigner.clock.Now()
+	for _, move := range res.Moves {
+		// новый ревьювер запасной, только если команда входит в запасные команды автора
+		authorTeam := authorTeams[move.PullRequestID]
+		fromFallback := authorTeam != teamName && slices.Contains(settings[authorTeam].FallbackTeams, teamName)
+		if _, err := tx.Exec(ctx, `
+				UPDATE pr_reviewers
+				SET reviewer_id = $3, from_fallback = $4
+				WHERE pull_request_id = $1
+					AND reviewer_id = $2
+		`, move.PullRequestID, move.FromUserID, move.ToUserID, fromFallback); err != nil {
+			return res, err
+		}
+		if err := saveAssignmentTraceTx(ctx, tx, &entity.AssignmentTrace{
+			PullRequestID:      move.PullRequestID,
+			Action:             entity.TraceActionReassign,
+			ReplacedReviewerID: move.FromUserID,
+			Reviewers:          []string{move.ToUserID},
+			Steps:              []entity.AssignmentTraceStep{rebalanceTraceStep(teamName, move, byID, now)},
+			CreatedAt:          now,
+		}); err != nil {
+			return res, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// rebalanceTraceStep объясняет перенос: нагрузка снимаемого и нового ревьювера до переноса
+func rebalanceTraceStep(
+	teamName string,
+	move RebalanceMove,
+	users map[string]*entity.User,
+	now time.Time,
+) entity.AssignmentTraceStep {
+	return entity.AssignmentTraceStep{
+		Stage:    "rebalance",
+		TeamName: teamName,
+		Candidates: []entity.TraceCandidate{
+			{
+				UserID:       move.FromUserID,
+				OpenReviews:  move.FromOpenReviews,
+				WorkingHours: users[move.FromUserID].IsWorkingAt(now),
+			},
+			{
+				UserID:       move.ToUserID,
+				OpenReviews:  move.ToOpenReviews,
+				WorkingHours: users[move.ToUserID].IsWorkingAt(now),
+			},
+		},
+		Excluded: []entity.TraceExclusion{},
+		Selected: []string{move.ToUserID},
+	}
+}
+
+// planRebalance переносит назначения пока разброс нагрузки больше tolerance
+// loads и current обновляются по мере переноса
+// Каждый перенос уменьшает сумму квадратов нагрузок, поэтому цикл конечен
+func planRebalance(
+	participants []*entity.User,
+	loads map[string]int,
+	current map[string][]string,
+	assignments []rebalanceAssignment,
+	allowed func(rebalanceAssignment, *entity.User) bool,
+	tolerance int,
+) []RebalanceMove {
+	byReviewer := make(map[string][]rebalanceAssignment)
+	for _, a := range assignments {
+		byReviewer[a.reviewerID] = append(byReviewer[a.reviewerID], a)
+	}
+
+	// автора и уже назначенных ревьюверов PR не выбираем никогда
+	canTake := func(a rebalanceAssignment, to *entity.User) bool {
+		if to.ID == a.authorID {
+			return false
+		}
+		if _, ok := idSet(current[a.prID])[to.ID]; ok {
+			return false
+		}
+		return allowed(a, to)
+	}
+
+	moves := []RebalanceMove{}
+	for loadSpread(participants, loads) > tolerance {
+		move, ok := planRebalanceMove(participants, loads, byReviewer, canTake)
+		if !ok {
+			break
+		}
+
+		moves = append(moves, move)
+		loads[move.FromUserID]--
+		loads[move.ToUserID]++
+		for i, id := range current[move.PullRequestID] {
+			if id == move.FromUserID {
+				current[move.PullRequestID][i] = move.ToUserID
+			}
+		}
+		from := byReviewer[move.FromUserID]
+		for i, a := range from {
+			if a.prID == move.PullRequestID {
+				a.reviewerID = move.ToUserID
+				byReviewer[move.ToUserID] = append(byReviewer[move.ToUserID], a)
+				byReviewer[move.FromUserID] = append(from[:i:i], from[i+1:]...)
+				break
+			}
+		}
+	}
+	return moves
+}
+
+// planRebalanceMove ищет перенос с самого загруженного участника на наименее загруженного,
+// у которого разница нагрузок не меньше двух
+// Участники перебираются по убыванию и возрастанию нагрузки, при равенстве - по ID
+func planRebalanceMove(
+	participants []*entity.User,
+	loads map[string]int,
+	byReviewer map[string][]rebalanceAssignment,
+	canTake func(rebalanceAssignment, *entity.User) bool,
+) (RebalanceMove, bool) {
+	ordered := append([]*entity.User(nil), participants...)
+	sort.Slice(ordered, func(i, j int) bool {
+		if loads[ordered[i].ID] != loads[ordered[j].ID] {
+			return loads[ordered[i].ID] < loads[ordered[j].ID]
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		from := ordered[i]
+		for _, to := range ordered {
+			if loads[from.ID]-loads[to.ID] < 2 {
+				break
+			}
+			for _, a := range byReviewer[from.ID] {
+				if canTake(a, to) {
+					return RebalanceMove{
+						PullRequestID:   a.prID,
+						FromUserID:      from.ID,
+						ToUserID:        to.ID,
+						FromOpenReviews: loads[from.ID],
+						ToOpenReviews:   loads[to.ID],
+					}, true
+				}
+			}
+		}
+	}
+	return RebalanceMove{}, false
+}
+
+func loadSpread(users []*entity.User, loads map[string]int) int {
+	if len(users) == 0 {
+		return 0
+	}
+	lo, hi := loads[users[0].ID], loads[users[0].ID]
+	for _, u := range users[1:] {
+		lo = min(lo, loads[u.ID])
+		hi = max(hi, loads[u.ID])
+	}
+	return hi - lo
+}
+
+// loadMovableAssignmentsTx возвращает назначения участников команды в открытых PR,
+// кроме обязательных по CODEOWNERS; сначала самые свежие PR
+func loadMovableAssignmentsTx(ctx context.Context, tx pgx.Tx, teamName string) ([]rebalanceAssignment, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT pr.id, prr.reviewer_id, pr.author_id, author.team_name
+			FROM pr_reviewers prr
+			JOIN pull_requests pr ON pr.id = prr.pull_request_id
+			JOIN users reviewer ON reviewer.id = prr.reviewer_id
+			JOIN users author ON author.id = pr.author_id
+			WHERE reviewer.team_name = $1
+				AND pr.status = 'OPEN'
+				AND prr.required_pattern IS NULL
+			ORDER BY pr.created_at DESC, pr.id
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []rebalanceAssignment
+	for rows.Next() {
+		var a rebalanceAssignment
+		if err := rows.Scan(&a.prID, &a.reviewerID, &a.authorID, &a.authorTeam); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
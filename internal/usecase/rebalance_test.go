@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+func TestPlanRebalance(t *testing.T) {
+	t.Parallel()
+
+	busy := &entity.User{ID: "busy", IsActive: true}
+	idle := &entity.User{ID: "idle", IsActive: true}
+	mid := &entity.User{ID: "mid", IsActive: true}
+	participants := []*entity.User{busy, idle, mid}
+	anyone := func(rebalanceAssignment, *entity.User) bool { return true }
+
+	newAssignments := func() ([]rebalanceAssignment, map[string][]string) {
+		assignments := []rebalanceAssignment{
+			{prID: "pr1", reviewerID: "busy", authorID: "x"},
+			{prID: "pr2", reviewerID: "busy", authorID: "idle"},
+			{prID: "pr3", reviewerID: "busy", authorID: "x"},
+			{prID: "pr4", reviewerID: "busy", authorID: "x"},
+			{prID: "pr1", reviewerID: "mid", authorID: "x"},
+		}
+		current := map[string][]string{
+			"pr1": {"busy", "mid"},
+			"pr2": {"busy"},
+			"pr3": {"busy"},
+			"pr4": {"busy"},
+		}
+		return assignments, current
+	}
+
+	t.Run("moves until spread fits tolerance", func(t *testing.T) {
+		assignments, current := newAssignments()
+		loads := map[string]int{"busy": 4, "mid": 1}
+
+		moves := planRebalance(participants, loads, current, assignments, anyone, 1)
+		require.Equal(t, 1, loadSpread(participants, loads))
+		require.Len(t, moves, 2)
+		require.Equal(t, 4, moves[0].FromOpenReviews)
+		require.Equal(t, 0, moves[0].ToOpenReviews)
+		for _, m := range moves {
+			require.Equal(t, "busy", m.FromUserID)
+			// idle - автор pr2
+			require.False(t, m.PullRequestID == "pr2" && m.ToUserID == "idle")
+			// mid уже ревьювер pr1
+			require.False(t, m.PullRequestID == "pr1" && m.ToUserID == "mid")
+		}
+		for prID, reviewers := range current {
+			require.Len(t, idSet(reviewers), len(reviewers), prID)
+		}
+	})
+
+	t.Run("larger tolerance moves nothing", func(t *testing.T) {
+		assignments, current := newAssignments()
+		loads := map[string]int{"busy": 4, "mid": 1}
+
+		moves := planRebalance(participants, loads, current, assignments, anyone, 4)
+		require.Empty(t, moves)
+	})
+
+	t.Run("stops when nothing can be moved", func(t *testing.T) {
+		assignments, current := newAssignments()
+		loads := map[string]int{"busy": 4, "mid": 1}
+		nobody := func(rebalanceAssignment, *entity.User) bool { return false }
+
+		moves := planRebalance(participants, loads, current, assignments, nobody, 0)
+		require.Empty(t, moves)
+		require.Equal(t, 4, loadSpread(participants, loads))
+	})
+}
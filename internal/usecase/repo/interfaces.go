@@ -1,36 +1,110 @@
-// Package repo описывает контракты хранилищ
-package repo
-
-import (
-	"context"
-	"errors"
-
-	"github.com/vandermeer0/pr-reviewer/internal/entity"
-)
-
-// ErrNotFound означает что сущность не найдена
-var ErrNotFound = errors.New("not found")
-
-// ErrAlreadyExists означает что сущность уже существует
-var ErrAlreadyExists = errors.New("already exists")
-
-// UserRepository описывает работу с пользователями
-type UserRepository interface {
-	Save(ctx context.Context, user *entity.User) error
-	GetByID(ctx context.Context, id string) (*entity.User, error)
-	SaveBatch(ctx context.Context, users []*entity.User) error
-}
-
-// TeamRepository описывает работу с командами
-type TeamRepository interface {
-	Save(ctx context.Context, team *entity.Team) error
-	GetByName(ctx context.Context, name string) (*entity.Team, error)
-}
-
-// PullRequestRepository описывает работу с PR
-type PullRequestRepository interface {
-	Save(ctx context.Context, pr *entity.PullRequest) error
-	GetByID(ctx context.Context, id string) (*entity.PullRequest, error)
-	Update(ctx context.Context, pr *entity.PullRequest) error
-	GetByReviewerID(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error)
-}
+// Package repo описывает контракты хранилищ
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+// ErrNotFound означает что сущность не найдена
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists означает что сущность уже существует
+var ErrAlreadyExists = errors.New("already exists")
+
+// UserRepository описывает работу с пользователями
+type UserRepository interface {
+	Save(ctx context.Context, user *entity.User) error
+	GetByID(ctx context.Context, id string) (*entity.User, error)
+	// GetByUsername возвращает пользователя по имени, при совпадениях - с меньшим ID
+	GetByUsername(ctx context.Context, username string) (*entity.User, error)
+	SaveBatch(ctx context.Context, users []*entity.User) error
+	// AddOutOfOffice сохраняет период отсутствия и заполняет его ID
+	AddOutOfOffice(ctx context.Context, period *entity.OutOfOffice) error
+	ListOutOfOffice(ctx context.Context, userID string) ([]*entity.OutOfOffice, error)
+	// DeleteOutOfOffice удаляет период пользователя или возвращает ErrNotFound
+	DeleteOutOfOffice(ctx context.Context, userID string, id int64) error
+	// GetAwayUserIDs возвращает тех из userIDs, у кого период отсутствия покрывает момент at
+	GetAwayUserIDs(ctx context.Context, userIDs []string, at time.Time) (map[string]struct{}, error)
+}
+
+// TeamRepository описывает работу с командами
+type TeamRepository interface {
+	Save(ctx context.Context, team *entity.Team) error
+	GetByName(ctx context.Context, name string) (*entity.Team, error)
+	// GetSettings возвращает сохранённые настройки команды или ErrNotFound
+	GetSettings(ctx context.Context, teamName string) (*entity.TeamSettings, error)
+	SaveSettings(ctx context.Context, settings *entity.TeamSettings) error
+}
+
+// PullRequestRepository описывает работу с PR
+type PullRequestRepository interface {
+	Save(ctx context.Context, pr *entity.PullRequest) error
+	// SaveWithTrace создает PR и сохраняет трассировку назначения в одной транзакции
+	SaveWithTrace(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error
+	GetByID(ctx context.Context, id string) (*entity.PullRequest, error)
+	Update(ctx context.Context, pr *entity.PullRequest) error
+	// UpdateWithTrace обновляет PR и сохраняет трассировку назначения в одной транзакции
+	UpdateWithTrace(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error
+	GetByReviewerID(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error)
+	// CountOpenReviews возвращает число открытых PR на ревью у каждого из пользователей
+	CountOpenReviews(ctx context.Context, reviewerIDs []string) (map[string]int, error)
+	// GetPairHistory возвращает историю ревью PR автора, созданных не раньше since
+	GetPairHistory(
+		ctx context.Context,
+		authorID string,
+		reviewerIDs []string,
+		since time.Time,
+	) (map[string]entity.ReviewPairHistory, error)
+	// SaveAssignmentTrace сохраняет трассировку назначения и заполняет её ID
+	SaveAssignmentTrace(ctx context.Context, trace *entity.AssignmentTrace) error
+	// ListAssignmentTraces возвращает трассировки назначений PR в порядке создания
+	ListAssignmentTraces(ctx context.Context, prID string) ([]*entity.AssignmentTrace, error)
+}
+
+// OwnershipRepository описывает работу с правилами владения путями
+type OwnershipRepository interface {
+	// Save сохраняет правило и заполняет его ID
+	Save(ctx context.Context, rule *entity.OwnershipRule) error
+	List(ctx context.Context) ([]*entity.OwnershipRule, error)
+	// Delete удаляет правило или возвращает ErrNotFound
+	Delete(ctx context.Context, id int64) error
+}
+
+// CodeOwnersRepository описывает хранение импортированного файла CODEOWNERS
+type CodeOwnersRepository interface {
+	// Replace заменяет все правила новым набором
+	Replace(ctx context.Context, rules []*entity.CodeOwnersRule) error
+	// List возвращает правила в порядке строк файла
+	List(ctx context.Context) ([]*entity.CodeOwnersRule, error)
+}
+
+// ConflictRepository описывает работу с парами пользователей в конфликте интересов
+type ConflictRepository interface {
+	// Save сохраняет пару и заполняет её ID, если пара уже есть в любом порядке - ErrAlreadyExists
+	Save(ctx context.Context, conflict *entity.ReviewConflict) error
+	List(ctx context.Context) ([]*entity.ReviewConflict, error)
+	// Delete удаляет пару или возвращает ErrNotFound
+	Delete(ctx context.Context, id int64) error
+	// ConflictingUserIDs возвращает пользователей, которые в паре с userID
+	ConflictingUserIDs(ctx context.Context, userID string) (map[string]struct{}, error)
+}
+
+// RotationRepository хранит курсоры round-robin команд
+type RotationRepository interface {
+	// Last возвращает последнего выбранного по кругу пользователя команды, "" - курсора ещё нет
+	Last(ctx context.Context, teamName string) (string, error)
+	// Save сохраняет курсоры команд team_name -> последний выбранный пользователь
+	Save(ctx context.Context, cursors map[string]string) error
+}
+
+// ReviewRulesRepository хранит YAML правил подбора ревьюверов по командам
+type ReviewRulesRepository interface {
+	// Get возвращает правила команды или ErrNotFound, если их не задавали
+	Get(ctx context.Context, teamName string) (*entity.TeamReviewRules, error)
+	// Save сохраняет текст правил команды вместо прежнего
+	Save(ctx context.Context, rules *entity.TeamReviewRules) error
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// WithReviewRules включает правила команд, меняющие подбор ревьюверов по атрибутам PR
+func WithReviewRules(rulesRepo repo.ReviewRulesRepository) AssignmentOption {
+	return func(a *reviewerAssigner) {
+		a.reviewRules = rulesRepo
+	}
+}
+
+// ReviewRulesValidation - итог проверки текста правил без сохранения
+type ReviewRulesValidation struct {
+	Rules  []*entity.ReviewRule
+	Errors []entity.ReviewRuleError
+}
+
+// ReviewRulesTestInput - гипотетический PR, для которого проверяются правила команды автора
+type ReviewRulesTestInput struct {
+	AuthorID     string
+	Name         string
+	Labels       []string
+	ChangedFiles []string
+	// Content - проверяемый текст правил, nil - сохранённые правила команды автора
+	Content *string
+}
+
+// ReviewRulesTestResult - как правила изменят подбор ревьюверов для PR
+type ReviewRulesTestResult struct {
+	TeamName string
+	Matched  []string
+	// Settings - настройки команды автора с учётом сработавших правил
+	Settings  *entity.TeamSettings
+	Additions []entity.ReviewRuleAddition
+}
+
+type reviewRulesService struct {
+	rulesRepo repo.ReviewRulesRepository
+	userRepo  repo.UserRepository
+	teamRepo  repo.TeamRepository
+}
+
+// NewReviewRulesService создаёт реализацию ReviewRulesService
+func NewReviewRulesService(
+	rulesRepo repo.ReviewRulesRepository,
+	userRepo repo.UserRepository,
+	teamRepo repo.TeamRepository,
+) ReviewRulesService {
+	return &reviewRulesService{
+		rulesRepo: rulesRepo,
+		userRepo:  userRepo,
+		teamRepo:  teamRepo,
+	}
+}
+
+func (s *reviewRulesService) GetRules(ctx context.Context, teamName string) (*entity.TeamReviewRules, error) {
+	if _, err := s.teamRepo.GetByName(ctx, teamName); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("team not found")
+		}
+		return nil, err
+	}
+
+	rules, err := loadReviewRules(ctx, s.rulesRepo, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = &entity.TeamReviewRules{TeamName: teamName}
+	}
+	return rules, nil
+}
+
+func (s *reviewRulesService) SaveRules(ctx context.Context, teamName string, content string) (*entity.TeamReviewRules, error) {
+	if _, err := s.teamRepo.GetByName(ctx, teamName); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("team not found")
+		}
+		return nil, err
+	}
+
+	res, err := s.Validate(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		return nil, NewInvalidInputError(entity.ReviewRuleErrors(res.Errors).Error())
+	}
+
+	rules := &entity.TeamReviewRules{
+		TeamName: teamName,
+		Content:  content,
+		Rules:    res.Rules,
+	}
+	if err := s.rulesRepo.Save(ctx, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Validate кроме синтаксиса проверяет, что команды из add_from_team существуют
+func (s *reviewRulesService) Validate(ctx context.Context, content string) (*ReviewRulesValidation, error) {
+	rules, err := entity.ParseReviewRules(content)
+	if err != nil {
+		var ruleErrs entity.ReviewRuleErrors
+		if errors.As(err, &ruleErrs) {
+			return &ReviewRulesValidation{Rules: []*entity.ReviewRule{}, Errors: ruleErrs}, nil
+		}
+		return nil, err
+	}
+
+	res := &ReviewRulesValidation{Rules: rules, Errors: []entity.ReviewRuleError{}}
+	for _, rule := range rules {
+		add := rule.Then.AddFromTeam
+		if add == nil {
+			continue
+		}
+		if _, err := s.teamRepo.GetByName(ctx, add.TeamName); err != nil {
+			if !errors.Is(err, repo.ErrNotFound) {
+				return nil, err
+			}
+			res.Errors = append(res.Errors, entity.ReviewRuleError{
+				Line:    rule.Line,
+				Message: fmt.Sprintf("rule %q: team %s not found", rule.Name, add.TeamName),
+			})
+		}
+	}
+	return res, nil
+}
+
+func (s *reviewRulesService) Test(ctx context.Context, input ReviewRulesTestInput) (*ReviewRulesTestResult, error) {
+	author, err := s.userRepo.GetByID(ctx, input.AuthorID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("author not found")
+		}
+		return nil, err
+	}
+
+	var rules []*entity.ReviewRule
+	if input.Content != nil {
+		rules, err = entity.ParseReviewRules(*input.Content)
+		if err != nil {
+			return nil, NewInvalidInputError(err.Error())
+		}
+	} else {
+		saved, err := loadReviewRules(ctx, s.rulesRepo, author.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			rules = saved.Rules
+		}
+	}
+
+	settings, err := teamSettingsOrDefault(ctx, s.teamRepo, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := entity.EvaluateReviewRules(rules, entity.ReviewRuleSubject{
+		Name:            input.Name,
+		Labels:          input.Labels,
+		AuthorSeniority: author.Seniority,
+		ChangedFiles:    input.ChangedFiles,
+	})
+	return &ReviewRulesTestResult{
+		TeamName:  author.TeamName,
+		Matched:   outcome.Matched,
+		Settings:  outcome.Apply(settings),
+		Additions: outcome.Additions,
+	}, nil
+}
+
+// loadReviewRules читает и разбирает правила команды, nil - правил нет
+func loadReviewRules(
+	ctx context.Context,
+	rulesRepo repo.ReviewRulesRepository,
+	teamName string,
+) (*entity.TeamReviewRules, error) {
+	if rulesRepo == nil {
+		return nil, nil
+	}
+
+	rules, err := rulesRepo.Get(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// сохраняются только проверенные правила, ошибка здесь - повреждённые данные
+	rules.Rules, err = entity.ParseReviewRules(rules.Content)
+	if err != nil {
+		return nil, fmt.Errorf("review rules of team %s: %w", teamName, err)
+	}
+	return rules, nil
+}
+
+// reviewRulesOutcome вычисляет правила команды для создаваемого PR
+func reviewRulesOutcome(
+	ctx context.Context,
+	rulesRepo repo.ReviewRulesRepository,
+	teamName string,
+	subject entity.ReviewRuleSubject,
+) (*entity.ReviewRulesOutcome, error) {
+	rules, err := loadReviewRules(ctx, rulesRepo, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		return &entity.ReviewRulesOutcome{}, nil
+	}
+	return entity.EvaluateReviewRules(rules.Rules, subject), nil
+}
+
+// ruleAdditionPools возвращает участников команд из add_from_team, удалённая команда - пустой пул
+func ruleAdditionPools(
+	ctx context.Context,
+	teamRepo repo.TeamRepository,
+	additions []entity.ReviewRuleAddition,
+) ([]teamPool, error) {
+	pools := make([]teamPool, 0, len(additions))
+	for _, add := range additions {
+		pool := teamPool{TeamName: add.TeamName}
+		team, err := teamRepo.GetByName(ctx, add.TeamName)
+		if err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			pool.Members = team.Members
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
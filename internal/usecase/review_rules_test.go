@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+type inMemoryReviewRulesRepo struct {
+	rules map[string]string
+}
+
+func (r *inMemoryReviewRulesRepo) Get(_ context.Context, teamName string) (*entity.TeamReviewRules, error) {
+	content, ok := r.rules[teamName]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return &entity.TeamReviewRules{TeamName: teamName, Content: content}, nil
+}
+
+func (r *inMemoryReviewRulesRepo) Save(_ context.Context, rules *entity.TeamReviewRules) error {
+	r.rules[rules.TeamName] = rules.Content
+	return nil
+}
+
+const testReviewRules = `
+rules:
+  - name: security
+    when: {label: security}
+    then:
+      add_from_team: {team: security, count: 1}
+  - name: hotfix
+    when:
+      name_prefix: "[hotfix]"
+    then:
+      reviewers: 1
+  - name: junior author
+    when: {author_seniority: junior}
+    then: {min_senior_reviewers: 1}
+`
+
+func TestParseReviewRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := entity.ParseReviewRules(testReviewRules)
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	require.Equal(t, 3, rules[0].Line)
+	require.Equal(t, &entity.ReviewRuleAddition{TeamName: "security", Count: 1}, rules[0].Then.AddFromTeam)
+
+	outcome := entity.EvaluateReviewRules(rules, entity.ReviewRuleSubject{
+		Name:            "[HOTFIX] token leak",
+		Labels:          []string{"Security"},
+		AuthorSeniority: entity.SeniorityJunior,
+	})
+	require.Equal(t, []string{"security", "hotfix", "junior author"}, outcome.Matched)
+	settings := outcome.Apply(&entity.TeamSettings{TeamName: "backend", MinReviewers: 2, MaxReviewers: 3})
+	require.Equal(t, 1, settings.MinReviewers)
+	require.Equal(t, 1, settings.MaxReviewers)
+	require.Equal(t, 1, settings.MinSeniorReviewers)
+
+	// состав по уровню не превышает число ревьюверов из правила
+	capped := (&entity.ReviewRulesOutcome{Reviewers: intPtr(1), MinSeniorReviewers: 1, MinJuniorReviewers: 1}).
+		Apply(&entity.TeamSettings{TeamName: "backend", MinReviewers: 2, MaxReviewers: 3})
+	require.Equal(t, 1, capped.MaxReviewers)
+	require.Equal(t, 1, capped.MinSeniorReviewers)
+	require.Zero(t, capped.MinJuniorReviewers)
+
+	none, err := entity.ParseReviewRules("")
+	require.NoError(t, err)
+	require.Empty(t, none)
+
+	cases := []struct {
+		name    string
+		content string
+		want    []entity.ReviewRuleError
+	}{
+		{
+			name:    "syntax",
+			content: "rules:\n  - name: a\n    when: {label: x\n",
+			want:    []entity.ReviewRuleError{{Line: 2}},
+		},
+		{
+			name:    "wrong type",
+			content: "rules:\n  - name: a\n    when: {label: x}\n    then: {reviewers: many}\n",
+			want:    []entity.ReviewRuleError{{Line: 4, Message: "cannot unmarshal !!str `many` into int"}},
+		},
+		{
+			name:    "unknown field",
+			content: "rules:\n  - name: a\n    when: {lable: x}\n    then: {reviewers: 1}\n",
+			want:    []entity.ReviewRuleError{{Line: 3, Message: "field lable not found"}},
+		},
+		{
+			name: "invalid rules",
+			content: `rules:
+  - name: a
+    when: {author_seniority: lead}
+    then: {reviewers: 0}
+  - name: a
+    when: {label: x}
+    then: {add_from_team: {team: security}}
+  - when: {label: y}
+`,
+			want: []entity.ReviewRuleError{
+				{Line: 2, Message: `rule "a": author_seniority: unknown seniority "lead": expected junior, middle or senior`},
+				{Line: 2, Message: `rule "a": reviewers must be at least 1`},
+				{Line: 5, Message: `rule "a": add_from_team.count must be at least 1`},
+				{Line: 5, Message: `rule "a" is defined twice`},
+				{Line: 8, Message: "rule name is required"},
+				{Line: 8, Message: "rule: then must set at least one action"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := entity.ParseReviewRules(tc.content)
+			var errs entity.ReviewRuleErrors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, len(tc.want))
+			for i, want := range tc.want {
+				require.Equal(t, want.Line, errs[i].Line)
+				if want.Message != "" {
+					require.Equal(t, want.Message, errs[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestPullRequestService_ReviewRules(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+	rr := &inMemoryReviewRulesRepo{rules: make(map[string]string)}
+
+	author := &entity.User{ID: "a", Username: "author", TeamName: "backend", IsActive: true, Seniority: entity.SeniorityJunior}
+	b1 := &entity.User{ID: "b1", Username: "b1", TeamName: "backend", IsActive: true, Seniority: entity.SeniorityMiddle}
+	b2 := &entity.User{ID: "b2", Username: "b2", TeamName: "backend", IsActive: true, Seniority: entity.SeniorityMiddle}
+	b3 := &entity.User{ID: "b3", Username: "b3", TeamName: "backend", IsActive: true, Seniority: entity.SenioritySenior}
+	sec := &entity.User{ID: "sec", Username: "sec", TeamName: "security", IsActive: true}
+	for _, u := range []*entity.User{author, b1, b2, b3, sec} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "backend", Members: []*entity.User{author, b1, b2, b3}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "security", Members: []*entity.User{sec}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "backend", MinReviewers: 2, MaxReviewers: 2}))
+
+	rulesSvc := NewReviewRulesService(rr, ur, tr)
+	saved, err := rulesSvc.SaveRules(ctx, "backend", testReviewRules)
+	require.NoError(t, err)
+	require.Len(t, saved.Rules, 3)
+
+	svc := NewPullRequestService(prr, ur, tr, WithReviewRules(rr))
+
+	t.Run("hotfix from junior gets a single senior", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-hotfix", Name: "[hotfix] crash", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.Equal(t, []string{b3.ID}, pr.Reviewers)
+		require.Equal(t, 1, pr.TargetReviewers)
+	})
+
+	t.Run("security label adds reviewer from security team", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-sec", Name: "Rotate keys", AuthorID: author.ID, Labels: []string{"security"},
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 3)
+		require.Contains(t, pr.Reviewers, sec.ID)
+		require.Contains(t, pr.Reviewers, b3.ID)
+		require.Equal(t, []string{sec.ID}, pr.RuleReviewers)
+		// место правила не входит в число ревьюверов команды
+		require.Equal(t, 2, pr.TargetReviewers)
+		require.False(t, pr.IsUnderstaffed())
+
+		traces, err := svc.GetAssignmentTraces(ctx, "pr-sec")
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		require.Equal(t, "rule", traces[0].Steps[0].Stage)
+		require.Equal(t, "security", traces[0].Steps[0].Scope)
+	})
+
+	t.Run("vacated rule slot is not refilled from author team", func(t *testing.T) {
+		_, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-sec-reopen", Name: "Rotate tokens", AuthorID: author.ID, Labels: []string{"security"},
+		})
+		require.NoError(t, err)
+		_, err = svc.Close(ctx, "pr-sec-reopen")
+		require.NoError(t, err)
+
+		sec.IsActive = false
+		require.NoError(t, ur.Save(ctx, sec))
+		t.Cleanup(func() {
+			sec.IsActive = true
+			require.NoError(t, ur.Save(ctx, sec))
+		})
+
+		pr, err := svc.Reopen(ctx, "pr-sec-reopen")
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+		require.NotContains(t, pr.Reviewers, sec.ID)
+		require.Empty(t, pr.RuleReviewers)
+		require.False(t, pr.IsUnderstaffed())
+	})
+
+	t.Run("rule team without available reviewers blocks create", func(t *testing.T) {
+		sec.IsActive = false
+		t.Cleanup(func() { sec.IsActive = true })
+
+		_, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-sec-2", Name: "Rotate keys", AuthorID: author.ID, Labels: []string{"security"},
+		})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+}
+
+func TestReviewRulesService_ValidateAndTest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	rr := &inMemoryReviewRulesRepo{rules: make(map[string]string)}
+
+	author := &entity.User{ID: "a", Username: "author", TeamName: "backend", IsActive: true}
+	require.NoError(t, ur.Save(ctx, author))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "backend", Members: []*entity.User{author}}))
+
+	svc := NewReviewRulesService(rr, ur, tr)
+
+	res, err := svc.Validate(ctx, testReviewRules)
+	require.NoError(t, err)
+	require.Len(t, res.Rules, 3)
+	require.Equal(t, []entity.ReviewRuleError{{Line: 3, Message: `rule "security": team security not found`}}, res.Errors)
+
+	_, err = svc.SaveRules(ctx, "backend", testReviewRules)
+	var de *DomainError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+	_, err = svc.SaveRules(ctx, "ghost", "")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+
+	rules, err := svc.GetRules(ctx, "backend")
+	require.NoError(t, err)
+	require.Empty(t, rules.Rules)
+
+	// непроверенный текст правил можно проверить на гипотетическом PR до сохранения
+	content := testReviewRules
+	got, err := svc.Test(ctx, ReviewRulesTestInput{
+		AuthorID: author.ID,
+		Name:     "[hotfix] leak",
+		Labels:   []string{"security"},
+		Content:  &content,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "backend", got.TeamName)
+	require.Equal(t, []string{"security", "hotfix"}, got.Matched)
+	// ревьюверы правила в max_reviewers не входят
+	require.Equal(t, 1, got.Settings.MaxReviewers)
+	require.Equal(t, []entity.ReviewRuleAddition{{Rule: "security", TeamName: "security", Count: 1}}, got.Additions)
+
+	got, err = svc.Test(ctx, ReviewRulesTestInput{AuthorID: author.ID, Name: "[hotfix] leak"})
+	require.NoError(t, err)
+	require.Empty(t, got.Matched)
+	require.Equal(t, entity.DefaultMaxReviewers, got.Settings.MaxReviewers)
+}
@@ -0,0 +1,407 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+// SelectionStrategy - имя стратегии выбора ревьюверов
+type SelectionStrategy string
+
+const (
+	// StrategyRandom - равновероятный случайный выбор
+	StrategyRandom SelectionStrategy = "random"
+	// StrategyRoundRobin - выбор по кругу внутри команды
+	StrategyRoundRobin SelectionStrategy = "round_robin"
+	// StrategyLeastLoaded - выбор наименее загруженных открытыми ревью
+	StrategyLeastLoaded SelectionStrategy = "least_loaded"
+	// StrategyPairDiversity - случайный выбор с пониженным шансом для недавних пар автор/ревьювер
+	StrategyPairDiversity SelectionStrategy = "pair_diversity"
+	// StrategyWeightedRandom - случайный выбор с шансом пропорциональным весу пользователя
+	StrategyWeightedRandom SelectionStrategy = "weighted_random"
+)
+
+// ReviewerCandidate - кандидат в ревьюверы вместе с данными для выбора
+type ReviewerCandidate struct {
+	User        *entity.User
+	OpenReviews int
+	// PairReviews - сколько PR автора кандидат ревьюил за окно истории
+	PairReviews int
+	// PairFreshness - свежесть последнего такого ревью: 1 - только что, 0 - вне окна
+	PairFreshness float64
+}
+
+// ReviewerSelectionInput - данные для выбора ревьюверов
+type ReviewerSelectionInput struct {
+	// TeamName - команда кандидатов, по ней ведётся курсор round_robin
+	TeamName   string
+	AuthorID   string
+	Candidates []ReviewerCandidate
+	Count      int
+	// Cursor - курсор round_robin из ещё не сохранённых назначений операции, пусто - курсор из хранилища
+	Cursor string
+}
+
+// ReviewerSelection - результат выбора стратегии
+type ReviewerSelection struct {
+	Reviewers []ReviewerCandidate
+	Strategy  SelectionStrategy
+	// Seed - зерно генератора, на котором сделан выбор, 0 - стратегия без случайности
+	Seed int64
+	// Cursor - новый курсор round_robin (последний выбранный), пусто - стратегия без курсора;
+	// стратегия его не сохраняет, курсор сдвигается после записи назначения
+	Cursor string
+}
+
+// ReviewerSelector выбирает ревьюверов из уже отфильтрованных кандидатов
+type ReviewerSelector interface {
+	// Select возвращает не больше Count кандидатов
+	Select(ctx context.Context, input ReviewerSelectionInput) (ReviewerSelection, error)
+}
+
+// ReviewerSelectors хранит стратегию по умолчанию и стратегии отдельных команд
+type ReviewerSelectors struct {
+	defaultSelector ReviewerSelector
+	byTeam          map[string]ReviewerSelector
+	// rotation - курсоры round_robin, сюда сохраняются курсоры записанных назначений
+	rotation repo.RotationRepository
+}
+
+// selectorsConfig - общие зависимости стратегий
+type selectorsConfig struct {
+	rotation repo.RotationRepository
+}
+
+// SelectorsOption настраивает стратегии, которые собирает NewReviewerSelectors
+type SelectorsOption func(cfg *selectorsConfig)
+
+// WithPersistentRotation хранит курсоры round_robin в rotation вместо памяти процесса
+func WithPersistentRotation(rotation repo.RotationRepository) SelectorsOption {
+	return func(cfg *selectorsConfig) {
+		if rotation != nil {
+			cfg.rotation = rotation
+		}
+	}
+}
+
+// NewReviewerSelectors собирает стратегии по именам из конфигурации
+func NewReviewerSelectors(
+	defaultStrategy string,
+	teamStrategies map[string]string,
+	opts ...SelectorsOption,
+) (*ReviewerSelectors, error) {
+	cfg := selectorsConfig{rotation: newMemoryRotation()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	instances := map[SelectionStrategy]ReviewerSelector{
+		StrategyRandom:         NewRandomSelector(time.Now().UnixNano()),
+		StrategyRoundRobin:     NewPersistentRoundRobinSelector(cfg.rotation),
+		StrategyLeastLoaded:    NewLeastLoadedSelector(time.Now().UnixNano()),
+		StrategyPairDiversity:  NewPairDiversitySelector(time.Now().UnixNano()),
+		StrategyWeightedRandom: NewWeightedRandomSelector(time.Now().UnixNano()),
+	}
+
+	lookup := func(name string) (ReviewerSelector, error) {
+		sel, ok := instances[SelectionStrategy(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown reviewer selection strategy %q", name)
+		}
+		return sel, nil
+	}
+
+	if defaultStrategy == "" {
+		defaultStrategy = string(StrategyRandom)
+	}
+	def, err := lookup(defaultStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	byTeam := make(map[string]ReviewerSelector, len(teamStrategies))
+	for team, name := range teamStrategies {
+		sel, err := lookup(name)
+		if err != nil {
+			return nil, fmt.Errorf("team %s: %w", team, err)
+		}
+		byTeam[team] = sel
+	}
+
+	return &ReviewerSelectors{
+		defaultSelector: def,
+		byTeam:          byTeam,
+		rotation:        cfg.rotation,
+	}, nil
+}
+
+func defaultReviewerSelectors() *ReviewerSelectors {
+	return &ReviewerSelectors{
+		defaultSelector: NewRandomSelector(time.Now().UnixNano()),
+		byTeam:          map[string]ReviewerSelector{},
+		rotation:        newMemoryRotation(),
+	}
+}
+
+// ForTeam возвращает стратегию команды или стратегию по умолчанию
+func (s *ReviewerSelectors) ForTeam(teamName string) ReviewerSelector {
+	if sel, ok := s.byTeam[teamName]; ok {
+		return sel
+	}
+	return s.defaultSelector
+}
+
+type randomSelector struct {
+	rng *lockedRand
+}
+
+// NewRandomSelector создаёт стратегию равновероятного выбора
+func NewRandomSelector(seed int64) ReviewerSelector {
+	return &randomSelector{rng: newLockedRand(seed)}
+}
+
+func (s *randomSelector) Select(_ context.Context, input ReviewerSelectionInput) (ReviewerSelection, error) {
+	seed, rng := s.rng.decision()
+	out := append([]ReviewerCandidate(nil), input.Candidates...)
+	rng.Shuffle(len(out), func(i, j int) {
+		out[i], out[j] = out[j], out[i]
+	})
+	return ReviewerSelection{
+		Reviewers: limitCandidates(out, input.Count),
+		Strategy:  StrategyRandom,
+		Seed:      seed,
+	}, nil
+}
+
+type roundRobinSelector struct {
+	rotation repo.RotationRepository
+}
+
+// NewRoundRobinSelector создаёт стратегию выбора по кругу с курсором в памяти процесса
+// Курсор команды хранит последнего выбранного пользователя, поэтому
+// выбывшие кандидаты не сбивают очередь
+func NewRoundRobinSelector() ReviewerSelector {
+	return &roundRobinSelector{rotation: newMemoryRotation()}
+}
+
+// NewPersistentRoundRobinSelector создаёт стратегию выбора по кругу с курсором в хранилище
+// Курсор переживает перезапуск и общий для всех экземпляров сервиса
+// Стратегия курсор только читает: новый курсор возвращается в ReviewerSelection.Cursor
+// и сохраняется вызывающим после записи назначения
+func NewPersistentRoundRobinSelector(rotation repo.RotationRepository) ReviewerSelector {
+	return &roundRobinSelector{rotation: rotation}
+}
+
+func (s *roundRobinSelector) Select(ctx context.Context, input ReviewerSelectionInput) (ReviewerSelection, error) {
+	res := ReviewerSelection{Strategy: StrategyRoundRobin}
+	sorted := append([]ReviewerCandidate(nil), input.Candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].User.ID < sorted[j].User.ID
+	})
+	if input.Count <= 0 || len(sorted) == 0 {
+		return res, nil
+	}
+
+	last := input.Cursor
+	if last == "" {
+		var err error
+		last, err = s.rotation.Last(ctx, input.TeamName)
+		if err != nil {
+			return ReviewerSelection{}, err
+		}
+	}
+
+	res.Reviewers = rotateFrom(sorted, last, input.Count)
+	res.Cursor = res.Reviewers[len(res.Reviewers)-1].User.ID
+	return res, nil
+}
+
+// memoryRotation - курсоры round_robin в памяти процесса
+type memoryRotation struct {
+	mu   sync.Mutex
+	last map[string]string
+}
+
+func newMemoryRotation() *memoryRotation {
+	return &memoryRotation{last: make(map[string]string)}
+}
+
+func (r *memoryRotation) Last(_ context.Context, teamName string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last[teamName], nil
+}
+
+func (r *memoryRotation) Save(_ context.Context, cursors map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for team, last := range cursors {
+		r.last[team] = last
+	}
+	return nil
+}
+
+// rotateFrom берёт до n кандидатов по кругу, начиная с первого после last
+// Кандидаты отсортированы по ID, last может уже не быть среди них
+func rotateFrom(sorted []ReviewerCandidate, last string, n int) []ReviewerCandidate {
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	start := 0
+	if last != "" {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return sorted[i].User.ID > last
+		})
+	}
+
+	out := make([]ReviewerCandidate, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, sorted[(start+i)%len(sorted)])
+	}
+	return out
+}
+
+type leastLoadedSelector struct {
+	rng *lockedRand
+}
+
+// NewLeastLoadedSelector создаёт стратегию выбора наименее загруженных
+// Кандидаты ранжируются по числу открытых ревью, равные - в случайном порядке
+func NewLeastLoadedSelector(seed int64) ReviewerSelector {
+	return &leastLoadedSelector{rng: newLockedRand(seed)}
+}
+
+func (s *leastLoadedSelector) Select(_ context.Context, input ReviewerSelectionInput) (ReviewerSelection, error) {
+	seed, rng := s.rng.decision()
+	out := append([]ReviewerCandidate(nil), input.Candidates...)
+	rng.Shuffle(len(out), func(i, j int) {
+		out[i], out[j] = out[j], out[i]
+	})
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].OpenReviews < out[j].OpenReviews
+	})
+	return ReviewerSelection{
+		Reviewers: limitCandidates(out, input.Count),
+		Strategy:  StrategyLeastLoaded,
+		Seed:      seed,
+	}, nil
+}
+
+type pairDiversitySelector struct {
+	rng *lockedRand
+}
+
+// NewPairDiversitySelector создаёт стратегию, которая реже сводит уже работавшие пары
+// Вес кандидата падает с числом недавних ревью PR автора и со свежестью последнего,
+// выбор - взвешенная случайная выборка без повторов
+func NewPairDiversitySelector(seed int64) ReviewerSelector {
+	return &pairDiversitySelector{rng: newLockedRand(seed)}
+}
+
+// minPairWeight - доля шанса, которая остаётся у только что работавшей пары
+const minPairWeight = 0.1
+
+func (s *pairDiversitySelector) Select(_ context.Context, input ReviewerSelectionInput) (ReviewerSelection, error) {
+	seed, rng := s.rng.decision()
+	return ReviewerSelection{
+		Reviewers: weightedSample(rng, input.Candidates, input.Count, pairWeight),
+		Strategy:  StrategyPairDiversity,
+		Seed:      seed,
+	}, nil
+}
+
+func pairWeight(c ReviewerCandidate) float64 {
+	freshness := math.Min(math.Max(c.PairFreshness, 0), 1)
+	return (1 - (1-minPairWeight)*freshness) / float64(1+c.PairReviews)
+}
+
+type weightedRandomSelector struct {
+	rng *lockedRand
+}
+
+// NewWeightedRandomSelector создаёт стратегию выбора по весам пользователей
+// Пользователь с весом 0.5 в среднем получает вдвое меньше ревью, чем с весом 1
+func NewWeightedRandomSelector(seed int64) ReviewerSelector {
+	return &weightedRandomSelector{rng: newLockedRand(seed)}
+}
+
+func (s *weightedRandomSelector) Select(_ context.Context, input ReviewerSelectionInput) (ReviewerSelection, error) {
+	seed, rng := s.rng.decision()
+	return ReviewerSelection{
+		Reviewers: weightedSample(rng, input.Candidates, input.Count, reviewWeight),
+		Strategy:  StrategyWeightedRandom,
+		Seed:      seed,
+	}, nil
+}
+
+func reviewWeight(c ReviewerCandidate) float64 {
+	return c.User.EffectiveReviewWeight()
+}
+
+// weightedSample выбирает n кандидатов без повторов с вероятностью пропорциональной весу
+// (ключ log(u)/w, берутся наибольшие)
+func weightedSample(
+	rng *rand.Rand,
+	candidates []ReviewerCandidate,
+	n int,
+	weight func(ReviewerCandidate) float64,
+) []ReviewerCandidate {
+	type keyed struct {
+		c   ReviewerCandidate
+		key float64
+	}
+	items := make([]keyed, 0, len(candidates))
+	for _, c := range candidates {
+		w := weight(c)
+		if w <= 0 {
+			continue
+		}
+		items = append(items, keyed{c: c, key: math.Log(1-rng.Float64()) / w})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].key > items[j].key
+	})
+
+	out := make([]ReviewerCandidate, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.c)
+	}
+	return limitCandidates(out, n)
+}
+
+func limitCandidates(candidates []ReviewerCandidate, n int) []ReviewerCandidate {
+	if n <= 0 {
+		return nil
+	}
+	if len(candidates) > n {
+		return candidates[:n]
+	}
+	return candidates
+}
+
+// lockedRand - потокобезопасный источник зёрен для отдельных выборов
+type lockedRand struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rng: rand.New(rand.NewSource(seed))}
+}
+
+// decision выдаёт зерно и генератор для одного выбора,
+// по зерну из трассировки выбор можно воспроизвести
+func (r *lockedRand) decision() (int64, *rand.Rand) {
+	r.mu.Lock()
+	seed := r.rng.Int63()
+	r.mu.Unlock()
+	return seed, rand.New(rand.NewSource(seed))
+}
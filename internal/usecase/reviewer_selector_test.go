@@ -0,0 +1,360 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+func candidatesOf(loads map[string]int, ids ...string) []ReviewerCandidate {
+	out := make([]ReviewerCandidate, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, ReviewerCandidate{
+			User:        &entity.User{ID: id, Username: id, TeamName: "team", IsActive: true},
+			OpenReviews: loads[id],
+		})
+	}
+	return out
+}
+
+func selectedIDs(selected []ReviewerCandidate) []string {
+	ids := make([]string, 0, len(selected))
+	for _, c := range selected {
+		ids = append(ids, c.User.ID)
+	}
+	return ids
+}
+
+func TestRoundRobinSelector_RotatesAndKeepsPosition(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sel := NewRoundRobinSelector()
+
+	// курсор каждого выбора передаётся в следующий, как внутри одной операции
+	var cursor string
+	pick := func(count int, ids ...string) []string {
+		out, err := sel.Select(ctx, ReviewerSelectionInput{
+			TeamName:   "team",
+			Candidates: candidatesOf(nil, ids...),
+			Count:      count,
+			Cursor:     cursor,
+		})
+		require.NoError(t, err)
+		cursor = out.Cursor
+		return selectedIDs(out.Reviewers)
+	}
+
+	require.Equal(t, []string{"u1", "u2"}, pick(2, "u1", "u2", "u3", "u4"))
+	// u3 выбыл (например стал автором) - очередь продолжается с u4
+	require.Equal(t, []string{"u4"}, pick(1, "u1", "u2", "u4"))
+	require.Equal(t, []string{"u1", "u2"}, pick(2, "u4", "u2", "u1", "u3"))
+	require.Equal(t, []string{"u3", "u4"}, pick(2, "u1", "u2", "u3", "u4"))
+}
+
+// inMemoryRotationRepo - курсоры round-robin, saves считает сохранения курсора каждой команды
+type inMemoryRotationRepo struct {
+	mu      sync.Mutex
+	cursors map[string]string
+	saves   map[string]int
+}
+
+func (r *inMemoryRotationRepo) Last(_ context.Context, teamName string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cursors[teamName], nil
+}
+
+func (r *inMemoryRotationRepo) Save(_ context.Context, cursors map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for team, last := range cursors {
+		r.cursors[team] = last
+		if r.saves != nil {
+			r.saves[team]++
+		}
+	}
+	return nil
+}
+
+func TestPersistentRoundRobinSelector(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rotation := &inMemoryRotationRepo{cursors: map[string]string{"team": "u2"}}
+	sel := NewPersistentRoundRobinSelector(rotation)
+
+	t.Run("continues from stored cursor and skips missing candidates", func(t *testing.T) {
+		// u3 - автор, поэтому его нет среди кандидатов
+		out, err := sel.Select(ctx, ReviewerSelectionInput{
+			TeamName: "team", AuthorID: "u3", Candidates: candidatesOf(nil, "u1", "u2", "u4"), Count: 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"u4"}, selectedIDs(out.Reviewers))
+		require.Equal(t, "u4", out.Cursor)
+		// выбор курсор не сохраняет, это делает сервис после записи назначения
+		require.Equal(t, "u2", rotation.cursors["team"])
+	})
+
+	t.Run("unsaved cursor of the operation wins over stored", func(t *testing.T) {
+		out, err := sel.Select(ctx, ReviewerSelectionInput{
+			TeamName: "team", Candidates: candidatesOf(nil, "u1", "u2", "u3", "u4"), Count: 2, Cursor: "u3",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"u4", "u1"}, selectedIDs(out.Reviewers))
+		require.Equal(t, "u1", out.Cursor)
+	})
+}
+
+func TestLeastLoadedSelector_PrefersFewerOpenReviews(t *testing.T) {
+	t.Parallel()
+
+	sel := NewLeastLoadedSelector(1)
+	loads := map[string]int{"u1": 3, "u2": 0, "u3": 1}
+
+	out, err := sel.Select(context.Background(), ReviewerSelectionInput{
+		TeamName:   "team",
+		Candidates: candidatesOf(loads, "u1", "u2", "u3"),
+		Count:      2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u2", "u3"}, selectedIDs(out.Reviewers))
+	require.Equal(t, StrategyLeastLoaded, out.Strategy)
+	require.NotZero(t, out.Seed)
+}
+
+func TestLeastLoadedSelector_BreaksTiesRandomly(t *testing.T) {
+	t.Parallel()
+
+	sel := NewLeastLoadedSelector(42)
+	loads := map[string]int{"u1": 1, "u2": 1, "u3": 1, "busy": 5}
+
+	seen := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		out, err := sel.Select(context.Background(), ReviewerSelectionInput{
+			TeamName:   "team",
+			Candidates: candidatesOf(loads, "u1", "u2", "u3", "busy"),
+			Count:      1,
+		})
+		require.NoError(t, err)
+		require.Len(t, out.Reviewers, 1)
+		seen[out.Reviewers[0].User.ID]++
+	}
+
+	require.Zero(t, seen["busy"])
+	for _, id := range []string{"u1", "u2", "u3"} {
+		require.Positive(t, seen[id], "tie should be broken randomly, %s never picked", id)
+	}
+}
+
+func TestPairDiversitySelector_Weights(t *testing.T) {
+	t.Parallel()
+
+	sel := NewPairDiversitySelector(3)
+	candidates := candidatesOf(nil, "fresh", "stale", "new")
+	candidates[0].PairReviews, candidates[0].PairFreshness = 4, 1
+	candidates[1].PairReviews, candidates[1].PairFreshness = 1, 0.1
+
+	picks := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		out, err := sel.Select(context.Background(), ReviewerSelectionInput{TeamName: "team", Candidates: candidates, Count: 1})
+		require.NoError(t, err)
+		picks[out.Reviewers[0].User.ID]++
+	}
+
+	// веса: fresh 0.02, stale ~0.46, new 1
+	require.Less(t, picks["fresh"], 100)
+	require.Less(t, picks["stale"], picks["new"])
+	require.Positive(t, picks["stale"])
+}
+
+func TestWeightedRandomSelector_Weights(t *testing.T) {
+	t.Parallel()
+
+	sel := NewWeightedRandomSelector(5)
+	candidates := candidatesOf(nil, "full", "half", "default")
+	candidates[0].User.ReviewWeight = 1
+	candidates[1].User.ReviewWeight = 0.5
+
+	picks := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		out, err := sel.Select(context.Background(), ReviewerSelectionInput{TeamName: "team", Candidates: candidates, Count: 1})
+		require.NoError(t, err)
+		require.Equal(t, StrategyWeightedRandom, out.Strategy)
+		picks[out.Reviewers[0].User.ID]++
+	}
+
+	// веса 1 : 0.5 : 1 (не заданный вес считается 1) - ожидаемо 1200 : 600 : 1200
+	require.InDelta(t, 600, picks["half"], 120)
+	require.InDelta(t, 1200, picks["full"], 150)
+	require.InDelta(t, 1200, picks["default"], 150)
+}
+
+func TestNewReviewerSelectors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("per-team strategy", func(t *testing.T) {
+		selectors, err := NewReviewerSelectors("random", map[string]string{
+			"backend": "least_loaded",
+		})
+		require.NoError(t, err)
+		require.IsType(t, &leastLoadedSelector{}, selectors.ForTeam("backend"))
+		require.IsType(t, &randomSelector{}, selectors.ForTeam("frontend"))
+	})
+
+	t.Run("persistent rotation", func(t *testing.T) {
+		rotation := &inMemoryRotationRepo{cursors: map[string]string{"backend": "u1"}}
+		selectors, err := NewReviewerSelectors("round_robin", nil, WithPersistentRotation(rotation))
+		require.NoError(t, err)
+
+		out, err := selectors.ForTeam("backend").Select(context.Background(), ReviewerSelectionInput{
+			TeamName: "backend", Candidates: candidatesOf(nil, "u1", "u2"), Count: 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"u2"}, selectedIDs(out.Reviewers))
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		_, err := NewReviewerSelectors("random", map[string]string{
+			"backend": "by_mood",
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestPullRequestService_ReassignReviewer_LeastLoaded(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
+	old := &entity.User{ID: "old", Username: "Old", TeamName: "team", IsActive: true}
+	busy := &entity.User{ID: "busy", Username: "Busy", TeamName: "team", IsActive: true}
+	idle := &entity.User{ID: "idle", Username: "Idle", TeamName: "team", IsActive: true}
+	for _, u := range []*entity.User{author, old, busy, idle} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{
+		Name:    "team",
+		Members: []*entity.User{author, old, busy, idle},
+	}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "pr-busy-1", Name: "B1", AuthorID: idle.ID, Status: entity.StatusOpen, Reviewers: []string{busy.ID},
+	}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "pr", Name: "PR", AuthorID: author.ID, Status: entity.StatusOpen, Reviewers: []string{old.ID},
+	}))
+
+	selectors, err := NewReviewerSelectors("least_loaded", nil)
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(prr, ur, tr, WithReviewerSelectors(selectors))
+	_, replacedBy, err := svc.ReassignReviewer(ctx, "pr", old.ID)
+	require.NoError(t, err)
+	require.Equal(t, idle.ID, replacedBy)
+}
+
+func TestPullRequestService_Create_UsesTeamSelector(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
+	busy := &entity.User{ID: "busy", Username: "Busy", TeamName: "team", IsActive: true}
+	idle1 := &entity.User{ID: "idle1", Username: "Idle1", TeamName: "team", IsActive: true}
+	idle2 := &entity.User{ID: "idle2", Username: "Idle2", TeamName: "team", IsActive: true}
+	for _, u := range []*entity.User{author, busy, idle1, idle2} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{
+		Name:    "team",
+		Members: []*entity.User{author, busy, idle1, idle2},
+	}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID:        "pr-old",
+		Name:      "Old",
+		AuthorID:  idle1.ID,
+		Status:    entity.StatusOpen,
+		Reviewers: []string{busy.ID},
+	}))
+
+	selectors, err := NewReviewerSelectors("random", map[string]string{"team": "least_loaded"})
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(prr, ur, tr, WithReviewerSelectors(selectors))
+	pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-new", Name: "New", AuthorID: author.ID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"idle1", "idle2"}, pr.Reviewers)
+}
+
+func TestPullRequestService_Create_RoundRobinCursorPerPool(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	weekdays := entity.WeekdayMask(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+	office := &entity.WorkSchedule{Start: 9 * 60, End: 18 * 60, Weekdays: weekdays}
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	newYork := &entity.User{ID: "nyc", Username: "Nyc", TeamName: "t", IsActive: true, Timezone: "America/New_York", Schedule: office}
+	msk1 := &entity.User{ID: "msk1", Username: "Msk1", TeamName: "t", IsActive: true, Timezone: "Europe/Moscow", Schedule: office}
+	msk2 := &entity.User{ID: "msk2", Username: "Msk2", TeamName: "t", IsActive: true, Timezone: "Europe/Moscow", Schedule: office}
+	solo := &entity.User{ID: "solo", Username: "Solo", TeamName: "tiny", IsActive: true}
+	p1 := &entity.User{ID: "p1", Username: "P1", TeamName: "platform", IsActive: true}
+	s1 := &entity.User{ID: "s1", Username: "S1", TeamName: "short", IsActive: true}
+	s2 := &entity.User{ID: "s2", Username: "S2", TeamName: "short", IsActive: true}
+	for _, u := range []*entity.User{author, newYork, msk1, msk2, solo, p1, s1, s2} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, newYork, msk1, msk2}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "tiny", Members: []*entity.User{solo}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "platform", Members: []*entity.User{p1}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "short", Members: []*entity.User{s1, s2}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "short", MinReviewers: 2, MaxReviewers: 2}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 2}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{
+		TeamName: "tiny", MinReviewers: 1, MaxReviewers: 1, FallbackTeams: []string{"platform"},
+	}))
+
+	rotation := &inMemoryRotationRepo{cursors: map[string]string{}, saves: map[string]int{}}
+	selectors, err := NewReviewerSelectors("round_robin", nil, WithPersistentRotation(rotation))
+	require.NoError(t, err)
+	// среда 15:00 UTC: в Москве 18:00, в Нью-Йорке 10:00
+	now := time.Date(2025, time.November, 5, 15, 0, 0, 0, time.UTC)
+	svc := NewPullRequestService(newInMemoryPRRepo(), ur, tr,
+		WithReviewerSelectors(selectors), WithClock(fixedClock{now: now}))
+
+	t.Run("working and off-hours pools advance the cursor once", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-t", Name: "T", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+		require.Contains(t, pr.Reviewers, newYork.ID)
+		require.Equal(t, 1, rotation.saves["t"])
+	})
+
+	t.Run("fallback pick advances the fallback team cursor", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-tiny", Name: "Tiny", AuthorID: solo.ID})
+		require.NoError(t, err)
+		require.Equal(t, []string{p1.ID}, pr.Reviewers)
+		require.Equal(t, p1.ID, rotation.cursors["platform"])
+		require.Zero(t, rotation.saves["tiny"])
+	})
+
+	t.Run("failed create keeps the cursor", func(t *testing.T) {
+		// в short кроме автора один ревьюер, а нужно два
+		_, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-short", Name: "Short", AuthorID: s1.ID})
+		require.Error(t, err)
+		require.Zero(t, rotation.saves["short"])
+		require.Empty(t, rotation.cursors["short"])
+	})
+}
@@ -1,51 +1,220 @@
-package usecase
-
-import (
-	"context"
-
-	"github.com/vandermeer0/pr-reviewer/internal/entity"
-)
-
-// CreateTeamMemberInput - данные для создания команды
-type CreateTeamMemberInput struct {
-	UserID   string
-	Username string
-	IsActive bool
-}
-
-// PullRequestCreateInput - данные для создания PR
-type PullRequestCreateInput struct {
-	ID       string
-	Name     string
-	AuthorID string
-}
-
-// TeamService описывает операции с командами
-type TeamService interface {
-	// CreateTeam создаёт команду и юзеров
-	CreateTeam(ctx context.Context, teamName string, members []CreateTeamMemberInput) (*entity.Team, error)
-
-	// GetTeam возвращает команду по имени или NOT_FOUND
-	GetTeam(ctx context.Context, teamName string) (*entity.Team, error)
-}
-
-// UserService описывает операции с юзерами
-type UserService interface {
-	// SetIsActive меняет флаг активности юзера
-	SetIsActive(ctx context.Context, userID string, isActive bool) (*entity.User, error)
-}
-
-// PullRequestService описывает операции с PR
-type PullRequestService interface {
-	// Create создаёт PR и назначает до двух ревьюверов
-	Create(ctx context.Context, input PullRequestCreateInput) (*entity.PullRequest, error)
-
-	// Merge идемпотентно помечает PR как MERGED
-	Merge(ctx context.Context, prID string) (*entity.PullRequest, error)
-
-	// ReassignReviewer заменяет ревьювера на другого из его команды
-	ReassignReviewer(ctx context.Context, prID string, oldReviewerID string) (*entity.PullRequest, string, error)
-
-	// GetByReviewer возвращает PRы где пользователь ревьювер
-	GetByReviewer(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error)
-}
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+// CreateTeamMemberInput - данные для создания команды
+type CreateTeamMemberInput struct {
+	UserID    string
+	Username  string
+	IsActive  bool
+	Seniority entity.Seniority
+	IsTrainee bool
+}
+
+// TeamSettingsInput - данные для обновления настроек команды,
+// nil-поля оставляют сохранённое значение без изменений
+type TeamSettingsInput struct {
+	TeamName       string
+	MinReviewers   *int
+	MaxReviewers   *int
+	MaxOpenReviews *int
+	// ClearMaxOpenReviews снимает лимит открытых ревью команды
+	ClearMaxOpenReviews bool
+	// MinSeniorReviewers, MinJuniorReviewers - состав ревьюверов по уровню опыта
+	MinSeniorReviewers *int
+	MinJuniorReviewers *int
+	FallbackTeams      *[]string
+}
+
+// OutOfOfficeInput - данные периода отсутствия пользователя
+type OutOfOfficeInput struct {
+	UserID   string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Reason   string
+}
+
+// UserScheduleInput - часовой пояс и рабочие часы пользователя
+type UserScheduleInput struct {
+	UserID   string
+	Timezone string
+	// Schedule - рабочие часы, nil - пользователь доступен в любое время
+	Schedule *entity.WorkSchedule
+}
+
+// OwnershipRuleInput - glob путей и его владелец: пользователь или команда
+type OwnershipRuleInput struct {
+	Pattern  string
+	UserID   string
+	TeamName string
+}
+
+// ReviewConflictInput - пара пользователей, которые не должны ревьюить друг друга
+type ReviewConflictInput struct {
+	UserID      string
+	OtherUserID string
+	Reason      string
+}
+
+// PullRequestCreateInput - данные для создания PR
+type PullRequestCreateInput struct {
+	ID       string
+	Name     string
+	AuthorID string
+	// ChangedFiles - изменённые пути, по ним подбирается владелец кода
+	ChangedFiles []string
+	// RequestedReviewers - кого автор просит назначить, назначаются первыми
+	RequestedReviewers []string
+	// Labels - метки PR, по ним срабатывают правила команды
+	Labels []string
+	// Draft - создать черновик без подбора ревьюверов
+	Draft bool
+}
+
+// PullRequestReadyInput - атрибуты черновика, по которым подбираются ревьюверы
+// Пустое поле - используется сохранённое при создании черновика
+type PullRequestReadyInput struct {
+	ID                 string
+	ChangedFiles       []string
+	RequestedReviewers []string
+	Labels             []string
+}
+
+// TeamService описывает операции с командами
+type TeamService interface {
+	// CreateTeam создаёт команду и юзеров
+	CreateTeam(ctx context.Context, teamName string, members []CreateTeamMemberInput) (*entity.Team, error)
+
+	// GetTeam возвращает команду по имени или NOT_FOUND
+	GetTeam(ctx context.Context, teamName string) (*entity.Team, error)
+
+	// GetSettings возвращает настройки команды, для команды без настроек - значения по умолчанию
+	GetSettings(ctx context.Context, teamName string) (*entity.TeamSettings, error)
+
+	// UpdateSettings обновляет переданные поля настроек команды, остальные остаются прежними
+	UpdateSettings(ctx context.Context, input TeamSettingsInput) (*entity.TeamSettings, error)
+}
+
+// UserService описывает операции с юзерами
+type UserService interface {
+	// SetIsActive меняет флаг активности юзера
+	SetIsActive(ctx context.Context, userID string, isActive bool) (*entity.User, error)
+
+	// SetMaxOpenReviews задаёт личный лимит открытых ревью, nil - лимит команды
+	SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*entity.User, error)
+
+	// SetReviewWeight задаёт вес пользователя для стратегии weighted_random
+	SetReviewWeight(ctx context.Context, userID string, weight float64) (*entity.User, error)
+
+	// SetTrainee отмечает пользователя стажёром, которого назначают теневым ревьювером
+	SetTrainee(ctx context.Context, userID string, isTrainee bool) (*entity.User, error)
+
+	// SetSeniority задаёт уровень опыта пользователя
+	SetSeniority(ctx context.Context, userID string, seniority entity.Seniority) (*entity.User, error)
+
+	// SetSchedule задаёт часовой пояс и рабочие часы пользователя
+	SetSchedule(ctx context.Context, input UserScheduleInput) (*entity.User, error)
+
+	// AddOutOfOffice добавляет период отсутствия, на это время пользователь не назначается ревьювером
+	AddOutOfOffice(ctx context.Context, input OutOfOfficeInput) (*entity.OutOfOffice, error)
+
+	// ListOutOfOffice возвращает периоды отсутствия пользователя
+	ListOutOfOffice(ctx context.Context, userID string) ([]*entity.OutOfOffice, error)
+
+	// DeleteOutOfOffice удаляет период отсутствия
+	DeleteOutOfOffice(ctx context.Context, userID string, id int64) error
+
+	// CurrentOutOfOffice возвращает действующий сейчас период отсутствия или nil
+	CurrentOutOfOffice(ctx context.Context, userID string) (*entity.OutOfOffice, error)
+}
+
+// PullRequestService описывает операции с PR
+type PullRequestService interface {
+	// Create создаёт PR и назначает ревьюверов по настройкам команды автора,
+	// черновик создаётся без ревьюверов
+	Create(ctx context.Context, input PullRequestCreateInput) (*entity.PullRequest, error)
+
+	// ReadyForReview идемпотентно переводит черновик в OPEN и назначает ревьюверов так же как Create
+	ReadyForReview(ctx context.Context, input PullRequestReadyInput) (*entity.PullRequest, error)
+
+	// Merge идемпотентно помечает PR как MERGED, закрытый PR сначала нужно переоткрыть,
+	// черновик - отметить готовым к ревью
+	Merge(ctx context.Context, prID string) (*entity.PullRequest, error)
+
+	// Close идемпотентно закрывает открытый PR или черновик без слияния, ревьюверы сохраняются
+	Close(ctx context.Context, prID string) (*entity.PullRequest, error)
+
+	// Reopen идемпотентно возвращает закрытый PR в OPEN с прежними ревьюверами, кроме деактивированных
+	// и отсутствующих за время закрытия, освободившиеся места добираются из команды автора
+	// Закрытый черновик возвращается в DRAFT
+	Reopen(ctx context.Context, prID string) (*entity.PullRequest, error)
+
+	// ReassignReviewer заменяет ревьювера открытого PR на другого из его команды,
+	// если состав команды автора требует ревьюверов его уровня - на ревьювера того же уровня
+	ReassignReviewer(ctx context.Context, prID string, oldReviewerID string) (*entity.PullRequest, string, error)
+
+	// AddReviewer вручную добавляет ревьювера в открытый PR сверх уже назначенных
+	AddReviewer(ctx context.Context, prID string, userID string) (*entity.PullRequest, error)
+
+	// RemoveReviewer снимает ревьювера с открытого PR без замены
+	RemoveReviewer(ctx context.Context, prID string, userID string) (*entity.PullRequest, error)
+
+	// GetAssignmentTraces возвращает трассировки назначений PR в порядке создания
+	GetAssignmentTraces(ctx context.Context, prID string) ([]*entity.AssignmentTrace, error)
+
+	// GetByReviewer возвращает PRы где пользователь ревьювер
+	GetByReviewer(ctx context.Context, reviewerID string) ([]*entity.PullRequest, error)
+}
+
+// OwnershipService описывает операции с правилами владения путями
+type OwnershipService interface {
+	// AddRule регистрирует glob путей за пользователем или командой
+	AddRule(ctx context.Context, input OwnershipRuleInput) (*entity.OwnershipRule, error)
+
+	// ListRules возвращает все правила владения
+	ListRules(ctx context.Context) ([]*entity.OwnershipRule, error)
+
+	// DeleteRule удаляет правило владения
+	DeleteRule(ctx context.Context, id int64) error
+}
+
+// ConflictService описывает ведение списка конфликтов интересов
+type ConflictService interface {
+	// AddConflict запрещает пользователям ревьюить PR друг друга
+	AddConflict(ctx context.Context, input ReviewConflictInput) (*entity.ReviewConflict, error)
+
+	// ListConflicts возвращает все пары
+	ListConflicts(ctx context.Context) ([]*entity.ReviewConflict, error)
+
+	// DeleteConflict снимает запрет
+	DeleteConflict(ctx context.Context, id int64) error
+}
+
+// CodeOwnersService описывает импорт файла CODEOWNERS
+type CodeOwnersService interface {
+	// Import разбирает файл и заменяет им ранее импортированные правила
+	Import(ctx context.Context, content string) (*CodeOwnersImportResult, error)
+
+	// List возвращает импортированные правила
+	List(ctx context.Context) ([]*entity.CodeOwnersRule, error)
+}
+
+// ReviewRulesService описывает правила команд, меняющие подбор ревьюверов по атрибутам PR
+type ReviewRulesService interface {
+	// GetRules возвращает правила команды, если их не задавали - пустые
+	GetRules(ctx context.Context, teamName string) (*entity.TeamReviewRules, error)
+
+	// SaveRules проверяет YAML и заменяет им правила команды
+	SaveRules(ctx context.Context, teamName string, content string) (*entity.TeamReviewRules, error)
+
+	// Validate проверяет YAML без сохранения и возвращает все найденные ошибки
+	Validate(ctx context.Context, content string) (*ReviewRulesValidation, error)
+
+	// Test вычисляет, как правила изменят подбор ревьюверов для гипотетического PR
+	Test(ctx context.Context, input ReviewRulesTestInput) (*ReviewRulesTestResult, error)
+}
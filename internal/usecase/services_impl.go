@@ -3,7 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"math"
 	"time"
 
 	"github.com/vandermeer0/pr-reviewer/internal/entity"
@@ -35,11 +35,16 @@ func (s *teamService) CreateTeam(
 
 	users := make([]*entity.User, 0, len(members))
 	for _, m := range members {
+		if err := m.Seniority.Validate(); err != nil {
+			return nil, NewInvalidInputError(err.Error())
+		}
 		u := &entity.User{
-			ID:       m.UserID,
-			Username: m.Username,
-			TeamName: teamName,
-			IsActive: m.IsActive,
+			ID:        m.UserID,
+			Username:  m.Username,
+			TeamName:  teamName,
+			IsActive:  m.IsActive,
+			Seniority: m.Seniority,
+			IsTrainee: m.IsTrainee,
 		}
 		if err := u.Validate(); err != nil {
 			return nil, err
@@ -73,14 +78,98 @@ func (s *teamService) GetTeam(ctx context.Context, teamName string) (*entity.Tea
 	return team, nil
 }
 
+func (s *teamService) GetSettings(ctx context.Context, teamName string) (*entity.TeamSettings, error) {
+	if _, err := s.teamRepo.GetByName(ctx, teamName); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("team not found")
+		}
+		return nil, err
+	}
+	return teamSettingsOrDefault(ctx, s.teamRepo, teamName)
+}
+
+func (s *teamService) UpdateSettings(
+	ctx context.Context,
+	input TeamSettingsInput,
+) (*entity.TeamSettings, error) {
+	if _, err := s.teamRepo.GetByName(ctx, input.TeamName); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("team not found")
+		}
+		return nil, err
+	}
+
+	settings, err := teamSettingsOrDefault(ctx, s.teamRepo, input.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if input.MinReviewers != nil {
+		settings.MinReviewers = *input.MinReviewers
+	}
+	if input.MaxReviewers != nil {
+		settings.MaxReviewers = *input.MaxReviewers
+	}
+	if input.ClearMaxOpenReviews {
+		settings.MaxOpenReviews = nil
+	} else if input.MaxOpenReviews != nil {
+		limit := *input.MaxOpenReviews
+		settings.MaxOpenReviews = &limit
+	}
+	if input.MinSeniorReviewers != nil {
+		settings.MinSeniorReviewers = *input.MinSeniorReviewers
+	}
+	if input.MinJuniorReviewers != nil {
+		settings.MinJuniorReviewers = *input.MinJuniorReviewers
+	}
+	if input.FallbackTeams != nil {
+		settings.FallbackTeams = *input.FallbackTeams
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	for _, name := range settings.FallbackTeams {
+		if _, err := s.teamRepo.GetByName(ctx, name); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, NewNotFoundError("fallback team " + name + " not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.teamRepo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// teamSettingsOrDefault возвращает сохранённые настройки команды или настройки по умолчанию
+func teamSettingsOrDefault(
+	ctx context.Context,
+	teamRepo repo.TeamRepository,
+	teamName string,
+) (*entity.TeamSettings, error) {
+	settings, err := teamRepo.GetSettings(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return entity.DefaultTeamSettings(teamName), nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
 type userService struct {
 	userRepo repo.UserRepository
+	assigner *reviewerAssigner
 }
 
-// NewUserService создаёт реализацию UserService
-func NewUserService(userRepo repo.UserRepository) UserService {
+// NewUserService создаёт реализацию UserService, часы берутся из опций подбора,
+// чтобы отсутствие оценивалось на тот же момент что и при назначении
+func NewUserService(userRepo repo.UserRepository, opts ...AssignmentOption) UserService {
 	return &userService{
 		userRepo: userRepo,
+		assigner: newReviewerAssigner(opts),
 	}
 }
 
@@ -104,11 +193,212 @@ func (s *userService) SetIsActive(
 	return u, nil
 }
 
+func (s *userService) SetMaxOpenReviews(
+	ctx context.Context,
+	userID string,
+	limit *int,
+) (*entity.User, error) {
+	if limit != nil && *limit < 0 {
+		return nil, NewInvalidInputError("max_open_reviews must not be negative")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	u.MaxOpenReviews = limit
+	if err := s.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) SetReviewWeight(
+	ctx context.Context,
+	userID string,
+	weight float64,
+) (*entity.User, error) {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		return nil, NewInvalidInputError("review_weight must be a positive number")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	u.ReviewWeight = weight
+	if err := s.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) SetTrainee(
+	ctx context.Context,
+	userID string,
+	isTrainee bool,
+) (*entity.User, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	u.IsTrainee = isTrainee
+	if err := s.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) SetSeniority(
+	ctx context.Context,
+	userID string,
+	seniority entity.Seniority,
+) (*entity.User, error) {
+	if err := seniority.Validate(); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	u.Seniority = seniority
+	if err := s.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) SetSchedule(
+	ctx context.Context,
+	input UserScheduleInput,
+) (*entity.User, error) {
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = entity.DefaultTimezone
+	}
+	if err := entity.ValidateTimezone(timezone); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+	if input.Schedule != nil {
+		if err := input.Schedule.Validate(); err != nil {
+			return nil, NewInvalidInputError(err.Error())
+		}
+	}
+
+	u, err := s.userRepo.GetByID(ctx, input.UserID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	u.Timezone = timezone
+	u.Schedule = input.Schedule
+	if err := s.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) AddOutOfOffice(
+	ctx context.Context,
+	input OutOfOfficeInput,
+) (*entity.OutOfOffice, error) {
+	if _, err := s.userRepo.GetByID(ctx, input.UserID); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	period := &entity.OutOfOffice{
+		UserID:   input.UserID,
+		StartsAt: input.StartsAt.UTC(),
+		EndsAt:   input.EndsAt.UTC(),
+		Reason:   input.Reason,
+	}
+	if err := period.Validate(); err != nil {
+		return nil, NewInvalidInputError(err.Error())
+	}
+
+	if err := s.userRepo.AddOutOfOffice(ctx, period); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+func (s *userService) ListOutOfOffice(
+	ctx context.Context,
+	userID string,
+) ([]*entity.OutOfOffice, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+
+	periods, err := s.userRepo.ListOutOfOffice(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if periods == nil {
+		periods = []*entity.OutOfOffice{}
+	}
+	return periods, nil
+}
+
+func (s *userService) DeleteOutOfOffice(ctx context.Context, userID string, id int64) error {
+	if err := s.userRepo.DeleteOutOfOffice(ctx, userID, id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return NewNotFoundError("out of office period not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *userService) CurrentOutOfOffice(
+	ctx context.Context,
+	userID string,
+) (*entity.OutOfOffice, error) {
+	periods, err := s.userRepo.ListOutOfOffice(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.assigner.clock.Now()
+	for _, p := range periods {
+		if p.Covers(now) {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
 type pullRequestService struct {
 	prRepo   repo.PullRequestRepository
 	userRepo repo.UserRepository
 	teamRepo repo.TeamRepository
-	rng      *rand.Rand
+	assigner *reviewerAssigner
 }
 
 // NewPullRequestService создаёт реализацию PullRequestService
@@ -116,12 +406,13 @@ func NewPullRequestService(
 	prRepo repo.PullRequestRepository,
 	userRepo repo.UserRepository,
 	teamRepo repo.TeamRepository,
+	opts ...AssignmentOption,
 ) PullRequestService {
 	return &pullRequestService{
 		prRepo:   prRepo,
 		userRepo: userRepo,
 		teamRepo: teamRepo,
-		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		assigner: newReviewerAssigner(opts),
 	}
 }
 
@@ -137,63 +428,60 @@ func (s *pullRequestService) Create(
 		return nil, err
 	}
 
-	team, err := s.teamRepo.GetByName(ctx, author.TeamName)
-	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, NewNotFoundError("author team not found")
-		}
-		return nil, err
+	pr := &entity.PullRequest{
+		ID:        input.ID,
+		Name:      input.Name,
+		AuthorID:  input.AuthorID,
+		Status:    entity.StatusOpen,
+		CreatedAt: s.assigner.clock.Now(),
 	}
 
-	candidates := make([]string, 0, len(team.Members))
-	for _, m := range team.Members {
-		if m == nil {
-			continue
-		}
-		if !m.IsActive {
-			continue
+	// черновик сохраняется без ревьюверов, подбор - в ReadyForReview по сохранённым атрибутам
+	var steps []entity.AssignmentTraceStep
+	cursors := make(map[string]string)
+	if input.Draft {
+		pr.Status = entity.StatusDraft
+		pr.Draft = entity.DraftAttributes{
+			ChangedFiles:       input.ChangedFiles,
+			RequestedReviewers: input.RequestedReviewers,
+			Labels:             input.Labels,
 		}
-		if m.ID == author.ID {
-			continue
+	} else {
+		steps, err = s.selectReviewers(ctx, pr, author, input, cursors)
+		if err != nil {
+			return nil, err
 		}
-		candidates = append(candidates, m.ID)
 	}
 
-	if len(candidates) > 1 {
-		s.rng.Shuffle(len(candidates), func(i, j int) {
-			candidates[i], candidates[j] = candidates[j], candidates[i]
+	// PR и трассировка пишутся вместе: без этого повтор запроса после сбоя
+	// получил бы PR_EXISTS, а у PR не было бы объяснения назначения
+	if pr.IsDraft() {
+		err = s.prRepo.Save(ctx, pr)
+	} else {
+		err = s.prRepo.SaveWithTrace(ctx, pr, &entity.AssignmentTrace{
+			PullRequestID: pr.ID,
+			Action:        entity.TraceActionCreate,
+			Reviewers:     pr.Reviewers,
+			Steps:         steps,
+			CreatedAt:     pr.CreatedAt,
 		})
 	}
-
-	reviewers := candidates
-	if len(reviewers) > 2 {
-		reviewers = reviewers[:2]
-	}
-
-	pr := &entity.PullRequest{
-		ID:        input.ID,
-		Name:      input.Name,
-		AuthorID:  input.AuthorID,
-		Status:    entity.StatusOpen,
-		Reviewers: reviewers,
-		CreatedAt: time.Now().UTC(),
-	}
-
-	if err := s.prRepo.Save(ctx, pr); err != nil {
+	if err != nil {
 		if errors.Is(err, repo.ErrAlreadyExists) {
 			return nil, NewPRExistsError("pull request already exists")
 		}
 		return nil, err
 	}
+	s.assigner.saveCursors(ctx, cursors)
 
 	return pr, nil
 }
 
-func (s *pullRequestService) Merge(
+func (s *pullRequestService) ReadyForReview(
 	ctx context.Context,
-	prID string,
+	input PullRequestReadyInput,
 ) (*entity.PullRequest, error) {
-	pr, err := s.prRepo.GetByID(ctx, prID)
+	pr, err := s.prRepo.GetByID(ctx, input.ID)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, NewNotFoundError("pull request not found")
@@ -201,53 +489,606 @@ func (s *pullRequestService) Merge(
 		return nil, err
 	}
 
-	if !pr.CanBeMerged() {
+	switch pr.Status {
+	case entity.StatusOpen:
 		return pr, nil
+	case entity.StatusMerged:
+		return nil, NewPRMergedError("pull request already merged")
+	case entity.StatusClosed:
+		return nil, NewPRClosedError("pull request is closed")
 	}
 
-	now := time.Now().UTC()
-	pr.Status = entity.StatusMerged
-	pr.MergedAt = &now
+	author, err := s.userRepo.GetByID(ctx, pr.AuthorID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("author not found")
+		}
+		return nil, err
+	}
 
-	if err := s.prRepo.Update(ctx, pr); err != nil {
+	// непереданные атрибуты берутся из сохранённых при создании черновика
+	cursors := make(map[string]string)
+	steps, err := s.selectReviewers(ctx, pr, author, PullRequestCreateInput{
+		ID:                 pr.ID,
+		Name:               pr.Name,
+		AuthorID:           pr.AuthorID,
+		ChangedFiles:       orStored(input.ChangedFiles, pr.Draft.ChangedFiles),
+		RequestedReviewers: orStored(input.RequestedReviewers, pr.Draft.RequestedReviewers),
+		Labels:             orStored(input.Labels, pr.Draft.Labels),
+	}, cursors)
+	if err != nil {
+		return nil, err
+	}
+	pr.Status = entity.StatusOpen
+	pr.Draft = entity.DraftAttributes{}
+
+	// статус и трассировка пишутся вместе, иначе PR стал бы открытым без объяснения назначения
+	if err := s.prRepo.UpdateWithTrace(ctx, pr, &entity.AssignmentTrace{
+		PullRequestID: pr.ID,
+		Action:        entity.TraceActionReady,
+		Reviewers:     pr.Reviewers,
+		Steps:         steps,
+		CreatedAt:     s.assigner.clock.Now(),
+	}); err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, NewNotFoundError("pull request not found")
 		}
 		return nil, err
 	}
+	s.assigner.saveCursors(ctx, cursors)
 
 	return pr, nil
 }
 
-func (s *pullRequestService) ReassignReviewer(
+// orStored возвращает given, а если он пуст - сохранённое значение черновика
+func orStored(given, stored []string) []string {
+	if len(given) > 0 {
+		return given
+	}
+	return stored
+}
+
+// selectReviewers подбирает ревьюверов нового PR по настройкам и правилам команды автора
+// и записывает их в pr, возвращает шаги подбора для трассировки
+// Курсоры round_robin подбора из команды автора и запасных команд попадают в cursors,
+// вызывающий сохраняет их после записи PR
+func (s *pullRequestService) selectReviewers(
 	ctx context.Context,
-	prID string,
-	oldReviewerID string,
-) (*entity.PullRequest, string, error) {
-	pr, err := s.prRepo.GetByID(ctx, prID)
+	pr *entity.PullRequest,
+	author *entity.User,
+	input PullRequestCreateInput,
+	cursors map[string]string,
+) ([]entity.AssignmentTraceStep, error) {
+	conflicts, err := s.assigner.conflictsOf(ctx, author.ID)
 	if err != nil {
-		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", NewNotFoundError("pull request not found")
-		}
-		return nil, "", err
+		return nil, err
 	}
 
-	if !pr.CanReassignReviewers() {
-		return nil, "", NewPRMergedError("pull request already merged")
+	requested, err := s.requestedReviewers(ctx, author.ID, conflicts, input.RequestedReviewers)
+	if err != nil {
+		return nil, err
 	}
 
-	index := -1
-	for i, id := range pr.Reviewers {
-		if id == oldReviewerID {
-			index = i
-			break
+	team, err := s.teamRepo.GetByName(ctx, author.TeamName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("author team not found")
 		}
-	}
-	if index == -1 {
-		return nil, "", NewNotAssignedError("reviewer is not assigned to this pull request")
+		return nil, err
 	}
 
-	reviewer, err := s.userRepo.GetByID(ctx, oldReviewerID)
+	settings, err := teamSettingsOrDefault(ctx, s.teamRepo, team.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// правила команды по атрибутам PR меняют число ревьюверов и добавляют ревьюверов из других команд
+	rules, err := reviewRulesOutcome(ctx, s.assigner.reviewRules, team.Name, entity.ReviewRuleSubject{
+		Name:            input.Name,
+		Labels:          input.Labels,
+		AuthorSeniority: author.Seniority,
+		ChangedFiles:    input.ChangedFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	settings = rules.Apply(settings)
+
+	rulePools, err := ruleAdditionPools(ctx, s.teamRepo, rules.Additions)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := codeOwnerGroups(ctx, s.assigner.codeOwners, s.userRepo, s.teamRepo, input.ChangedFiles, author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := pathOwners(ctx, s.assigner.ownership, s.userRepo, s.teamRepo, input.ChangedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbacks, err := fallbackPools(ctx, s.teamRepo, settings.FallbackTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append(append([]*entity.User(nil), team.Members...), owners...)
+	candidates = append(candidates, requested...)
+	candidates = append(candidates, poolMembers(fallbacks)...)
+	candidates = append(candidates, poolMembers(rulePools)...)
+	for _, g := range groups {
+		candidates = append(candidates, g.Owners...)
+	}
+
+	otherLimits, err := otherTeamLimits(ctx, s.teamRepo, team.Name, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := memberIDs(candidates)
+	loads, err := s.prRepo.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	away, err := s.userRepo.GetAwayUserIDs(ctx, ids, s.assigner.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := loadPairHistory(ctx, s.assigner, s.prRepo, author.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	req := assignmentRequest{
+		TeamName:        team.Name,
+		AuthorID:        author.ID,
+		Conflicts:       conflicts,
+		Away:            away,
+		Loads:           loads,
+		TeamReviewLimit: settings.MaxOpenReviews,
+		OtherTeamLimits: otherLimits,
+		PairHistory:     history,
+	}
+
+	// запрошенные автором назначаются первыми, без учёта лимитов и отсутствия
+	var picked assignmentResult
+	if len(requested) > 0 {
+		picked.Reviewers = memberIDs(requested)
+		picked.Trace = append(picked.Trace, requestedTraceStep(team.Name, requested, loads, s.assigner.clock.Now()))
+	}
+
+	pickMore := func(stage, scope string, members []*entity.User, count int, fallbacks []teamPool) (assignmentResult, error) {
+		r := req
+		r.Members = members
+		r.Exclude = idSet(picked.Reviewers)
+		r.Count = count
+		r.Stage = stage
+		r.Scope = scope
+		// очередь round_robin сдвигает только подбор из всей команды, а не из её подмножеств
+		if stage == "team" {
+			r.Cursors = cursors
+		}
+		res, err := s.assigner.pickWithFallbacks(ctx, r, fallbacks)
+		if err != nil {
+			return res, err
+		}
+		picked.merge(res)
+		return res, nil
+	}
+
+	// владелец изменённых путей подбирается до этапов, занимающих места,
+	// чтобы хотя бы один владелец попал на PR даже при заполненном составе
+	if len(owners) > 0 && !containsAnyUser(picked.Reviewers, owners) {
+		if _, err := pickMore("ownership", "", owners, 1, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// обязательные владельцы по CODEOWNERS - по одному на каждое сработавшее правило
+	var required []entity.RequiredReviewer
+	requiredIDs := make(map[string]struct{})
+	for _, g := range groups {
+		// владелец уже назначен: запрошен автором или выбран по другому правилу
+		if owner, ok := firstAssigned(picked.Reviewers, g.Owners); ok {
+			if _, already := requiredIDs[owner]; !already {
+				required = append(required, entity.RequiredReviewer{UserID: owner, Pattern: g.Pattern})
+				requiredIDs[owner] = struct{}{}
+			}
+			continue
+		}
+		res, err := pickMore("code_owners", g.Pattern, g.Owners, 1, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Reviewers) == 0 {
+			return nil, res.shortageError("no available code owner for " + g.Pattern)
+		}
+		required = append(required, entity.RequiredReviewer{UserID: res.Reviewers[0], Pattern: g.Pattern})
+		requiredIDs[res.Reviewers[0]] = struct{}{}
+	}
+
+	// ревьюверы из других команд по правилам, уже назначенные участники команды засчитываются
+	// Добавленные правилом занимают не места команды, а свои, и при доборе не восполняются
+	var ruleReviewers []string
+	for i, add := range rules.Additions {
+		pool := rulePools[i]
+		need := add.Count - countAssigned(picked.Reviewers, pool.Members)
+		if need <= 0 {
+			continue
+		}
+		res, err := pickMore("rule", add.Rule, pool.Members, need, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Reviewers) < need {
+			return nil, res.shortageError("not enough reviewers from team " + add.TeamName + " for rule " + add.Rule)
+		}
+		ruleReviewers = append(ruleReviewers, res.Reviewers...)
+	}
+
+	// состав по уровню опыта: недостающих старших и младших добираем из команды автора и запасных
+	seniority := seniorityIndex(candidates)
+	for _, level := range entity.CompositionLevels {
+		need := settings.MinBySeniority(level) - countSeniority(picked.Reviewers, seniority, level)
+		if need <= 0 {
+			continue
+		}
+		res, err := pickMore("seniority", string(level), withSeniority(team.Members, level), need,
+			fallbackPoolsWithSeniority(fallbacks, level))
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Reviewers) < need {
+			return nil, res.shortageError("not enough " + string(level) + " reviewers to meet team composition")
+		}
+	}
+
+	// недостающих добираем из запасных команд по порядку
+	if need := settings.MaxReviewers - (len(picked.Reviewers) - len(ruleReviewers)); need > 0 {
+		if _, err := pickMore("team", "", team.Members, need, fallbacks); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(picked.Reviewers)-len(ruleReviewers) < settings.MinReviewers {
+		return nil, picked.shortageError("not enough reviewers in team to meet min_reviewers")
+	}
+
+	// стажёр команды наблюдает за ревью и в число ревьюверов не входит
+	shadowReq := req
+	shadowReq.Exclude = idSet(picked.Reviewers)
+	shadow, err := s.assigner.pickShadowReviewers(ctx, shadowReq, team.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	// если ревьюверов меньше чем max_reviewers, PR сохраняется с TargetReviewers
+	// и считается недоукомплектованным
+	pr.Reviewers = picked.Reviewers
+	pr.RequiredReviewers = required
+	pr.FallbackReviewers = picked.Fallback
+	pr.RuleReviewers = ruleReviewers
+	pr.ShadowReviewers = shadow.Reviewers
+	pr.TargetReviewers = settings.MaxReviewers
+
+	return append(picked.Trace, shadow.Trace...), nil
+}
+
+func (s *pullRequestService) Merge(
+	ctx context.Context,
+	prID string,
+) (*entity.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	switch pr.Status {
+	case entity.StatusClosed:
+		return nil, NewPRClosedError("pull request is closed")
+	case entity.StatusDraft:
+		return nil, NewPRDraftError("draft must be marked ready for review before merge")
+	}
+	if !pr.CanBeMerged() {
+		return pr, nil
+	}
+
+	now := s.assigner.clock.Now()
+	pr.Status = entity.StatusMerged
+	pr.MergedAt = &now
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (s *pullRequestService) Close(
+	ctx context.Context,
+	prID string,
+) (*entity.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	switch pr.Status {
+	case entity.StatusClosed:
+		return pr, nil
+	case entity.StatusMerged:
+		return nil, NewPRMergedError("pull request already merged")
+	}
+
+	// черновик закрывается как есть, ревьюверов у него нет
+	now := s.assigner.clock.Now()
+	pr.Status = entity.StatusClosed
+	pr.ClosedAt = &now
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (s *pullRequestService) Reopen(
+	ctx context.Context,
+	prID string,
+) (*entity.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	if pr.Status == entity.StatusMerged {
+		return nil, NewPRMergedError("merged pull request cannot be reopened")
+	}
+	if !pr.CanBeReopened() {
+		return pr, nil
+	}
+
+	// закрытый черновик возвращается черновиком, ревьюверы подберутся при readyForReview
+	if pr.IsClosedDraft() {
+		pr.Status = entity.StatusDraft
+		pr.ClosedAt = nil
+		if err := s.prRepo.Update(ctx, pr); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, NewNotFoundError("pull request not found")
+			}
+			return nil, err
+		}
+		return pr, nil
+	}
+
+	pr.Status = entity.StatusOpen
+	pr.ClosedAt = nil
+
+	cursors := make(map[string]string)
+	trace, err := s.refreshReviewers(ctx, pr, cursors)
+	if err != nil {
+		return nil, err
+	}
+
+	if trace != nil {
+		err = s.prRepo.UpdateWithTrace(ctx, pr, trace)
+	} else {
+		err = s.prRepo.Update(ctx, pr)
+	}
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+	s.assigner.saveCursors(ctx, cursors)
+
+	return pr, nil
+}
+
+// refreshReviewers снимает с переоткрываемого PR ревьюверов, которых пока он был закрыт
+// деактивировали или отправили в отпуск, и добирает освободившиеся места из команды автора
+// Возвращает трассировку добора или nil, если никто не добавлен, курсоры round_robin - в cursors
+func (s *pullRequestService) refreshReviewers(
+	ctx context.Context,
+	pr *entity.PullRequest,
+	cursors map[string]string,
+) (*entity.AssignmentTrace, error) {
+	assigned := append(append([]string(nil), pr.Reviewers...), pr.ShadowReviewers...)
+	away, err := s.userRepo.GetAwayUserIDs(ctx, assigned, s.assigner.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	gone := make(map[string]struct{})
+	for _, id := range assigned {
+		if _, isAway := away[id]; isAway {
+			gone[id] = struct{}{}
+			continue
+		}
+		u, err := s.userRepo.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return nil, err
+		}
+		if err != nil || !u.IsActive {
+			gone[id] = struct{}{}
+		}
+	}
+	if len(gone) == 0 {
+		return nil, nil
+	}
+
+	pr.Reviewers = withoutIDs(pr.Reviewers, gone)
+	pr.ShadowReviewers = withoutIDs(pr.ShadowReviewers, gone)
+	pr.FallbackReviewers = withoutIDs(pr.FallbackReviewers, gone)
+	pr.RuleReviewers = withoutIDs(pr.RuleReviewers, gone)
+	required := pr.RequiredReviewers[:0]
+	for _, r := range pr.RequiredReviewers {
+		if _, ok := gone[r.UserID]; !ok {
+			required = append(required, r)
+		}
+	}
+	pr.RequiredReviewers = required
+
+	// места добавленных правилами из команды автора не восполняются
+	need := pr.TargetReviewers - pr.TeamReviewerCount()
+	if need <= 0 {
+		return nil, nil
+	}
+
+	author, err := s.userRepo.GetByID(ctx, pr.AuthorID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("author not found")
+		}
+		return nil, err
+	}
+
+	team, err := s.teamRepo.GetByName(ctx, author.TeamName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("author team not found")
+		}
+		return nil, err
+	}
+
+	settings, err := teamSettingsOrDefault(ctx, s.teamRepo, team.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbacks, err := fallbackPools(ctx, s.teamRepo, settings.FallbackTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := append(append([]*entity.User(nil), team.Members...), poolMembers(fallbacks)...)
+	otherLimits, err := otherTeamLimits(ctx, s.teamRepo, team.Name, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := memberIDs(pool)
+	loads, err := s.prRepo.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	poolAway, err := s.userRepo.GetAwayUserIDs(ctx, ids, s.assigner.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := loadPairHistory(ctx, s.assigner, s.prRepo, pr.AuthorID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := s.assigner.conflictsOf(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// снятые тоже исключаются: неактивных и так не выберут, а отсутствующих не вернём обратно
+	exclude := idSet(pr.Reviewers)
+	for id := range gone {
+		exclude[id] = struct{}{}
+	}
+
+	// нехватка кандидатов не мешает переоткрытию, PR остаётся недоукомплектованным
+	picked, err := s.assigner.pickWithFallbacks(ctx, assignmentRequest{
+		TeamName:        team.Name,
+		AuthorID:        pr.AuthorID,
+		Members:         team.Members,
+		Exclude:         exclude,
+		Conflicts:       conflicts,
+		Away:            poolAway,
+		Loads:           loads,
+		TeamReviewLimit: settings.MaxOpenReviews,
+		OtherTeamLimits: otherLimits,
+		PairHistory:     history,
+		Count:           need,
+		Stage:           "top_up",
+		Cursors:         cursors,
+	}, fallbacks)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked.Reviewers) == 0 {
+		return nil, nil
+	}
+
+	pr.Reviewers = append(pr.Reviewers, picked.Reviewers...)
+	pr.FallbackReviewers = append(pr.FallbackReviewers, picked.Fallback...)
+
+	return &entity.AssignmentTrace{
+		PullRequestID: pr.ID,
+		Action:        entity.TraceActionTopUp,
+		Reviewers:     picked.Reviewers,
+		Steps:         picked.Trace,
+		CreatedAt:     s.assigner.clock.Now(),
+	}, nil
+}
+
+// notOpenError объясняет почему ревьюверов PR менять нельзя
+func notOpenError(pr *entity.PullRequest) *DomainError {
+	switch pr.Status {
+	case entity.StatusClosed:
+		return NewPRClosedError("pull request is closed")
+	case entity.StatusDraft:
+		return NewPRDraftError("reviewers of a draft are assigned when it is ready for review")
+	}
+	return NewPRMergedError("pull request already merged")
+}
+
+func (s *pullRequestService) ReassignReviewer(
+	ctx context.Context,
+	prID string,
+	oldReviewerID string,
+) (*entity.PullRequest, string, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, "", NewNotFoundError("pull request not found")
+		}
+		return nil, "", err
+	}
+
+	if !pr.CanReassignReviewers() {
+		return nil, "", notOpenError(pr)
+	}
+
+	index := -1
+	for i, id := range pr.Reviewers {
+		if id == oldReviewerID {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, "", NewNotAssignedError("reviewer is not assigned to this pull request")
+	}
+
+	reviewer, err := s.userRepo.GetByID(ctx, oldReviewerID)
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			return nil, "", NewNotFoundError("reviewer not found")
@@ -268,42 +1109,461 @@ func (s *pullRequestService) ReassignReviewer(
 		current[id] = struct{}{}
 	}
 
-	candidates := make([]*entity.User, 0, len(team.Members))
-	for _, m := range team.Members {
-		if m == nil {
-			continue
+	settings, err := teamSettingsOrDefault(ctx, s.teamRepo, team.Name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fallbacks, err := fallbackPools(ctx, s.teamRepo, settings.FallbackTeams)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// обязательного владельца заменяет только другой владелец того же правила,
+	// если правило убрали из CODEOWNERS - ревьювер заменяется как обычный
+	candidates := team.Members
+	requiredPattern, isRequired := pr.RequiredPattern(oldReviewerID)
+	traceScope := ""
+	if isRequired {
+		rule, err := codeOwnerRuleByPattern(ctx, s.assigner.codeOwners, requiredPattern)
+		if err != nil {
+			return nil, "", err
+		}
+		if rule != nil {
+			candidates, err = codeOwnersOf(ctx, s.userRepo, s.teamRepo, rule)
+			if err != nil {
+				return nil, "", err
+			}
+			fallbacks = nil
+			traceScope = requiredPattern
+		} else {
+			isRequired = false
+		}
+	}
+
+	// если команда автора требует ревьюверов уровня заменяемого, замена того же уровня
+	sameLevel, err := s.requiresSameSeniority(ctx, pr.AuthorID, reviewer.Seniority)
+	if err != nil {
+		return nil, "", err
+	}
+	if sameLevel {
+		candidates = withSeniority(candidates, reviewer.Seniority)
+		fallbacks = fallbackPoolsWithSeniority(fallbacks, reviewer.Seniority)
+	}
+
+	pool := append(append([]*entity.User(nil), candidates...), poolMembers(fallbacks)...)
+	otherLimits, err := otherTeamLimits(ctx, s.teamRepo, team.Name, pool)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := memberIDs(pool)
+	loads, err := s.prRepo.CountOpenReviews(ctx, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	away, err := s.userRepo.GetAwayUserIDs(ctx, ids, s.assigner.clock.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	history, err := loadPairHistory(ctx, s.assigner, s.prRepo, pr.AuthorID, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conflicts, err := s.assigner.conflictsOf(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// очередь round_robin сдвигает только замена из всей команды, а не из владельцев или одного уровня
+	var cursors map[string]string
+	if !isRequired && !sameLevel {
+		cursors = make(map[string]string)
+	}
+
+	picked, err := s.assigner.pickWithFallbacks(ctx, assignmentRequest{
+		TeamName:        team.Name,
+		AuthorID:        pr.AuthorID,
+		Members:         candidates,
+		Exclude:         current,
+		Conflicts:       conflicts,
+		Away:            away,
+		Loads:           loads,
+		TeamReviewLimit: settings.MaxOpenReviews,
+		OtherTeamLimits: otherLimits,
+		PairHistory:     history,
+		Count:           1,
+		Stage:           "reassign",
+		Scope:           traceScope,
+		Cursors:         cursors,
+	}, fallbacks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(picked.Reviewers) == 0 {
+		if isRequired {
+			return nil, "", picked.shortageError("no available code owner of " + requiredPattern + " to replace required reviewer")
+		}
+		if sameLevel {
+			return nil, "", picked.shortageError("no available " + string(reviewer.Seniority) + " replacement to keep team composition")
+		}
+		return nil, "", picked.shortageError("no active replacement candidate in team")
+	}
+
+	newReviewerID := picked.Reviewers[0]
+	pr.Reviewers[index] = newReviewerID
+
+	required := pr.RequiredReviewers[:0]
+	for _, r := range pr.RequiredReviewers {
+		if r.UserID == oldReviewerID {
+			if !isRequired {
+				continue
+			}
+			r.UserID = newReviewerID
+		}
+		required = append(required, r)
+	}
+	pr.RequiredReviewers = required
+
+	// замена ревьювера из запасной команды тоже считается запасной
+	wasFallback := pr.IsFallbackReviewer(oldReviewerID)
+	fallback := pr.FallbackReviewers[:0]
+	for _, id := range pr.FallbackReviewers {
+		if id != oldReviewerID {
+			fallback = append(fallback, id)
+		}
+	}
+	if len(picked.Fallback) > 0 || wasFallback {
+		fallback = append(fallback, newReviewerID)
+	}
+	pr.FallbackReviewers = fallback
+
+	// замена добавленного правилом идёт из его же команды и занимает место правила
+	for i, id := range pr.RuleReviewers {
+		if id == oldReviewerID {
+			pr.RuleReviewers[i] = newReviewerID
+		}
+	}
+
+	if err := s.prRepo.UpdateWithTrace(ctx, pr, &entity.AssignmentTrace{
+		PullRequestID:      pr.ID,
+		Action:             entity.TraceActionReassign,
+		ReplacedReviewerID: oldReviewerID,
+		Reviewers:          []string{newReviewerID},
+		Steps:              picked.Trace,
+		CreatedAt:          s.assigner.clock.Now(),
+	}); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, "", NewNotFoundError("pull request not found")
+		}
+		return nil, "", err
+	}
+	s.assigner.saveCursors(ctx, cursors)
+
+	return pr, newReviewerID, nil
+}
+
+func (s *pullRequestService) AddReviewer(
+	ctx context.Context,
+	prID string,
+	userID string,
+) (*entity.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	if !pr.CanReassignReviewers() {
+		return nil, notOpenError(pr)
+	}
+	if pr.AuthorID == userID {
+		return nil, NewInvalidInputError("author cannot review own pull request")
+	}
+	if pr.HasReviewer(userID) {
+		return nil, NewAlreadyAssignedError("user is already a reviewer of this pull request")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("user not found")
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, NewUserInactiveError("user is not active")
+	}
+
+	conflicts, err := s.assigner.conflictsOf(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if _, conflict := conflicts[user.ID]; conflict {
+		return nil, NewConflictOfInterestError("user has a conflict of interest with the pull request author")
+	}
+
+	// теневой ревьювер, добавленный вручную, становится обычным
+	withoutShadowReviewer(pr, user.ID)
+	pr.Reviewers = append(pr.Reviewers, user.ID)
+	if err := s.prRepo.UpdateWithTrace(ctx, pr, &entity.AssignmentTrace{
+		PullRequestID: pr.ID,
+		Action:        entity.TraceActionAdd,
+		Reviewers:     []string{user.ID},
+		CreatedAt:     s.assigner.clock.Now(),
+	}); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (s *pullRequestService) RemoveReviewer(
+	ctx context.Context,
+	prID string,
+	userID string,
+) (*entity.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	if !pr.CanReassignReviewers() {
+		return nil, notOpenError(pr)
+	}
+	if !pr.HasReviewer(userID) {
+		return nil, NewNotAssignedError("reviewer is not assigned to this pull request")
+	}
+
+	// обязательного владельца можно только заменить, пока правило есть в CODEOWNERS
+	if pattern, ok := pr.RequiredPattern(userID); ok {
+		rule, err := codeOwnerRuleByPattern(ctx, s.assigner.codeOwners, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			return nil, NewReviewerRequiredError("reviewer is required by CODEOWNERS rule " + pattern + ", use reassign")
+		}
+	}
+
+	remaining := make([]string, 0, len(pr.Reviewers))
+	for _, id := range pr.Reviewers {
+		if id != userID {
+			remaining = append(remaining, id)
+		}
+	}
+
+	if err := s.checkCompositionWithout(ctx, pr.AuthorID, userID, remaining); err != nil {
+		return nil, err
+	}
+
+	pr.Reviewers = remaining
+	required := pr.RequiredReviewers[:0]
+	for _, r := range pr.RequiredReviewers {
+		if r.UserID != userID {
+			required = append(required, r)
+		}
+	}
+	pr.RequiredReviewers = required
+	fallback := pr.FallbackReviewers[:0]
+	for _, id := range pr.FallbackReviewers {
+		if id != userID {
+			fallback = append(fallback, id)
 		}
-		if !m.IsActive {
+	}
+	pr.FallbackReviewers = fallback
+	pr.RuleReviewers = withoutIDs(pr.RuleReviewers, map[string]struct{}{userID: {}})
+
+	if err := s.prRepo.UpdateWithTrace(ctx, pr, &entity.AssignmentTrace{
+		PullRequestID:      pr.ID,
+		Action:             entity.TraceActionRemove,
+		ReplacedReviewerID: userID,
+		CreatedAt:          s.assigner.clock.Now(),
+	}); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// checkCompositionWithout проверяет что без снятого ревьювера состав команды автора
+// по уровню опыта по-прежнему выполняется
+func (s *pullRequestService) checkCompositionWithout(
+	ctx context.Context,
+	authorID string,
+	removedID string,
+	remaining []string,
+) error {
+	removed, err := s.userRepo.GetByID(ctx, removedID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	settings, err := s.authorTeamSettings(ctx, authorID)
+	if err != nil || settings == nil {
+		return err
+	}
+	minLevel := settings.MinBySeniority(removed.Seniority)
+	if removed.Seniority == entity.SeniorityUnset || minLevel == 0 {
+		return nil
+	}
+
+	count := 0
+	for _, id := range remaining {
+		u, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if u.Seniority == removed.Seniority {
+			count++
+		}
+	}
+	if count < minLevel {
+		return NewReviewerRequiredError("team composition requires " + string(removed.Seniority) + " reviewers, use reassign")
+	}
+	return nil
+}
+
+// причины, по которым запрошенного автором ревьювера нельзя назначить
+const (
+	requestedNotFound  = "not_found"
+	requestedDuplicate = "duplicate"
+)
+
+// requestedReviewers проверяет запрошенных автором ревьюверов и возвращает их в порядке запроса
+// Все неподходящие перечисляются в одной ошибке INVALID_REVIEWERS
+func (s *pullRequestService) requestedReviewers(
+	ctx context.Context,
+	authorID string,
+	conflicts map[string]struct{},
+	ids []string,
+) ([]*entity.User, error) {
+	var users []*entity.User
+	var details []ErrorDetail
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, dup := seen[id]; dup {
+			details = append(details, ErrorDetail{UserID: id, Reason: requestedDuplicate})
 			continue
 		}
-		if m.ID == reviewer.ID {
+		seen[id] = struct{}{}
+
+		if id == authorID {
+			details = append(details, ErrorDetail{UserID: id, Reason: string(ExclusionAuthor)})
 			continue
 		}
-		if m.ID == pr.AuthorID {
+		if _, conflict := conflicts[id]; conflict {
+			details = append(details, ErrorDetail{UserID: id, Reason: string(ExclusionConflict)})
 			continue
 		}
-		if _, exists := current[m.ID]; exists {
+		u, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				details = append(details, ErrorDetail{UserID: id, Reason: requestedNotFound})
+				continue
+			}
+			return nil, err
+		}
+		if !u.IsActive {
+			details = append(details, ErrorDetail{UserID: id, Reason: string(ExclusionInactive)})
 			continue
 		}
-		candidates = append(candidates, m)
+		users = append(users, u)
 	}
 
-	if len(candidates) == 0 {
-		return nil, "", NewNoCandidateError("no active replacement candidate in team")
+	if len(details) > 0 {
+		return nil, NewInvalidReviewersError("requested reviewers cannot be assigned", details)
 	}
+	return users, nil
+}
 
-	candidate := candidates[s.rng.Intn(len(candidates))]
+func requestedTraceStep(
+	teamName string,
+	requested []*entity.User,
+	loads map[string]int,
+	now time.Time,
+) entity.AssignmentTraceStep {
+	step := entity.AssignmentTraceStep{
+		Stage:      "requested",
+		TeamName:   teamName,
+		Candidates: make([]entity.TraceCandidate, 0, len(requested)),
+		Excluded:   []entity.TraceExclusion{},
+		Selected:   memberIDs(requested),
+	}
+	for _, u := range requested {
+		step.Candidates = append(step.Candidates, entity.TraceCandidate{
+			UserID:       u.ID,
+			OpenReviews:  loads[u.ID],
+			WorkingHours: u.IsWorkingAt(now),
+		})
+	}
+	return step
+}
 
-	pr.Reviewers[index] = candidate.ID
+// requiresSameSeniority сообщает требует ли состав команды автора ревьюверов уровня level
+func (s *pullRequestService) requiresSameSeniority(
+	ctx context.Context,
+	authorID string,
+	level entity.Seniority,
+) (bool, error) {
+	settings, err := s.authorTeamSettings(ctx, authorID)
+	if err != nil || settings == nil {
+		return false, err
+	}
+	return settings.MinBySeniority(level) > 0, nil
+}
 
-	if err := s.prRepo.Update(ctx, pr); err != nil {
+// authorTeamSettings возвращает настройки команды автора PR, nil - автора уже нет
+func (s *pullRequestService) authorTeamSettings(ctx context.Context, authorID string) (*entity.TeamSettings, error) {
+	author, err := s.userRepo.GetByID(ctx, authorID)
+	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
-			return nil, "", NewNotFoundError("pull request not found")
+			return nil, nil
 		}
-		return nil, "", err
+		return nil, err
+	}
+	return teamSettingsOrDefault(ctx, s.teamRepo, author.TeamName)
+}
+
+func (s *pullRequestService) GetAssignmentTraces(
+	ctx context.Context,
+	prID string,
+) ([]*entity.AssignmentTrace, error) {
+	if _, err := s.prRepo.GetByID(ctx, prID); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, NewNotFoundError("pull request not found")
+		}
+		return nil, err
 	}
 
-	return pr, candidate.ID, nil
+	traces, err := s.prRepo.ListAssignmentTraces(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if traces == nil {
+		traces = []*entity.AssignmentTrace{}
+	}
+	return traces, nil
 }
 
 func (s *pullRequestService) GetByReviewer(
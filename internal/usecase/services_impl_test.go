@@ -1,464 +1,1669 @@
-package usecase
-
-import (
-	"context"
-	"errors"
-	"testing"
-	"time"
-
-	"github.com/stretchr/testify/require"
-
-	"github.com/vandermeer0/pr-reviewer/internal/entity"
-	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
-)
-
-type inMemoryUserRepo struct {
-	users map[string]*entity.User
-}
-
-func newInMemoryUserRepo() *inMemoryUserRepo {
-	return &inMemoryUserRepo{
-		users: make(map[string]*entity.User),
-	}
-}
-
-func (r *inMemoryUserRepo) Save(_ context.Context, u *entity.User) error {
-	uCopy := *u
-	r.users[u.ID] = &uCopy
-	return nil
-}
-
-func (r *inMemoryUserRepo) GetByID(_ context.Context, id string) (*entity.User, error) {
-	u, ok := r.users[id]
-	if !ok {
-		return nil, repo.ErrNotFound
-	}
-	uCopy := *u
-	return &uCopy, nil
-}
-
-func (r *inMemoryUserRepo) SaveBatch(ctx context.Context, users []*entity.User) error {
-	for _, u := range users {
-		if err := r.Save(ctx, u); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-type inMemoryTeamRepo struct {
-	teams map[string]*entity.Team
-}
-
-func newInMemoryTeamRepo() *inMemoryTeamRepo {
-	return &inMemoryTeamRepo{
-		teams: make(map[string]*entity.Team),
-	}
-}
-
-func (r *inMemoryTeamRepo) Save(_ context.Context, team *entity.Team) error {
-	if _, exists := r.teams[team.Name]; exists {
-		return repo.ErrAlreadyExists
-	}
-	teamCopy := *team
-	r.teams[team.Name] = &teamCopy
-	return nil
-}
-
-func (r *inMemoryTeamRepo) GetByName(_ context.Context, name string) (*entity.Team, error) {
-	team, ok := r.teams[name]
-	if !ok {
-		return nil, repo.ErrNotFound
-	}
-	teamCopy := *team
-	teamCopy.Members = make([]*entity.User, 0, len(team.Members))
-	for _, m := range team.Members {
-		if m == nil {
-			continue
-		}
-		u := *m
-		teamCopy.Members = append(teamCopy.Members, &u)
-	}
-	return &teamCopy, nil
-}
-
-type inMemoryPRRepo struct {
-	prs map[string]*entity.PullRequest
-}
-
-func newInMemoryPRRepo() *inMemoryPRRepo {
-	return &inMemoryPRRepo{
-		prs: make(map[string]*entity.PullRequest),
-	}
-}
-
-func (r *inMemoryPRRepo) Save(_ context.Context, pr *entity.PullRequest) error {
-	if pr == nil {
-		return errors.New("pr is nil")
-	}
-	if _, exists := r.prs[pr.ID]; exists {
-		return repo.ErrAlreadyExists
-	}
-	prCopy := *pr
-	prCopy.Reviewers = append([]string(nil), pr.Reviewers...)
-	r.prs[pr.ID] = &prCopy
-	return nil
-}
-
-func (r *inMemoryPRRepo) GetByID(_ context.Context, id string) (*entity.PullRequest, error) {
-	pr, ok := r.prs[id]
-	if !ok {
-		return nil, repo.ErrNotFound
-	}
-	prCopy := *pr
-	prCopy.Reviewers = append([]string(nil), pr.Reviewers...)
-	return &prCopy, nil
-}
-
-func (r *inMemoryPRRepo) Update(_ context.Context, pr *entity.PullRequest) error {
-	if pr == nil {
-		return errors.New("pr is nil")
-	}
-	if _, exists := r.prs[pr.ID]; !exists {
-		return repo.ErrNotFound
-	}
-	prCopy := *pr
-	prCopy.Reviewers = append([]string(nil), pr.Reviewers...)
-	r.prs[pr.ID] = &prCopy
-	return nil
-}
-
-func (r *inMemoryPRRepo) GetByReviewerID(_ context.Context, reviewerID string) ([]*entity.PullRequest, error) {
-	var result []*entity.PullRequest
-	for _, pr := range r.prs {
-		for _, rid := range pr.Reviewers {
-			if rid == reviewerID {
-				prCopy := *pr
-				prCopy.Reviewers = append([]string(nil), pr.Reviewers...)
-				result = append(result, &prCopy)
-				break
-			}
-		}
-	}
-	if len(result) == 0 {
-		return nil, repo.ErrNotFound
-	}
-	return result, nil
-}
-
-func TestPullRequestService_Create_AssignsReviewers(t *testing.T) {
-	t.Parallel()
-	ctx := context.Background()
-
-	makeService := func(t *testing.T, users []*entity.User, team *entity.Team) PullRequestService {
-		t.Helper()
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		pr := newInMemoryPRRepo()
-
-		for _, u := range users {
-			require.NoError(t, ur.Save(ctx, u))
-		}
-		require.NoError(t, tr.Save(ctx, team))
-
-		return NewPullRequestService(pr, ur, tr)
-	}
-
-	t.Run("no candidates (only author)", func(t *testing.T) {
-		t.Parallel()
-		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
-		team := &entity.Team{
-			Name:    "t",
-			Members: []*entity.User{author},
-		}
-
-		svc := makeService(t, []*entity.User{author}, team)
-
-		pr, err := svc.Create(ctx, PullRequestCreateInput{
-			ID:       "pr-no-candidates",
-			Name:     "Test",
-			AuthorID: "u1",
-		})
-		require.NoError(t, err)
-		require.Equal(t, entity.StatusOpen, pr.Status)
-		require.Len(t, pr.Reviewers, 0)
-	})
-
-	t.Run("one candidate", func(t *testing.T) {
-		t.Parallel()
-		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
-		u2 := &entity.User{ID: "u2", Username: "Bob", TeamName: "t", IsActive: true}
-		team := &entity.Team{
-			Name:    "t",
-			Members: []*entity.User{author, u2},
-		}
-
-		svc := makeService(t, []*entity.User{author, u2}, team)
-
-		pr, err := svc.Create(ctx, PullRequestCreateInput{
-			ID:       "pr-one-candidate",
-			Name:     "Test",
-			AuthorID: "u1",
-		})
-		require.NoError(t, err)
-		require.Len(t, pr.Reviewers, 1)
-		require.Equal(t, "u2", pr.Reviewers[0])
-	})
-
-	t.Run("two active candidates, inactive и автор отфильтрованы", func(t *testing.T) {
-		t.Parallel()
-		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
-		u2 := &entity.User{ID: "u2", Username: "Bob", TeamName: "t", IsActive: true}
-		u3 := &entity.User{ID: "u3", Username: "Carol", TeamName: "t", IsActive: false}
-		u4 := &entity.User{ID: "u4", Username: "Dave", TeamName: "t", IsActive: true}
-
-		team := &entity.Team{
-			Name:    "t",
-			Members: []*entity.User{author, u2, u3, u4},
-		}
-
-		svc := makeService(t, []*entity.User{author, u2, u3, u4}, team)
-
-		pr, err := svc.Create(ctx, PullRequestCreateInput{
-			ID:       "pr-two-candidates",
-			Name:     "Test",
-			AuthorID: "u1",
-		})
-		require.NoError(t, err)
-		require.Len(t, pr.Reviewers, 2)
-
-		for _, rID := range pr.Reviewers {
-			require.NotEqual(t, "u1", rID)
-			require.NotEqual(t, "u3", rID)
-		}
-	})
-}
-
-func TestPullRequestService_ReassignReviewer_HappyPath(t *testing.T) {
-	t.Parallel()
-	ctx := context.Background()
-
-	ur := newInMemoryUserRepo()
-	tr := newInMemoryTeamRepo()
-	prr := newInMemoryPRRepo()
-
-	author := &entity.User{ID: "u-author", Username: "Author", TeamName: "team", IsActive: true}
-	oldRev := &entity.User{ID: "u-old", Username: "Old", TeamName: "team", IsActive: true}
-	newRev := &entity.User{ID: "u-new", Username: "New", TeamName: "team", IsActive: true}
-
-	for _, u := range []*entity.User{author, oldRev, newRev} {
-		require.NoError(t, ur.Save(ctx, u))
-	}
-
-	team := &entity.Team{
-		Name:    "team",
-		Members: []*entity.User{author, oldRev, newRev},
-	}
-	require.NoError(t, tr.Save(ctx, team))
-
-	pr := &entity.PullRequest{
-		ID:        "pr-1",
-		Name:      "Test PR",
-		AuthorID:  author.ID,
-		Status:    entity.StatusOpen,
-		Reviewers: []string{oldRev.ID},
-		CreatedAt: time.Now().UTC(),
-	}
-	require.NoError(t, prr.Save(ctx, pr))
-
-	svc := NewPullRequestService(prr, ur, tr)
-	prOut, replacedBy, err := svc.ReassignReviewer(ctx, "pr-1", oldRev.ID)
-	require.NoError(t, err)
-	require.Equal(t, "pr-1", prOut.ID)
-	require.Len(t, prOut.Reviewers, 1)
-
-	require.Equal(t, replacedBy, prOut.Reviewers[0])
-	require.Equal(t, newRev.ID, replacedBy)
-	require.NotEqual(t, oldRev.ID, replacedBy)
-	require.NotEqual(t, author.ID, replacedBy)
-}
-
-func TestPullRequestService_ReassignReviewer_Errors(t *testing.T) {
-	t.Parallel()
-	ctx := context.Background()
-
-	t.Run("PR_MERGED", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		prr := newInMemoryPRRepo()
-
-		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
-		rev := &entity.User{ID: "r", Username: "R", TeamName: "team", IsActive: true}
-
-		for _, u := range []*entity.User{author, rev} {
-			require.NoError(t, ur.Save(ctx, u))
-		}
-		require.NoError(t, tr.Save(ctx, &entity.Team{
-			Name:    "team",
-			Members: []*entity.User{author, rev},
-		}))
-
-		pr := &entity.PullRequest{
-			ID:        "pr-merged",
-			Name:      "Merged",
-			AuthorID:  author.ID,
-			Status:    entity.StatusMerged,
-			Reviewers: []string{rev.ID},
-			CreatedAt: time.Now().UTC(),
-		}
-		require.NoError(t, prr.Save(ctx, pr))
-
-		svc := NewPullRequestService(prr, ur, tr)
-		_, _, err := svc.ReassignReviewer(ctx, "pr-merged", rev.ID)
-		require.Error(t, err)
-
-		var de *DomainError
-		require.ErrorAs(t, err, &de)
-		require.Equal(t, ErrorCodePRMerged, de.Code)
-	})
-
-	t.Run("NOT_ASSIGNED", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		prr := newInMemoryPRRepo()
-
-		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
-		revAssigned := &entity.User{ID: "r1", Username: "R1", TeamName: "team", IsActive: true}
-		revNotAssigned := &entity.User{ID: "r2", Username: "R2", TeamName: "team", IsActive: true}
-
-		for _, u := range []*entity.User{author, revAssigned, revNotAssigned} {
-			require.NoError(t, ur.Save(ctx, u))
-		}
-		require.NoError(t, tr.Save(ctx, &entity.Team{
-			Name:    "team",
-			Members: []*entity.User{author, revAssigned, revNotAssigned},
-		}))
-
-		pr := &entity.PullRequest{
-			ID:        "pr-na",
-			Name:      "NA",
-			AuthorID:  author.ID,
-			Status:    entity.StatusOpen,
-			Reviewers: []string{revAssigned.ID},
-			CreatedAt: time.Now().UTC(),
-		}
-		require.NoError(t, prr.Save(ctx, pr))
-
-		svc := NewPullRequestService(prr, ur, tr)
-		_, _, err := svc.ReassignReviewer(ctx, "pr-na", revNotAssigned.ID)
-		require.Error(t, err)
-
-		var de *DomainError
-		require.ErrorAs(t, err, &de)
-		require.Equal(t, ErrorCodeNotAssigned, de.Code)
-	})
-
-	t.Run("NO_CANDIDATE", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		prr := newInMemoryPRRepo()
-
-		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
-		rev1 := &entity.User{ID: "r1", Username: "R1", TeamName: "team", IsActive: true}
-
-		for _, u := range []*entity.User{author, rev1} {
-			require.NoError(t, ur.Save(ctx, u))
-		}
-		require.NoError(t, tr.Save(ctx, &entity.Team{
-			Name:    "team",
-			Members: []*entity.User{author, rev1},
-		}))
-
-		pr := &entity.PullRequest{
-			ID:        "pr-nc",
-			Name:      "NC",
-			AuthorID:  author.ID,
-			Status:    entity.StatusOpen,
-			Reviewers: []string{rev1.ID},
-			CreatedAt: time.Now().UTC(),
-		}
-		require.NoError(t, prr.Save(ctx, pr))
-
-		svc := NewPullRequestService(prr, ur, tr)
-		_, _, err := svc.ReassignReviewer(ctx, "pr-nc", rev1.ID)
-		require.Error(t, err)
-
-		var de *DomainError
-		require.ErrorAs(t, err, &de)
-		require.Equal(t, ErrorCodeNoCandidate, de.Code)
-	})
-
-	t.Run("NOT_FOUND (PR)", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		prr := newInMemoryPRRepo()
-
-		svc := NewPullRequestService(prr, ur, tr)
-		_, _, err := svc.ReassignReviewer(ctx, "no-such-pr", "someone")
-		require.Error(t, err)
-
-		var de *DomainError
-		require.ErrorAs(t, err, &de)
-		require.Equal(t, ErrorCodeNotFound, de.Code)
-	})
-}
-
-// --- TeamService.CreateTeam ---
-
-func TestTeamService_CreateTeam_BasicAndErrors(t *testing.T) {
-	t.Parallel()
-	ctx := context.Background()
-
-	t.Run("success", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-
-		svc := NewTeamService(ur, tr)
-
-		team, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
-			{UserID: "u1", Username: "Alice", IsActive: true},
-			{UserID: "u2", Username: "Bob", IsActive: false},
-		})
-		require.NoError(t, err)
-		require.Equal(t, "backend", team.Name)
-		require.Len(t, team.Members, 2)
-
-		require.Len(t, ur.users, 2)
-		require.Contains(t, ur.users, "u1")
-		require.Contains(t, ur.users, "u2")
-	})
-
-	t.Run("TEAM_EXISTS", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		svc := NewTeamService(ur, tr)
-
-		_, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
-			{UserID: "u1", Username: "Alice", IsActive: true},
-		})
-		require.NoError(t, err)
-
-		_, err = svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
-			{UserID: "u2", Username: "Bob", IsActive: true},
-		})
-		require.Error(t, err)
-
-		var de *DomainError
-		require.ErrorAs(t, err, &de)
-		require.Equal(t, ErrorCodeTeamExists, de.Code)
-	})
-
-	t.Run("validation error", func(t *testing.T) {
-		ur := newInMemoryUserRepo()
-		tr := newInMemoryTeamRepo()
-		svc := NewTeamService(ur, tr)
-
-		_, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
-			{UserID: "", Username: "Alice", IsActive: true},
-		})
-		require.Error(t, err)
-
-		var de *DomainError
-		require.False(t, errors.As(err, &de), "validation не должна мапиться в DomainError")
-	})
-}
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/usecase/repo"
+)
+
+type inMemoryUserRepo struct {
+	users     map[string]*entity.User
+	ooo       []*entity.OutOfOffice
+	nextOOOID int64
+}
+
+func newInMemoryUserRepo() *inMemoryUserRepo {
+	return &inMemoryUserRepo{
+		users: make(map[string]*entity.User),
+	}
+}
+
+func (r *inMemoryUserRepo) Save(_ context.Context, u *entity.User) error {
+	uCopy := *u
+	r.users[u.ID] = &uCopy
+	return nil
+}
+
+func (r *inMemoryUserRepo) GetByID(_ context.Context, id string) (*entity.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	uCopy := *u
+	return &uCopy, nil
+}
+
+func (r *inMemoryUserRepo) GetByUsername(_ context.Context, username string) (*entity.User, error) {
+	var found *entity.User
+	for _, u := range r.users {
+		if u.Username == username && (found == nil || u.ID < found.ID) {
+			found = u
+		}
+	}
+	if found == nil {
+		return nil, repo.ErrNotFound
+	}
+	uCopy := *found
+	return &uCopy, nil
+}
+
+func (r *inMemoryUserRepo) SaveBatch(ctx context.Context, users []*entity.User) error {
+	for _, u := range users {
+		if err := r.Save(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryUserRepo) AddOutOfOffice(_ context.Context, period *entity.OutOfOffice) error {
+	r.nextOOOID++
+	period.ID = r.nextOOOID
+	pCopy := *period
+	r.ooo = append(r.ooo, &pCopy)
+	return nil
+}
+
+func (r *inMemoryUserRepo) ListOutOfOffice(_ context.Context, userID string) ([]*entity.OutOfOffice, error) {
+	var result []*entity.OutOfOffice
+	for _, p := range r.ooo {
+		if p.UserID == userID {
+			pCopy := *p
+			result = append(result, &pCopy)
+		}
+	}
+	return result, nil
+}
+
+func (r *inMemoryUserRepo) DeleteOutOfOffice(_ context.Context, userID string, id int64) error {
+	for i, p := range r.ooo {
+		if p.ID == id && p.UserID == userID {
+			r.ooo = append(r.ooo[:i], r.ooo[i+1:]...)
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (r *inMemoryUserRepo) GetAwayUserIDs(_ context.Context, userIDs []string, at time.Time) (map[string]struct{}, error) {
+	away := make(map[string]struct{})
+	for _, id := range userIDs {
+		for _, p := range r.ooo {
+			if p.UserID == id && p.Covers(at) {
+				away[id] = struct{}{}
+			}
+		}
+	}
+	return away, nil
+}
+
+type inMemoryTeamRepo struct {
+	teams    map[string]*entity.Team
+	settings map[string]*entity.TeamSettings
+}
+
+func newInMemoryTeamRepo() *inMemoryTeamRepo {
+	return &inMemoryTeamRepo{
+		teams:    make(map[string]*entity.Team),
+		settings: make(map[string]*entity.TeamSettings),
+	}
+}
+
+func (r *inMemoryTeamRepo) Save(_ context.Context, team *entity.Team) error {
+	if _, exists := r.teams[team.Name]; exists {
+		return repo.ErrAlreadyExists
+	}
+	teamCopy := *team
+	r.teams[team.Name] = &teamCopy
+	return nil
+}
+
+func (r *inMemoryTeamRepo) GetByName(_ context.Context, name string) (*entity.Team, error) {
+	team, ok := r.teams[name]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	teamCopy := *team
+	teamCopy.Members = make([]*entity.User, 0, len(team.Members))
+	for _, m := range team.Members {
+		if m == nil {
+			continue
+		}
+		u := *m
+		teamCopy.Members = append(teamCopy.Members, &u)
+	}
+	return &teamCopy, nil
+}
+
+func (r *inMemoryTeamRepo) GetSettings(_ context.Context, teamName string) (*entity.TeamSettings, error) {
+	st, ok := r.settings[teamName]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	stCopy := *st
+	return &stCopy, nil
+}
+
+func (r *inMemoryTeamRepo) SaveSettings(_ context.Context, settings *entity.TeamSettings) error {
+	stCopy := *settings
+	r.settings[settings.TeamName] = &stCopy
+	return nil
+}
+
+type inMemoryPRRepo struct {
+	prs    map[string]*entity.PullRequest
+	traces []*entity.AssignmentTrace
+}
+
+func newInMemoryPRRepo() *inMemoryPRRepo {
+	return &inMemoryPRRepo{
+		prs: make(map[string]*entity.PullRequest),
+	}
+}
+
+func copyPR(pr *entity.PullRequest) *entity.PullRequest {
+	prCopy := *pr
+	prCopy.Reviewers = append([]string(nil), pr.Reviewers...)
+	prCopy.RequiredReviewers = append([]entity.RequiredReviewer(nil), pr.RequiredReviewers...)
+	prCopy.ShadowReviewers = append([]string(nil), pr.ShadowReviewers...)
+	prCopy.RuleReviewers = append([]string(nil), pr.RuleReviewers...)
+	return &prCopy
+}
+
+func (r *inMemoryPRRepo) Save(_ context.Context, pr *entity.PullRequest) error {
+	if pr == nil {
+		return errors.New("pr is nil")
+	}
+	if _, exists := r.prs[pr.ID]; exists {
+		return repo.ErrAlreadyExists
+	}
+	r.prs[pr.ID] = copyPR(pr)
+	return nil
+}
+
+func (r *inMemoryPRRepo) GetByID(_ context.Context, id string) (*entity.PullRequest, error) {
+	pr, ok := r.prs[id]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return copyPR(pr), nil
+}
+
+func (r *inMemoryPRRepo) Update(_ context.Context, pr *entity.PullRequest) error {
+	if pr == nil {
+		return errors.New("pr is nil")
+	}
+	if _, exists := r.prs[pr.ID]; !exists {
+		return repo.ErrNotFound
+	}
+	r.prs[pr.ID] = copyPR(pr)
+	return nil
+}
+
+func (r *inMemoryPRRepo) SaveWithTrace(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error {
+	if err := r.Save(ctx, pr); err != nil {
+		return err
+	}
+	return r.SaveAssignmentTrace(ctx, trace)
+}
+
+func (r *inMemoryPRRepo) UpdateWithTrace(ctx context.Context, pr *entity.PullRequest, trace *entity.AssignmentTrace) error {
+	if err := r.Update(ctx, pr); err != nil {
+		return err
+	}
+	return r.SaveAssignmentTrace(ctx, trace)
+}
+
+func (r *inMemoryPRRepo) GetByReviewerID(_ context.Context, reviewerID string) ([]*entity.PullRequest, error) {
+	var result []*entity.PullRequest
+	for _, pr := range r.prs {
+		if pr.Status == entity.StatusClosed || pr.Status == entity.StatusDraft {
+			continue
+		}
+		for _, rid := range pr.Reviewers {
+			if rid == reviewerID {
+				result = append(result, copyPR(pr))
+				break
+			}
+		}
+		if pr.HasShadowReviewer(reviewerID) {
+			result = append(result, &entity.PullRequest{
+				ID: pr.ID, Name: pr.Name, AuthorID: pr.AuthorID, Status: pr.Status,
+				ShadowReviewers: []string{reviewerID},
+			})
+		}
+	}
+	if len(result) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return result, nil
+}
+
+func (r *inMemoryPRRepo) CountOpenReviews(_ context.Context, reviewerIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(reviewerIDs))
+	wanted := make(map[string]struct{}, len(reviewerIDs))
+	for _, id := range reviewerIDs {
+		wanted[id] = struct{}{}
+	}
+	for _, pr := range r.prs {
+		if pr.Status != entity.StatusOpen {
+			continue
+		}
+		for _, rid := range pr.Reviewers {
+			if _, ok := wanted[rid]; ok {
+				counts[rid]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r *inMemoryPRRepo) GetPairHistory(
+	_ context.Context,
+	authorID string,
+	reviewerIDs []string,
+	since time.Time,
+) (map[string]entity.ReviewPairHistory, error) {
+	wanted := idSet(reviewerIDs)
+	out := make(map[string]entity.ReviewPairHistory)
+	for _, pr := range r.prs {
+		if pr.AuthorID != authorID || pr.CreatedAt.Before(since) || pr.Status == entity.StatusClosed || pr.Status == entity.StatusDraft {
+			continue
+		}
+		for _, rid := range pr.Reviewers {
+			if _, ok := wanted[rid]; !ok {
+				continue
+			}
+			h := out[rid]
+			h.Reviews++
+			if pr.CreatedAt.After(h.LastAt) {
+				h.LastAt = pr.CreatedAt
+			}
+			out[rid] = h
+		}
+	}
+	return out, nil
+}
+
+func (r *inMemoryPRRepo) SaveAssignmentTrace(_ context.Context, trace *entity.AssignmentTrace) error {
+	trace.ID = int64(len(r.traces) + 1)
+	traceCopy := *trace
+	r.traces = append(r.traces, &traceCopy)
+	return nil
+}
+
+func (r *inMemoryPRRepo) ListAssignmentTraces(_ context.Context, prID string) ([]*entity.AssignmentTrace, error) {
+	var result []*entity.AssignmentTrace
+	for _, t := range r.traces {
+		if t.PullRequestID == prID {
+			traceCopy := *t
+			result = append(result, &traceCopy)
+		}
+	}
+	return result, nil
+}
+
+func TestPullRequestService_Create_AssignsReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	makeService := func(t *testing.T, users []*entity.User, team *entity.Team) PullRequestService {
+		t.Helper()
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		pr := newInMemoryPRRepo()
+
+		for _, u := range users {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, team))
+
+		return NewPullRequestService(pr, ur, tr)
+	}
+
+	t.Run("no candidates (only author)", func(t *testing.T) {
+		t.Parallel()
+		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
+		team := &entity.Team{
+			Name:    "t",
+			Members: []*entity.User{author},
+		}
+
+		svc := makeService(t, []*entity.User{author}, team)
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID:       "pr-no-candidates",
+			Name:     "Test",
+			AuthorID: "u1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, entity.StatusOpen, pr.Status)
+		require.Len(t, pr.Reviewers, 0)
+	})
+
+	t.Run("one candidate", func(t *testing.T) {
+		t.Parallel()
+		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
+		u2 := &entity.User{ID: "u2", Username: "Bob", TeamName: "t", IsActive: true}
+		team := &entity.Team{
+			Name:    "t",
+			Members: []*entity.User{author, u2},
+		}
+
+		svc := makeService(t, []*entity.User{author, u2}, team)
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID:       "pr-one-candidate",
+			Name:     "Test",
+			AuthorID: "u1",
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 1)
+		require.Equal(t, "u2", pr.Reviewers[0])
+	})
+
+	t.Run("two active candidates, inactive и автор отфильтрованы", func(t *testing.T) {
+		t.Parallel()
+		author := &entity.User{ID: "u1", Username: "Alice", TeamName: "t", IsActive: true}
+		u2 := &entity.User{ID: "u2", Username: "Bob", TeamName: "t", IsActive: true}
+		u3 := &entity.User{ID: "u3", Username: "Carol", TeamName: "t", IsActive: false}
+		u4 := &entity.User{ID: "u4", Username: "Dave", TeamName: "t", IsActive: true}
+
+		team := &entity.Team{
+			Name:    "t",
+			Members: []*entity.User{author, u2, u3, u4},
+		}
+
+		svc := makeService(t, []*entity.User{author, u2, u3, u4}, team)
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID:       "pr-two-candidates",
+			Name:     "Test",
+			AuthorID: "u1",
+		})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 2)
+
+		for _, rID := range pr.Reviewers {
+			require.NotEqual(t, "u1", rID)
+			require.NotEqual(t, "u3", rID)
+		}
+	})
+}
+
+func TestPullRequestService_ReassignReviewer_HappyPath(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "u-author", Username: "Author", TeamName: "team", IsActive: true}
+	oldRev := &entity.User{ID: "u-old", Username: "Old", TeamName: "team", IsActive: true}
+	newRev := &entity.User{ID: "u-new", Username: "New", TeamName: "team", IsActive: true}
+
+	for _, u := range []*entity.User{author, oldRev, newRev} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+
+	team := &entity.Team{
+		Name:    "team",
+		Members: []*entity.User{author, oldRev, newRev},
+	}
+	require.NoError(t, tr.Save(ctx, team))
+
+	pr := &entity.PullRequest{
+		ID:        "pr-1",
+		Name:      "Test PR",
+		AuthorID:  author.ID,
+		Status:    entity.StatusOpen,
+		Reviewers: []string{oldRev.ID},
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, prr.Save(ctx, pr))
+
+	svc := NewPullRequestService(prr, ur, tr)
+	prOut, replacedBy, err := svc.ReassignReviewer(ctx, "pr-1", oldRev.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pr-1", prOut.ID)
+	require.Len(t, prOut.Reviewers, 1)
+
+	require.Equal(t, replacedBy, prOut.Reviewers[0])
+	require.Equal(t, newRev.ID, replacedBy)
+	require.NotEqual(t, oldRev.ID, replacedBy)
+	require.NotEqual(t, author.ID, replacedBy)
+}
+
+func TestPullRequestService_ReassignReviewer_Errors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("PR_MERGED", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+
+		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
+		rev := &entity.User{ID: "r", Username: "R", TeamName: "team", IsActive: true}
+
+		for _, u := range []*entity.User{author, rev} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{
+			Name:    "team",
+			Members: []*entity.User{author, rev},
+		}))
+
+		pr := &entity.PullRequest{
+			ID:        "pr-merged",
+			Name:      "Merged",
+			AuthorID:  author.ID,
+			Status:    entity.StatusMerged,
+			Reviewers: []string{rev.ID},
+			CreatedAt: time.Now().UTC(),
+		}
+		require.NoError(t, prr.Save(ctx, pr))
+
+		svc := NewPullRequestService(prr, ur, tr)
+		_, _, err := svc.ReassignReviewer(ctx, "pr-merged", rev.ID)
+		require.Error(t, err)
+
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodePRMerged, de.Code)
+	})
+
+	t.Run("NOT_ASSIGNED", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+
+		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
+		revAssigned := &entity.User{ID: "r1", Username: "R1", TeamName: "team", IsActive: true}
+		revNotAssigned := &entity.User{ID: "r2", Username: "R2", TeamName: "team", IsActive: true}
+
+		for _, u := range []*entity.User{author, revAssigned, revNotAssigned} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{
+			Name:    "team",
+			Members: []*entity.User{author, revAssigned, revNotAssigned},
+		}))
+
+		pr := &entity.PullRequest{
+			ID:        "pr-na",
+			Name:      "NA",
+			AuthorID:  author.ID,
+			Status:    entity.StatusOpen,
+			Reviewers: []string{revAssigned.ID},
+			CreatedAt: time.Now().UTC(),
+		}
+		require.NoError(t, prr.Save(ctx, pr))
+
+		svc := NewPullRequestService(prr, ur, tr)
+		_, _, err := svc.ReassignReviewer(ctx, "pr-na", revNotAssigned.ID)
+		require.Error(t, err)
+
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotAssigned, de.Code)
+	})
+
+	t.Run("NO_CANDIDATE", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+
+		author := &entity.User{ID: "a", Username: "A", TeamName: "team", IsActive: true}
+		rev1 := &entity.User{ID: "r1", Username: "R1", TeamName: "team", IsActive: true}
+
+		for _, u := range []*entity.User{author, rev1} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{
+			Name:    "team",
+			Members: []*entity.User{author, rev1},
+		}))
+
+		pr := &entity.PullRequest{
+			ID:        "pr-nc",
+			Name:      "NC",
+			AuthorID:  author.ID,
+			Status:    entity.StatusOpen,
+			Reviewers: []string{rev1.ID},
+			CreatedAt: time.Now().UTC(),
+		}
+		require.NoError(t, prr.Save(ctx, pr))
+
+		svc := NewPullRequestService(prr, ur, tr)
+		_, _, err := svc.ReassignReviewer(ctx, "pr-nc", rev1.ID)
+		require.Error(t, err)
+
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("NOT_FOUND (PR)", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+
+		svc := NewPullRequestService(prr, ur, tr)
+		_, _, err := svc.ReassignReviewer(ctx, "no-such-pr", "someone")
+		require.Error(t, err)
+
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+}
+
+// --- TeamService.CreateTeam ---
+
+func TestTeamService_CreateTeam_BasicAndErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+
+		svc := NewTeamService(ur, tr)
+
+		team, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: false},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "backend", team.Name)
+		require.Len(t, team.Members, 2)
+
+		require.Len(t, ur.users, 2)
+		require.Contains(t, ur.users, "u1")
+		require.Contains(t, ur.users, "u2")
+	})
+
+	t.Run("TEAM_EXISTS", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		svc := NewTeamService(ur, tr)
+
+		_, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+		})
+		require.NoError(t, err)
+
+		_, err = svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		})
+		require.Error(t, err)
+
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeTeamExists, de.Code)
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		svc := NewTeamService(ur, tr)
+
+		_, err := svc.CreateTeam(ctx, "backend", []CreateTeamMemberInput{
+			{UserID: "", Username: "Alice", IsActive: true},
+		})
+		require.Error(t, err)
+
+		var de *DomainError
+		require.False(t, errors.As(err, &de), "validation не должна мапиться в DomainError")
+	})
+}
+
+// --- TeamService settings ---
+
+func TestTeamService_Settings(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	svc := NewTeamService(ur, tr)
+
+	_, err := svc.CreateTeam(ctx, "security", []CreateTeamMemberInput{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		st, err := svc.GetSettings(ctx, "security")
+		require.NoError(t, err)
+		require.Equal(t, entity.DefaultMinReviewers, st.MinReviewers)
+		require.Equal(t, entity.DefaultMaxReviewers, st.MaxReviewers)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		_, err := svc.UpdateSettings(ctx, TeamSettingsInput{TeamName: "security", MinReviewers: intPtr(2), MaxReviewers: intPtr(3)})
+		require.NoError(t, err)
+
+		st, err := svc.GetSettings(ctx, "security")
+		require.NoError(t, err)
+		require.Equal(t, 2, st.MinReviewers)
+		require.Equal(t, 3, st.MaxReviewers)
+	})
+
+	t.Run("omitted fields keep stored values", func(t *testing.T) {
+		_, err := svc.UpdateSettings(ctx, TeamSettingsInput{
+			TeamName: "security", MaxOpenReviews: intPtr(4), MinSeniorReviewers: intPtr(1),
+		})
+		require.NoError(t, err)
+
+		st, err := svc.UpdateSettings(ctx, TeamSettingsInput{TeamName: "security", MaxReviewers: intPtr(4)})
+		require.NoError(t, err)
+		require.Equal(t, 2, st.MinReviewers)
+		require.Equal(t, 4, st.MaxReviewers)
+		require.Equal(t, intPtr(4), st.MaxOpenReviews)
+		require.Equal(t, 1, st.MinSeniorReviewers)
+
+		st, err = svc.UpdateSettings(ctx, TeamSettingsInput{TeamName: "security", ClearMaxOpenReviews: true})
+		require.NoError(t, err)
+		require.Nil(t, st.MaxOpenReviews)
+		require.Equal(t, 4, st.MaxReviewers)
+	})
+
+	t.Run("INVALID_INPUT", func(t *testing.T) {
+		_, err := svc.UpdateSettings(ctx, TeamSettingsInput{TeamName: "security", MinReviewers: intPtr(3), MaxReviewers: intPtr(1)})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+	})
+
+	t.Run("NOT_FOUND", func(t *testing.T) {
+		_, err := svc.GetSettings(ctx, "no-such-team")
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+}
+
+func TestPullRequestService_Create_RespectsTeamSettings(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	makeService := func(t *testing.T, settings *entity.TeamSettings, members ...*entity.User) PullRequestService {
+		t.Helper()
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		for _, u := range members {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: members}))
+		require.NoError(t, tr.SaveSettings(ctx, settings))
+		return NewPullRequestService(newInMemoryPRRepo(), ur, tr)
+	}
+
+	team := func() []*entity.User {
+		return []*entity.User{
+			{ID: "u1", Username: "A", TeamName: "t", IsActive: true},
+			{ID: "u2", Username: "B", TeamName: "t", IsActive: true},
+			{ID: "u3", Username: "C", TeamName: "t", IsActive: true},
+			{ID: "u4", Username: "D", TeamName: "t", IsActive: true},
+		}
+	}
+
+	t.Run("max_reviewers=3", func(t *testing.T) {
+		t.Parallel()
+		svc := makeService(t, &entity.TeamSettings{TeamName: "t", MinReviewers: 0, MaxReviewers: 3}, team()...)
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr", Name: "PR", AuthorID: "u1"})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"u2", "u3", "u4"}, pr.Reviewers)
+	})
+
+	t.Run("max_reviewers=1", func(t *testing.T) {
+		t.Parallel()
+		svc := makeService(t, &entity.TeamSettings{TeamName: "t", MinReviewers: 0, MaxReviewers: 1}, team()...)
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr", Name: "PR", AuthorID: "u1"})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 1)
+	})
+
+	t.Run("min_reviewers not reachable", func(t *testing.T) {
+		t.Parallel()
+		members := team()
+		members[2].IsActive = false
+		members[3].IsActive = false
+		svc := makeService(t, &entity.TeamSettings{TeamName: "t", MinReviewers: 2, MaxReviewers: 2}, members...)
+		_, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr", Name: "PR", AuthorID: "u1"})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+}
+
+func TestPullRequestService_ReviewCapacity(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	one := 1
+	five := 5
+
+	setup := func(t *testing.T, candOverride *int) (PullRequestService, *inMemoryPRRepo) {
+		t.Helper()
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+
+		author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+		old := &entity.User{ID: "old", Username: "Old", TeamName: "t", IsActive: true}
+		cand := &entity.User{ID: "cand", Username: "Cand", TeamName: "t", IsActive: true, MaxOpenReviews: candOverride}
+		for _, u := range []*entity.User{author, old, cand} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, old, cand}}))
+		require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{
+			TeamName: "t", MinReviewers: 0, MaxReviewers: 2, MaxOpenReviews: &one,
+		}))
+
+		require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+			ID: "pr-load", Name: "Load", AuthorID: old.ID, Status: entity.StatusOpen, Reviewers: []string{cand.ID},
+		}))
+		require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+			ID: "pr", Name: "PR", AuthorID: author.ID, Status: entity.StatusOpen, Reviewers: []string{old.ID},
+		}))
+
+		return NewPullRequestService(prr, ur, tr), prr
+	}
+
+	t.Run("NO_CAPACITY on reassign", func(t *testing.T) {
+		t.Parallel()
+		svc, _ := setup(t, nil)
+
+		_, _, err := svc.ReassignReviewer(ctx, "pr", "old")
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCapacity, de.Code)
+	})
+
+	t.Run("personal limit overrides team limit", func(t *testing.T) {
+		t.Parallel()
+		svc, _ := setup(t, &five)
+
+		_, replacedBy, err := svc.ReassignReviewer(ctx, "pr", "old")
+		require.NoError(t, err)
+		require.Equal(t, "cand", replacedBy)
+	})
+
+	t.Run("create records understaffed PR", func(t *testing.T) {
+		t.Parallel()
+		svc, _ := setup(t, nil)
+
+		// у обоих кандидатов уже по одному открытому ревью при лимите команды 1
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-new", Name: "New", AuthorID: "a"})
+		require.NoError(t, err)
+		require.Empty(t, pr.Reviewers)
+		require.Equal(t, 2, pr.TargetReviewers)
+		require.True(t, pr.IsUnderstaffed())
+	})
+}
+
+func TestOutOfOffice_ExcludesAwayReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	old := &entity.User{ID: "old", Username: "Old", TeamName: "t", IsActive: true}
+	onVacation := &entity.User{ID: "vac", Username: "Vac", TeamName: "t", IsActive: true}
+	back := &entity.User{ID: "back", Username: "Back", TeamName: "t", IsActive: true}
+	for _, u := range []*entity.User{author, old, onVacation, back} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, old, onVacation, back}}))
+
+	userSvc := NewUserService(ur)
+	now := time.Now().UTC()
+
+	current, err := userSvc.AddOutOfOffice(ctx, OutOfOfficeInput{
+		UserID: onVacation.ID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(7 * 24 * time.Hour), Reason: "vacation",
+	})
+	require.NoError(t, err)
+	_, err = userSvc.AddOutOfOffice(ctx, OutOfOfficeInput{
+		UserID: back.ID, StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	prSvc := NewPullRequestService(prr, ur, tr)
+
+	t.Run("create skips away user", func(t *testing.T) {
+		pr, err := prSvc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "PR", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{old.ID, back.ID}, pr.Reviewers)
+	})
+
+	t.Run("reassign skips away user", func(t *testing.T) {
+		_, _, err := prSvc.ReassignReviewer(ctx, "pr-1", old.ID)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("current period", func(t *testing.T) {
+		got, err := userSvc.CurrentOutOfOffice(ctx, onVacation.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		require.Equal(t, current.ID, got.ID)
+
+		got, err = userSvc.CurrentOutOfOffice(ctx, back.ID)
+		require.NoError(t, err)
+		require.Nil(t, got)
+
+		// период оценивается по часам подбора, а не по системному времени
+		pastSvc := NewUserService(ur, WithClock(fixedClock{now: now.Add(-36 * time.Hour)}))
+		got, err = pastSvc.CurrentOutOfOffice(ctx, back.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		_, err := userSvc.AddOutOfOffice(ctx, OutOfOfficeInput{UserID: back.ID, StartsAt: now, EndsAt: now.Add(-time.Hour)})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, userSvc.DeleteOutOfOffice(ctx, onVacation.ID, current.ID))
+
+		err := userSvc.DeleteOutOfOffice(ctx, onVacation.ID, current.ID)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+}
+
+func TestUserService_SetReviewWeight(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	require.NoError(t, ur.Save(ctx, &entity.User{ID: "u1", Username: "U1", TeamName: "t", IsActive: true}))
+	userSvc := NewUserService(ur)
+
+	u, err := userSvc.SetReviewWeight(ctx, "u1", 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 0.5, u.EffectiveReviewWeight())
+
+	for _, w := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		_, err = userSvc.SetReviewWeight(ctx, "u1", w)
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+	}
+
+	_, err = userSvc.SetReviewWeight(ctx, "missing", 1)
+	var de *DomainError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestPullRequestService_Create_PrefersWorkingHours(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	weekdays := entity.WeekdayMask(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+	office := &entity.WorkSchedule{Start: 9 * 60, End: 18 * 60, Weekdays: weekdays}
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	moscow := &entity.User{ID: "msk", Username: "Msk", TeamName: "t", IsActive: true, Timezone: "Europe/Moscow", Schedule: office}
+	newYork := &entity.User{ID: "nyc", Username: "Nyc", TeamName: "t", IsActive: true, Timezone: "America/New_York", Schedule: office}
+
+	makeService := func(t *testing.T, now time.Time) PullRequestService {
+		t.Helper()
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		for _, u := range []*entity.User{author, moscow, newYork} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, moscow, newYork}}))
+		require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 1}))
+		return NewPullRequestService(newInMemoryPRRepo(), ur, tr, WithClock(fixedClock{now: now}))
+	}
+
+	t.Run("evening in Moscow goes to New York", func(t *testing.T) {
+		t.Parallel()
+		// среда 15:00 UTC: в Москве 18:00, в Нью-Йорке 10:00
+		now := time.Date(2025, time.November, 5, 15, 0, 0, 0, time.UTC)
+		for i := 0; i < 10; i++ {
+			pr, err := makeService(t, now).Create(ctx, PullRequestCreateInput{ID: "pr", Name: "PR", AuthorID: author.ID})
+			require.NoError(t, err)
+			require.Equal(t, []string{newYork.ID}, pr.Reviewers)
+			require.Equal(t, now, pr.CreatedAt)
+		}
+	})
+
+	t.Run("nobody working falls back to everyone", func(t *testing.T) {
+		t.Parallel()
+		// суббота - выходной у обоих
+		now := time.Date(2025, time.November, 8, 12, 0, 0, 0, time.UTC)
+		pr, err := makeService(t, now).Create(ctx, PullRequestCreateInput{ID: "pr", Name: "PR", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 1)
+	})
+
+	t.Run("night shift spans midnight", func(t *testing.T) {
+		t.Parallel()
+		night := &entity.User{ID: "n", Timezone: "UTC", Schedule: &entity.WorkSchedule{
+			Start: 22 * 60, End: 6 * 60, Weekdays: entity.WeekdayMask(time.Friday),
+		}}
+		require.True(t, night.IsWorkingAt(time.Date(2025, time.November, 7, 23, 0, 0, 0, time.UTC)))
+		require.True(t, night.IsWorkingAt(time.Date(2025, time.November, 8, 5, 0, 0, 0, time.UTC)))
+		require.False(t, night.IsWorkingAt(time.Date(2025, time.November, 8, 23, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestFallbackTeams(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	solo := &entity.User{ID: "solo", Username: "Solo", TeamName: "tiny", IsActive: true}
+	mate := &entity.User{ID: "mate", Username: "Mate", TeamName: "small", IsActive: true}
+	smallAuthor := &entity.User{ID: "sa", Username: "SA", TeamName: "small", IsActive: true}
+	p1 := &entity.User{ID: "p1", Username: "P1", TeamName: "platform", IsActive: true}
+	s1 := &entity.User{ID: "s1", Username: "S1", TeamName: "sre", IsActive: true}
+	for _, u := range []*entity.User{solo, mate, smallAuthor, p1, s1} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "tiny", Members: []*entity.User{solo}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "small", Members: []*entity.User{smallAuthor, mate}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "platform", Members: []*entity.User{p1}}))
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "sre", Members: []*entity.User{s1}}))
+
+	teamSvc := NewTeamService(ur, tr)
+	_, err := teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+		TeamName: "tiny", MinReviewers: intPtr(1), MaxReviewers: intPtr(2), FallbackTeams: &[]string{"platform", "sre"},
+	})
+	require.NoError(t, err)
+	_, err = teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+		TeamName: "small", MinReviewers: intPtr(0), MaxReviewers: intPtr(1), FallbackTeams: &[]string{"sre"},
+	})
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	t.Run("solo author gets reviewers from fallbacks in order", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-solo", Name: "Solo", AuthorID: solo.ID})
+		require.NoError(t, err)
+		require.Equal(t, []string{p1.ID, s1.ID}, pr.Reviewers)
+		require.Equal(t, []string{p1.ID, s1.ID}, pr.FallbackReviewers)
+		require.False(t, pr.IsUnderstaffed())
+	})
+
+	t.Run("home team is used first", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-small", Name: "Small", AuthorID: smallAuthor.ID})
+		require.NoError(t, err)
+		require.Equal(t, []string{mate.ID}, pr.Reviewers)
+		require.Empty(t, pr.FallbackReviewers)
+	})
+
+	t.Run("reassign in small team falls back", func(t *testing.T) {
+		pr, replacedBy, err := svc.ReassignReviewer(ctx, "pr-small", mate.ID)
+		require.NoError(t, err)
+		require.Equal(t, s1.ID, replacedBy)
+		require.Equal(t, []string{s1.ID}, pr.FallbackReviewers)
+	})
+
+	t.Run("settings validation", func(t *testing.T) {
+		var de *DomainError
+
+		_, err := teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+			TeamName: "tiny", MaxReviewers: intPtr(1), FallbackTeams: &[]string{"tiny"},
+		})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+		_, err = teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+			TeamName: "tiny", MaxReviewers: intPtr(1), FallbackTeams: &[]string{"ghosts"},
+		})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+	})
+}
+
+func TestPullRequestService_PairDiversity(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Date(2025, time.November, 5, 12, 0, 0, 0, time.UTC)
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	usual := &entity.User{ID: "usual", Username: "Usual", TeamName: "t", IsActive: true}
+	rare1 := &entity.User{ID: "rare1", Username: "Rare1", TeamName: "t", IsActive: true}
+	rare2 := &entity.User{ID: "rare2", Username: "Rare2", TeamName: "t", IsActive: true}
+
+	newService := func(t *testing.T, window time.Duration) (PullRequestService, *inMemoryPRRepo) {
+		t.Helper()
+		ur := newInMemoryUserRepo()
+		tr := newInMemoryTeamRepo()
+		prr := newInMemoryPRRepo()
+		for _, u := range []*entity.User{author, usual, rare1, rare2} {
+			require.NoError(t, ur.Save(ctx, u))
+		}
+		require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, usual, rare1, rare2}}))
+		require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 1}))
+
+		// последние дни автор отдавал все PR одному и тому же ревьюверу
+		for i := 0; i < 5; i++ {
+			require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+				ID:        fmt.Sprintf("old-%d", i),
+				AuthorID:  author.ID,
+				Status:    entity.StatusMerged,
+				Reviewers: []string{usual.ID},
+				CreatedAt: now.Add(-time.Duration(i+1) * 24 * time.Hour),
+			}))
+		}
+
+		selectors := &ReviewerSelectors{defaultSelector: NewPairDiversitySelector(7), byTeam: map[string]ReviewerSelector{}}
+		svc := NewPullRequestService(prr, ur, tr,
+			WithReviewerSelectors(selectors),
+			WithClock(fixedClock{now: now}),
+			WithPairHistoryWindow(window),
+		)
+		return svc, prr
+	}
+
+	t.Run("recent pair is picked less often", func(t *testing.T) {
+		t.Parallel()
+		svc, _ := newService(t, 30*24*time.Hour)
+
+		picks := make(map[string]int)
+		for i := 0; i < 30; i++ {
+			pr, err := svc.Create(ctx, PullRequestCreateInput{ID: fmt.Sprintf("pr-%d", i), Name: "PR", AuthorID: author.ID})
+			require.NoError(t, err)
+			require.Len(t, pr.Reviewers, 1)
+			picks[pr.Reviewers[0]]++
+		}
+
+		require.Less(t, picks[usual.ID], picks[rare1.ID])
+		require.Less(t, picks[usual.ID], picks[rare2.ID])
+	})
+
+	t.Run("freshness decays over the window", func(t *testing.T) {
+		t.Parallel()
+		a := newReviewerAssigner([]AssignmentOption{
+			WithClock(fixedClock{now: now}),
+			WithPairHistoryWindow(10 * 24 * time.Hour),
+		})
+		require.InDelta(t, 0.9, a.pairFreshness(now, now.Add(-24*time.Hour)), 1e-9)
+		require.Zero(t, a.pairFreshness(now, now.Add(-11*24*time.Hour)))
+
+		disabled := newReviewerAssigner([]AssignmentOption{WithPairHistoryWindow(0)})
+		_, ok := disabled.pairHistorySince()
+		require.False(t, ok)
+	})
+}
+
+func TestSeniorityComposition(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+	teamSvc := NewTeamService(ur, tr)
+
+	_, err := teamSvc.CreateTeam(ctx, "core", []CreateTeamMemberInput{
+		{UserID: "a", Username: "Author", IsActive: true, Seniority: entity.SeniorityMiddle},
+		{UserID: "s1", Username: "S1", IsActive: true, Seniority: entity.SenioritySenior},
+		{UserID: "s2", Username: "S2", IsActive: true, Seniority: entity.SenioritySenior},
+		{UserID: "j1", Username: "J1", IsActive: true, Seniority: entity.SeniorityJunior},
+		{UserID: "m1", Username: "M1", IsActive: true, Seniority: entity.SeniorityMiddle},
+		{UserID: "m2", Username: "M2", IsActive: true},
+	})
+	require.NoError(t, err)
+	_, err = teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+		TeamName: "core", MaxReviewers: intPtr(2), MinSeniorReviewers: intPtr(1), MinJuniorReviewers: intPtr(1),
+	})
+	require.NoError(t, err)
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "Mixed", AuthorID: "a"})
+	require.NoError(t, err)
+	require.Len(t, pr.Reviewers, 2)
+	require.Contains(t, pr.Reviewers, "j1")
+	senior := "s1"
+	if !slices.Contains(pr.Reviewers, senior) {
+		senior = "s2"
+	}
+	require.Contains(t, pr.Reviewers, senior)
+
+	t.Run("senior is replaced by senior", func(t *testing.T) {
+		other := map[string]string{"s1": "s2", "s2": "s1"}[senior]
+		_, replacedBy, err := svc.ReassignReviewer(ctx, "pr-1", senior)
+		require.NoError(t, err)
+		require.Equal(t, other, replacedBy)
+	})
+
+	t.Run("no junior to replace junior", func(t *testing.T) {
+		_, _, err := svc.ReassignReviewer(ctx, "pr-1", "j1")
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("create fails when composition cannot be met", func(t *testing.T) {
+		_, err := teamSvc.CreateTeam(ctx, "seniors", []CreateTeamMemberInput{
+			{UserID: "sa", Username: "SA", IsActive: true, Seniority: entity.SenioritySenior},
+			{UserID: "sb", Username: "SB", IsActive: true, Seniority: entity.SenioritySenior},
+		})
+		require.NoError(t, err)
+		_, err = teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+			TeamName: "seniors", MaxReviewers: intPtr(1), MinJuniorReviewers: intPtr(1),
+		})
+		require.NoError(t, err)
+
+		_, err = svc.Create(ctx, PullRequestCreateInput{ID: "pr-2", Name: "No juniors", AuthorID: "sa"})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNoCandidate, de.Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var de *DomainError
+
+		_, err := teamSvc.CreateTeam(ctx, "bad", []CreateTeamMemberInput{
+			{UserID: "x", Username: "X", IsActive: true, Seniority: "lead"},
+		})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+
+		_, err = teamSvc.UpdateSettings(ctx, TeamSettingsInput{
+			TeamName: "core", MaxReviewers: intPtr(2), MinSeniorReviewers: intPtr(2), MinJuniorReviewers: intPtr(1),
+		})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidInput, de.Code)
+	})
+}
+
+func TestPullRequestService_AssignmentTrace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	idle := &entity.User{ID: "idle", Username: "Idle", TeamName: "t", IsActive: false}
+	for _, u := range []*entity.User{author, r1, r2, idle} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, r1, r2, idle}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 1}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "Traced", AuthorID: author.ID})
+	require.NoError(t, err)
+	require.Len(t, pr.Reviewers, 1)
+
+	traces, err := svc.GetAssignmentTraces(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+
+	created := traces[0]
+	require.Equal(t, entity.TraceActionCreate, created.Action)
+	require.Equal(t, pr.Reviewers, created.Reviewers)
+	require.Len(t, created.Steps, 1)
+	step := created.Steps[0]
+	require.Equal(t, "team", step.Stage)
+	require.Equal(t, string(StrategyRandom), step.Strategy)
+	require.Len(t, step.Seeds, 1)
+	require.ElementsMatch(t, []string{r1.ID, r2.ID}, []string{step.Candidates[0].UserID, step.Candidates[1].UserID})
+	require.ElementsMatch(t, []entity.TraceExclusion{
+		{UserID: author.ID, Reason: string(ExclusionAuthor)},
+		{UserID: idle.ID, Reason: string(ExclusionInactive)},
+	}, step.Excluded)
+	require.Equal(t, pr.Reviewers, step.Selected)
+
+	old := pr.Reviewers[0]
+	_, replacedBy, err := svc.ReassignReviewer(ctx, "pr-1", old)
+	require.NoError(t, err)
+
+	traces, err = svc.GetAssignmentTraces(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Len(t, traces, 2)
+	reassigned := traces[1]
+	require.Equal(t, entity.TraceActionReassign, reassigned.Action)
+	require.Equal(t, old, reassigned.ReplacedReviewerID)
+	require.Equal(t, []string{replacedBy}, reassigned.Reviewers)
+	require.Contains(t, reassigned.Steps[0].Excluded, entity.TraceExclusion{UserID: old, Reason: string(ExclusionAlreadyAssigned)})
+
+	_, err = svc.GetAssignmentTraces(ctx, "missing")
+	var de *DomainError
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestPullRequestService_AddRemoveReviewer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	senior := &entity.User{ID: "sen", Username: "Sen", TeamName: "t", IsActive: true, Seniority: entity.SenioritySenior}
+	expert := &entity.User{ID: "expert", Username: "Expert", TeamName: "other", IsActive: true}
+	gone := &entity.User{ID: "gone", Username: "Gone", TeamName: "t", IsActive: false}
+	for _, u := range []*entity.User{author, r1, senior, expert, gone} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, r1, senior, gone}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 2, MinSeniorReviewers: 1}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "pr", Name: "PR", AuthorID: author.ID, Status: entity.StatusOpen,
+		Reviewers: []string{senior.ID, r1.ID}, TargetReviewers: 2,
+	}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "merged", Name: "Merged", AuthorID: author.ID, Status: entity.StatusMerged, Reviewers: []string{r1.ID},
+	}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	t.Run("add expert from another team", func(t *testing.T) {
+		pr, err := svc.AddReviewer(ctx, "pr", expert.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{senior.ID, r1.ID, expert.ID}, pr.Reviewers)
+	})
+
+	t.Run("remove without replacement", func(t *testing.T) {
+		pr, err := svc.RemoveReviewer(ctx, "pr", r1.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{senior.ID, expert.ID}, pr.Reviewers)
+
+		traces, err := svc.GetAssignmentTraces(ctx, "pr")
+		require.NoError(t, err)
+		require.Len(t, traces, 2)
+		require.Equal(t, entity.TraceActionAdd, traces[0].Action)
+		require.Equal(t, entity.TraceActionRemove, traces[1].Action)
+		require.Equal(t, r1.ID, traces[1].ReplacedReviewerID)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			name string
+			call func() error
+			code ErrorCode
+		}{
+			{"duplicate", func() error { _, err := svc.AddReviewer(ctx, "pr", expert.ID); return err }, ErrorCodeAlreadyAssigned},
+			{"author", func() error { _, err := svc.AddReviewer(ctx, "pr", author.ID); return err }, ErrorCodeInvalidInput},
+			{"inactive", func() error { _, err := svc.AddReviewer(ctx, "pr", gone.ID); return err }, ErrorCodeUserInactive},
+			{"unknown user", func() error { _, err := svc.AddReviewer(ctx, "pr", "ghost"); return err }, ErrorCodeNotFound},
+			{"merged add", func() error { _, err := svc.AddReviewer(ctx, "merged", expert.ID); return err }, ErrorCodePRMerged},
+			{"merged remove", func() error { _, err := svc.RemoveReviewer(ctx, "merged", r1.ID); return err }, ErrorCodePRMerged},
+			{"not assigned", func() error { _, err := svc.RemoveReviewer(ctx, "pr", r1.ID); return err }, ErrorCodeNotAssigned},
+			{"last senior", func() error { _, err := svc.RemoveReviewer(ctx, "pr", senior.ID); return err }, ErrorCodeReviewerRequired},
+		}
+		for _, tc := range cases {
+			var de *DomainError
+			require.ErrorAs(t, tc.call(), &de, tc.name)
+			require.Equal(t, tc.code, de.Code, tc.name)
+		}
+	})
+}
+
+func TestPullRequestService_Create_RequestedReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	mate := &entity.User{ID: "mate", Username: "Mate", TeamName: "t", IsActive: true}
+	expert := &entity.User{ID: "expert", Username: "Expert", TeamName: "other", IsActive: true}
+	gone := &entity.User{ID: "gone", Username: "Gone", TeamName: "other", IsActive: false}
+	for _, u := range []*entity.User{author, mate, expert, gone} {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: []*entity.User{author, mate}}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MaxReviewers: 2}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	t.Run("requested first, rest auto-filled", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-1", Name: "PR", AuthorID: author.ID, RequestedReviewers: []string{expert.ID},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{expert.ID, mate.ID}, pr.Reviewers)
+
+		traces, err := svc.GetAssignmentTraces(ctx, "pr-1")
+		require.NoError(t, err)
+		require.Equal(t, "requested", traces[0].Steps[0].Stage)
+		require.Equal(t, "team", traces[0].Steps[1].Stage)
+	})
+
+	t.Run("all invalid reviewers are reported", func(t *testing.T) {
+		_, err := svc.Create(ctx, PullRequestCreateInput{
+			ID: "pr-2", Name: "PR", AuthorID: author.ID,
+			RequestedReviewers: []string{author.ID, "ghost", gone.ID, mate.ID, mate.ID},
+		})
+		var de *DomainError
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeInvalidReviewers, de.Code)
+		require.Equal(t, []ErrorDetail{
+			{UserID: author.ID, Reason: "author"},
+			{UserID: "ghost", Reason: "not_found"},
+			{UserID: gone.ID, Reason: "inactive"},
+			{UserID: mate.ID, Reason: "duplicate"},
+		}, de.Details)
+
+		_, err = prr.GetByID(ctx, "pr-2")
+		require.ErrorIs(t, err, repo.ErrNotFound)
+	})
+}
+
+func TestPullRequestService_ShadowReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	trainee := &entity.User{ID: "new", Username: "New", TeamName: "t", IsActive: true, IsTrainee: true}
+	members := []*entity.User{author, r1, r2, trainee}
+	for _, u := range members {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: members}))
+	require.NoError(t, tr.SaveSettings(ctx, &entity.TeamSettings{TeamName: "t", MinReviewers: 1, MaxReviewers: 1}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	t.Run("create fills shadow slot with trainee", func(t *testing.T) {
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "PR", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.Len(t, pr.Reviewers, 1)
+		require.NotContains(t, pr.Reviewers, trainee.ID)
+		require.Equal(t, []string{trainee.ID}, pr.ShadowReviewers)
+		require.False(t, pr.IsUnderstaffed())
+
+		traces, err := svc.GetAssignmentTraces(ctx, pr.ID)
+		require.NoError(t, err)
+		steps := traces[0].Steps
+		require.Equal(t, "shadow", steps[len(steps)-1].Stage)
+	})
+
+	t.Run("shadow is listed in reviewer PRs", func(t *testing.T) {
+		prs, err := svc.GetByReviewer(ctx, trainee.ID)
+		require.NoError(t, err)
+		require.Len(t, prs, 1)
+		require.True(t, prs[0].HasShadowReviewer(trainee.ID))
+		require.False(t, prs[0].HasReviewer(trainee.ID))
+	})
+
+	t.Run("added shadow becomes regular reviewer", func(t *testing.T) {
+		pr, err := svc.AddReviewer(ctx, "pr-1", trainee.ID)
+		require.NoError(t, err)
+		require.True(t, pr.HasReviewer(trainee.ID))
+		require.Empty(t, pr.ShadowReviewers)
+	})
+
+	t.Run("no trainee - no shadow", func(t *testing.T) {
+		// команда хранит тех же пользователей, что и тест
+		trainee.IsActive = false
+
+		pr, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-2", Name: "PR", AuthorID: author.ID})
+		require.NoError(t, err)
+		require.Empty(t, pr.ShadowReviewers)
+	})
+}
+
+func TestPullRequestService_CloseReopen(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	members := []*entity.User{author, r1, r2}
+	for _, u := range members {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: members}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "pr-1", Name: "Abandoned", AuthorID: author.ID, Status: entity.StatusOpen,
+		Reviewers: []string{r1.ID}, CreatedAt: time.Now().UTC(),
+	}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	closed, err := svc.Close(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusClosed, closed.Status)
+	require.NotNil(t, closed.ClosedAt)
+	require.Equal(t, []string{r1.ID}, closed.Reviewers)
+
+	again, err := svc.Close(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, closed.ClosedAt, again.ClosedAt)
+
+	prs, err := svc.GetByReviewer(ctx, r1.ID)
+	require.NoError(t, err)
+	require.Empty(t, prs)
+
+	var de *DomainError
+	_, _, err = svc.ReassignReviewer(ctx, "pr-1", r1.ID)
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRClosed, de.Code)
+	_, err = svc.AddReviewer(ctx, "pr-1", r2.ID)
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRClosed, de.Code)
+	_, err = svc.Merge(ctx, "pr-1")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRClosed, de.Code)
+
+	reopened, err := svc.Reopen(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, reopened.Status)
+	require.Nil(t, reopened.ClosedAt)
+	require.Equal(t, []string{r1.ID}, reopened.Reviewers)
+
+	prs, err = svc.GetByReviewer(ctx, r1.ID)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+
+	_, err = svc.Merge(ctx, "pr-1")
+	require.NoError(t, err)
+	_, err = svc.Reopen(ctx, "pr-1")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRMerged, de.Code)
+	_, err = svc.Close(ctx, "pr-1")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRMerged, de.Code)
+
+	_, err = svc.Close(ctx, "ghost")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestPullRequestService_DraftReadyForReview(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	members := []*entity.User{author, r1, r2}
+	for _, u := range members {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: members}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	draft, err := svc.Create(ctx, PullRequestCreateInput{ID: "pr-1", Name: "WIP", AuthorID: author.ID, Draft: true})
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusDraft, draft.Status)
+	require.Empty(t, draft.Reviewers)
+	require.Zero(t, draft.TargetReviewers)
+
+	traces, err := svc.GetAssignmentTraces(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Empty(t, traces)
+
+	var de *DomainError
+	_, err = svc.AddReviewer(ctx, "pr-1", r1.ID)
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRDraft, de.Code)
+	_, err = svc.Merge(ctx, "pr-1")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodePRDraft, de.Code)
+
+	// черновик можно закрыть, переоткрытый он снова черновик без ревьюверов
+	closed, err := svc.Close(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusClosed, closed.Status)
+	require.NotNil(t, closed.ClosedAt)
+	reopened, err := svc.Reopen(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusDraft, reopened.Status)
+	require.Nil(t, reopened.ClosedAt)
+	require.Empty(t, reopened.Reviewers)
+
+	// запрошенный при готовности ревьювер назначается первым, как при создании
+	ready, err := svc.ReadyForReview(ctx, PullRequestReadyInput{ID: "pr-1", RequestedReviewers: []string{r2.ID}})
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, ready.Status)
+	require.Equal(t, []string{r2.ID, r1.ID}, ready.Reviewers)
+	require.Equal(t, entity.DefaultMaxReviewers, ready.TargetReviewers)
+
+	traces, err = svc.GetAssignmentTraces(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	require.Equal(t, entity.TraceActionReady, traces[0].Action)
+
+	prs, err := svc.GetByReviewer(ctx, r1.ID)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+
+	again, err := svc.ReadyForReview(ctx, PullRequestReadyInput{ID: "pr-1"})
+	require.NoError(t, err)
+	require.Equal(t, ready.Reviewers, again.Reviewers)
+
+	// атрибуты подбора черновика сохраняются до готовности к ревью
+	withRequested, err := svc.Create(ctx, PullRequestCreateInput{
+		ID: "pr-2", Name: "WIP", AuthorID: author.ID, Draft: true, RequestedReviewers: []string{r1.ID},
+	})
+	require.NoError(t, err)
+	require.Empty(t, withRequested.Reviewers)
+	ready2, err := svc.ReadyForReview(ctx, PullRequestReadyInput{ID: "pr-2"})
+	require.NoError(t, err)
+	require.Equal(t, r1.ID, ready2.Reviewers[0])
+	require.Empty(t, ready2.Draft.RequestedReviewers)
+
+	_, err = svc.ReadyForReview(ctx, PullRequestReadyInput{ID: "ghost"})
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestPullRequestService_ReopenReplacesGoneReviewers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	ur := newInMemoryUserRepo()
+	tr := newInMemoryTeamRepo()
+	prr := newInMemoryPRRepo()
+
+	author := &entity.User{ID: "a", Username: "A", TeamName: "t", IsActive: true}
+	r1 := &entity.User{ID: "r1", Username: "R1", TeamName: "t", IsActive: true}
+	r2 := &entity.User{ID: "r2", Username: "R2", TeamName: "t", IsActive: true}
+	r3 := &entity.User{ID: "r3", Username: "R3", TeamName: "t", IsActive: true}
+	members := []*entity.User{author, r1, r2, r3}
+	for _, u := range members {
+		require.NoError(t, ur.Save(ctx, u))
+	}
+	require.NoError(t, tr.Save(ctx, &entity.Team{Name: "t", Members: members}))
+	require.NoError(t, prr.Save(ctx, &entity.PullRequest{
+		ID: "pr-1", Name: "Paused", AuthorID: author.ID, Status: entity.StatusOpen,
+		Reviewers: []string{r1.ID, r2.ID}, TargetReviewers: 2, CreatedAt: time.Now().UTC(),
+	}))
+
+	svc := NewPullRequestService(prr, ur, tr)
+
+	_, err := svc.Close(ctx, "pr-1")
+	require.NoError(t, err)
+
+	// пока PR закрыт, r1 деактивировали - после переоткрытия его место занимает r3
+	r1.IsActive = false
+	require.NoError(t, ur.Save(ctx, r1))
+
+	reopened, err := svc.Reopen(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, reopened.Status)
+	require.Equal(t, []string{r2.ID, r3.ID}, reopened.Reviewers)
+	require.False(t, reopened.IsUnderstaffed())
+
+	traces, err := svc.GetAssignmentTraces(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	require.Equal(t, entity.TraceActionTopUp, traces[0].Action)
+	require.Equal(t, []string{r3.ID}, traces[0].Reviewers)
+
+	// кандидатов не осталось: PR переоткрывается недоукомплектованным
+	_, err = svc.Close(ctx, "pr-1")
+	require.NoError(t, err)
+	r3.IsActive = false
+	require.NoError(t, ur.Save(ctx, r3))
+
+	reopened, err = svc.Reopen(ctx, "pr-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{r2.ID}, reopened.Reviewers)
+	require.True(t, reopened.IsUnderstaffed())
+}
+
+func intPtr(v int) *int {
+	return &v
+}
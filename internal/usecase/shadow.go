@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+)
+
+// shadowReviewersPerPR - сколько теневых ревьюверов назначается при создании PR
+const shadowReviewersPerPR = 1
+
+// pickShadowReviewers подбирает стажёров из members в теневые ревьюверы
+// Отсеиваются те же автор, конфликты, неактивные и отсутствующие, что и при обычном подборе,
+// а также уже назначенные ревьюверы из req.Exclude; лимиты открытых ревью не учитываются,
+// потому что теневое ревью их не занимает
+func (a *reviewerAssigner) pickShadowReviewers(
+	ctx context.Context,
+	req assignmentRequest,
+	members []*entity.User,
+) (assignmentResult, error) {
+	var trainees []*entity.User
+	for _, m := range members {
+		if m != nil && m.IsTrainee {
+			trainees = append(trainees, m)
+		}
+	}
+	if len(trainees) == 0 {
+		return assignmentResult{}, nil
+	}
+
+	req.Members = trainees
+	req.Loads = nil
+	req.TeamReviewLimit = nil
+	req.OtherTeamLimits = nil
+	req.PairHistory = nil
+	req.Count = shadowReviewersPerPR
+	req.Selector = a.shadowSelector
+	req.Shadow = true
+	req.Stage = "shadow"
+	req.Scope = ""
+	return a.pick(ctx, req)
+}
+
+// withoutShadowReviewer убирает пользователя из теневых ревьюверов PR
+func withoutShadowReviewer(pr *entity.PullRequest, userID string) {
+	out := pr.ShadowReviewers[:0]
+	for _, id := range pr.ShadowReviewers {
+		if id != userID {
+			out = append(out, id)
+		}
+	}
+	pr.ShadowReviewers = out
+}
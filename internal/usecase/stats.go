@@ -6,11 +6,24 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// ReviewerStat хранит данные по количеству назначений
+// ReviewerStat хранит данные по количеству назначений, закрытые без слияния PR не учитываются
 type ReviewerStat struct {
-	UserID      string
-	Username    string
+	UserID   string
+	Username string
+	TeamName string
+	IsActive bool
+	// IsTrainee - стажёр, автоматически обычным ревьювером не назначается
+	IsTrainee bool
+	// Assignments - назначения в открытых и слитых PR, закрытые и черновики не учитываются
 	Assignments int64
+	// ShadowAssignments - теневые назначения, в Assignments и доли не входят
+	ShadowAssignments int64
+	// ReviewWeight - вес пользователя в weighted_random
+	ReviewWeight float64
+	// ExpectedShare - доля назначений команды, которую даёт вес среди активных участников, не стажёров
+	ExpectedShare float64
+	// ActualShare - фактическая доля пользователя в назначениях участников команды
+	ActualShare float64
 }
 
 // StatsService выдаёт статистику по ревьюверам
@@ -29,10 +42,16 @@ func NewStatsService(db *pgxpool.Pool) StatsService {
 
 func (s *statsServiceImpl) GetReviewerStats(ctx context.Context) ([]ReviewerStat, error) {
 	const query = `
-SELECT u.id, u.username, COUNT(prr.pull_request_id) AS assignments
+SELECT u.id, u.username, u.team_name, u.is_active, u.is_trainee, u.review_weight,
+       (SELECT COUNT(*)
+        FROM pr_reviewers prr
+        JOIN pull_requests pr ON pr.id = prr.pull_request_id
+        WHERE prr.reviewer_id = u.id AND pr.status IN ('OPEN', 'MERGED')) AS assignments,
+       (SELECT COUNT(*)
+        FROM pr_shadow_reviewers srv
+        JOIN pull_requests pr ON pr.id = srv.pull_request_id
+        WHERE srv.reviewer_id = u.id AND pr.status IN ('OPEN', 'MERGED')) AS shadow_assignments
 FROM users u
-LEFT JOIN pr_reviewers prr ON prr.reviewer_id = u.id
-GROUP BY u.id, u.username
 ORDER BY assignments DESC, u.id
 `
 	rows, err := s.db.Query(ctx, query)
@@ -44,7 +63,10 @@ ORDER BY assignments DESC, u.id
 	var stats []ReviewerStat
 	for rows.Next() {
 		var st ReviewerStat
-		if err := rows.Scan(&st.UserID, &st.Username, &st.Assignments); err != nil {
+		if err := rows.Scan(
+			&st.UserID, &st.Username, &st.TeamName, &st.IsActive, &st.IsTrainee, &st.ReviewWeight,
+			&st.Assignments, &st.ShadowAssignments,
+		); err != nil {
 			return nil, err
 		}
 		stats = append(stats, st)
@@ -53,5 +75,34 @@ ORDER BY assignments DESC, u.id
 		return nil, err
 	}
 
+	fillReviewerShares(stats)
 	return stats, nil
 }
+
+// fillReviewerShares считает ожидаемую и фактическую долю назначений внутри каждой команды
+// Неактивные участники и стажёры ожидаемой доли не имеют, но их назначения входят в фактическую
+func fillReviewerShares(stats []ReviewerStat) {
+	weights := make(map[string]float64)
+	assignments := make(map[string]int64)
+	for _, st := range stats {
+		if expectsShare(st) {
+			weights[st.TeamName] += st.ReviewWeight
+		}
+		assignments[st.TeamName] += st.Assignments
+	}
+
+	for i := range stats {
+		st := &stats[i]
+		if total := weights[st.TeamName]; expectsShare(*st) && total > 0 {
+			st.ExpectedShare = st.ReviewWeight / total
+		}
+		if total := assignments[st.TeamName]; total > 0 {
+			st.ActualShare = float64(st.Assignments) / float64(total)
+		}
+	}
+}
+
+// expectsShare - участвует ли пользователь в автоматическом подборе обычных ревьюверов
+func expectsShare(st ReviewerStat) bool {
+	return st.IsActive && !st.IsTrainee
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFillReviewerShares(t *testing.T) {
+	t.Parallel()
+
+	stats := []ReviewerStat{
+		{UserID: "lead", TeamName: "backend", IsActive: true, ReviewWeight: 0.5, Assignments: 2},
+		{UserID: "dev1", TeamName: "backend", IsActive: true, ReviewWeight: 1, Assignments: 4},
+		{UserID: "dev2", TeamName: "backend", IsActive: true, ReviewWeight: 1, Assignments: 3},
+		{UserID: "left", TeamName: "backend", IsActive: false, ReviewWeight: 1, Assignments: 1},
+		{UserID: "solo", TeamName: "mobile", IsActive: true, ReviewWeight: 1},
+		{UserID: "intern", TeamName: "backend", IsActive: true, IsTrainee: true, ReviewWeight: 1},
+	}
+	fillReviewerShares(stats)
+
+	require.InDelta(t, 0.2, stats[0].ExpectedShare, 1e-9)
+	require.InDelta(t, 0.2, stats[0].ActualShare, 1e-9)
+	require.InDelta(t, 0.4, stats[1].ExpectedShare, 1e-9)
+	require.InDelta(t, 0.4, stats[1].ActualShare, 1e-9)
+	require.Zero(t, stats[3].ExpectedShare)
+	require.InDelta(t, 0.1, stats[3].ActualShare, 1e-9)
+	require.InDelta(t, 1, stats[4].ExpectedShare, 1e-9)
+	require.Zero(t, stats[4].ActualShare)
+	require.Zero(t, stats[5].ExpectedShare)
+}
@@ -2,9 +2,13 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vandermeer0/pr-reviewer/internal/entity"
+	"github.com/vandermeer0/pr-reviewer/internal/infrastructure/repository/postgresql"
 )
 
 // TeamDeactivationResult описывает результат массовой деактивации участников
@@ -16,18 +20,40 @@ type TeamDeactivationResult struct {
 	AffectedPullRequests int
 }
 
+// UserDeactivationResult описывает результат деактивации одного пользователя
+type UserDeactivationResult struct {
+	User                 *entity.User
+	RemovedAssignments   int64
+	NewAssignments       int64
+	AffectedPullRequests int
+}
+
 // TeamMaintenanceService описывает операции по массовому обслуживанию команд
 type TeamMaintenanceService interface {
 	DeactivateTeamMembers(ctx context.Context, teamName string) (TeamDeactivationResult, error)
+	// DeactivateUser деактивирует пользователя и перераспределяет его открытые ревью
+	DeactivateUser(ctx context.Context, userID string) (UserDeactivationResult, error)
+	// ActivateUser активирует пользователя и добирает его в недоукомплектованные PR команды
+	ActivateUser(ctx context.Context, userID string) (UserActivationResult, error)
+	// AddTeamMembers добавляет участников в команду и добирает их в недоукомплектованные PR команды
+	AddTeamMembers(ctx context.Context, teamName string, members []CreateTeamMemberInput) (TeamMembersAddResult, error)
+	// RebalanceTeam выравнивает число открытых ревью между участниками команды
+	RebalanceTeam(ctx context.Context, teamName string, opts RebalanceOptions) (TeamRebalanceResult, error)
+	// HandoffReviews передаёт все открытые ревью пользователя в одной транзакции
+	HandoffReviews(ctx context.Context, input ReviewHandoffInput) (ReviewHandoffResult, error)
 }
 
 type teamMaintenanceServiceImpl struct {
-	db *pgxpool.Pool
+	db       *pgxpool.Pool
+	assigner *reviewerAssigner
 }
 
 // NewTeamMaintenanceService создаёт реализацию TeamMaintenanceService
-func NewTeamMaintenanceService(db *pgxpool.Pool) TeamMaintenanceService {
-	return &teamMaintenanceServiceImpl{db: db}
+func NewTeamMaintenanceService(db *pgxpool.Pool, opts ...AssignmentOption) TeamMaintenanceService {
+	return &teamMaintenanceServiceImpl{
+		db:       db,
+		assigner: newReviewerAssigner(opts),
+	}
 }
 
 func (s *teamMaintenanceServiceImpl) DeactivateTeamMembers(ctx context.Context, teamName string) (res TeamDeactivationResult, err error) {
@@ -53,7 +79,8 @@ func (s *teamMaintenanceServiceImpl) DeactivateTeamMembers(ctx context.Context,
 	}
 	res.DeactivatedUsers = cmd.RowsAffected()
 
-	rows, err := tx.Query(ctx, `
+	var prIDs []string
+	res.RemovedAssignments, prIDs, err = removeOpenAssignmentsTx(ctx, tx, `
 			DELETE FROM pr_reviewers prr
 			USING pull_requests pr, users reviewer
 			WHERE prr.pull_request_id = pr.id
@@ -66,86 +93,539 @@ func (s *teamMaintenanceServiceImpl) DeactivateTeamMembers(ctx context.Context,
 	if err != nil {
 		return res, err
 	}
-	defer rows.Close()
 
-	prSet := make(map[string]struct{})
-	for rows.Next() {
-		var prID string
-		if err = rows.Scan(&prID); err != nil {
+	if len(prIDs) == 0 {
+		if err = tx.Commit(ctx); err != nil {
 			return res, err
 		}
-		res.RemovedAssignments++
-		prSet[prID] = struct{}{}
+		return res, nil
 	}
-	if err = rows.Err(); err != nil {
+
+	res.AffectedPullRequests = len(prIDs)
+
+	cursors := make(map[string]string)
+	res.NewAssignments, _, err = s.topUpReviewers(ctx, tx, prIDs, nil, cursors)
+	if err != nil {
 		return res, err
 	}
 
-	if len(prSet) == 0 {
-		if err = tx.Commit(ctx); err != nil {
-			return res, err
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+	s.assigner.saveCursors(ctx, cursors)
+
+	return res, nil
+}
+
+// DeactivateUser деактивирует одного пользователя, снимает его с открытых PR
+// и добирает ревьюверов в эти PR в той же транзакции
+func (s *teamMaintenanceServiceImpl) DeactivateUser(ctx context.Context, userID string) (res UserDeactivationResult, err error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
 		}
-		return res, nil
+	}()
+
+	cmd, err := tx.Exec(ctx, `
+			UPDATE users
+			SET is_active = FALSE
+			WHERE id = $1
+	`, userID)
+	if err != nil {
+		return res, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return res, NewNotFoundError("user not found")
 	}
 
-	prIDs := make([]string, 0, len(prSet))
-	for id := range prSet {
-		prIDs = append(prIDs, id)
+	var prIDs []string
+	res.RemovedAssignments, prIDs, err = removeOpenAssignmentsTx(ctx, tx, `
+			DELETE FROM pr_reviewers prr
+			USING pull_requests pr
+			WHERE prr.pull_request_id = pr.id
+				AND pr.status = 'OPEN'
+				AND prr.reviewer_id = $1
+			RETURNING prr.pull_request_id
+	`, userID)
+	if err != nil {
+		return res, err
 	}
 	res.AffectedPullRequests = len(prIDs)
 
-	cmd, err = tx.Exec(ctx, `
-WITH affected AS (
-    SELECT DISTINCT UNNEST($1::text[]) AS pr_id
-),
-current AS (
-    SELECT
-        pr.id AS pr_id,
-        COUNT(prr.reviewer_id) AS existing_cnt
-    FROM pull_requests pr
-    LEFT JOIN pr_reviewers prr ON prr.pull_request_id = pr.id
-    WHERE pr.id IN (SELECT pr_id FROM affected)
-        AND pr.status = 'OPEN'
-    GROUP BY pr.id
-),
-candidates AS (
-    SELECT
-        pr.id AS pr_id,
-        u.id AS candidate_id,
-        ROW_NUMBER() OVER (PARTITION BY pr.id ORDER BY RANDOM()) AS rn
-    FROM pull_requests pr
-    JOIN affected a ON a.pr_id = pr.id
-    JOIN users author ON author.id = pr.author_id
-    JOIN users u
-        ON u.team_name = author.team_name
-        AND u.is_active = TRUE
-        AND u.id <> author.id
-    WHERE NOT EXISTS (
-        SELECT 1
-        FROM pr_reviewers ex
-        WHERE ex.pull_request_id = pr.id
-            AND ex.reviewer_id = u.id
-    )
-),
-to_insert AS (
-    SELECT c.pr_id, c.candidate_id
-    FROM candidates c
-    JOIN current cur ON cur.pr_id = c.pr_id
-    WHERE cur.existing_cnt < 2
-        AND c.rn <= 2 - cur.existing_cnt
-)
-INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-SELECT pr_id, candidate_id
-FROM to_insert
-`, prIDs)
+	cursors := make(map[string]string)
+	if len(prIDs) > 0 {
+		res.NewAssignments, _, err = s.topUpReviewers(ctx, tx, prIDs, nil, cursors)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	users, err := loadUsersTx(ctx, tx, []string{userID})
 	if err != nil {
 		return res, err
 	}
-	res.NewAssignments = cmd.RowsAffected()
+	res.User = users[userID]
 
 	if err = tx.Commit(ctx); err != nil {
 		return res, err
 	}
-
+	s.assigner.saveCursors(ctx, cursors)
 	return res, nil
 }
+
+// removeOpenAssignmentsTx выполняет DELETE из pr_reviewers с RETURNING pull_request_id
+// и возвращает число снятых назначений и затронутые PR
+func removeOpenAssignmentsTx(ctx context.Context, tx pgx.Tx, query string, args ...any) (int64, []string, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var removed int64
+	var prIDs []string
+	prSet := make(map[string]struct{})
+	for rows.Next() {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
+			return 0, nil, err
+		}
+		removed++
+		if _, ok := prSet[prID]; ok {
+			continue
+		}
+		prSet[prID] = struct{}{}
+		prIDs = append(prIDs, prID)
+	}
+	return removed, prIDs, rows.Err()
+}
+
+// topUpReviewers добирает ревьюверов в открытые PR из команды автора и её
+// запасных команд до target_reviewers PR, only ограничивает кандидатов (nil - все)
+// Все данные читаются внутри транзакции чтобы видеть только что деактивированных
+// пользователей и удалённые назначения
+// Возвращает число новых назначений и PR, в которые кто-то был добавлен
+// Курсоры round_robin подборов попадают в cursors и сохраняются вызывающим после Commit;
+// nil - курсоры не сохраняются, но внутри операции очередь всё равно идёт по кругу
+func (s *teamMaintenanceServiceImpl) topUpReviewers(
+	ctx context.Context,
+	tx pgx.Tx,
+	prIDs []string,
+	only map[string]struct{},
+	cursors map[string]string,
+) (int64, []TopUpPullRequest, error) {
+	if cursors == nil {
+		cursors = make(map[string]string)
+	}
+
+	rows, err := tx.Query(ctx, `
+			SELECT pr.id, pr.author_id, author.team_name, pr.target_reviewers,
+			       (SELECT COUNT(*) FROM pr_reviewers prr WHERE prr.pull_request_id = pr.id AND prr.from_rule)
+			FROM pull_requests pr
+			JOIN users author ON author.id = pr.author_id
+			WHERE pr.id = ANY($1)
+				AND pr.status = 'OPEN'
+			ORDER BY pr.id
+	`, prIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	type openPR struct {
+		id              string
+		authorID        string
+		teamName        string
+		targetReviewers int
+		ruleReviewers   int
+	}
+	var prs []openPR
+	teamSet := make(map[string]struct{})
+	authorSet := make(map[string]struct{})
+	for rows.Next() {
+		var p openPR
+		if err := rows.Scan(&p.id, &p.authorID, &p.teamName, &p.targetReviewers, &p.ruleReviewers); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		prs = append(prs, p)
+		teamSet[p.teamName] = struct{}{}
+		authorSet[p.authorID] = struct{}{}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(prs) == 0 {
+		return 0, nil, nil
+	}
+
+	existing, err := loadReviewersTx(ctx, tx, prIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	authorTeams := make([]string, 0, len(teamSet))
+	for name := range teamSet {
+		authorTeams = append(authorTeams, name)
+	}
+	settings, err := loadTeamSettingsTx(ctx, tx, authorTeams)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// участники запасных команд тоже нужны: из них добираются недостающие
+	teamNames := authorTeams
+	for _, name := range authorTeams {
+		for _, fb := range settings[name].FallbackTeams {
+			if _, ok := teamSet[fb]; ok {
+				continue
+			}
+			teamSet[fb] = struct{}{}
+			teamNames = append(teamNames, fb)
+		}
+	}
+	if len(teamNames) > len(authorTeams) {
+		settings, err = loadTeamSettingsTx(ctx, tx, teamNames)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	members, err := loadTeamMembersTx(ctx, tx, teamNames)
+	if err != nil {
+		return 0, nil, err
+	}
+	loads, err := loadOpenReviewCountsTx(ctx, tx, teamNames)
+	if err != nil {
+		return 0, nil, err
+	}
+	away, err := loadAwayUsersTx(ctx, tx, teamNames, s.assigner.clock.Now())
+	if err != nil {
+		return 0, nil, err
+	}
+	history, err := s.loadPairHistoryTx(ctx, tx, authorSet)
+	if err != nil {
+		return 0, nil, err
+	}
+	conflicts, err := loadConflictsTx(ctx, tx, authorSet)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var inserted int64
+	var filled []TopUpPullRequest
+	for _, p := range prs {
+		current := existing[p.id]
+		// добавленные правилами в target_reviewers не входят
+		need := p.targetReviewers - (len(current) - p.ruleReviewers)
+		if need <= 0 {
+			continue
+		}
+
+		exclude := make(map[string]struct{}, len(current))
+		for _, id := range current {
+			exclude[id] = struct{}{}
+		}
+
+		var fallbacks []teamPool
+		otherLimits := make(map[string]*int)
+		for _, fb := range settings[p.teamName].FallbackTeams {
+			fallbacks = append(fallbacks, teamPool{TeamName: fb, Members: onlyUsers(members[fb], only)})
+			otherLimits[fb] = settings[fb].MaxOpenReviews
+		}
+
+		picked, err := s.assigner.pickWithFallbacks(ctx, assignmentRequest{
+			TeamName:        p.teamName,
+			AuthorID:        p.authorID,
+			Members:         onlyUsers(members[p.teamName], only),
+			Exclude:         exclude,
+			Conflicts:       conflicts[p.authorID],
+			Away:            away,
+			Loads:           loads,
+			TeamReviewLimit: settings[p.teamName].MaxOpenReviews,
+			OtherTeamLimits: otherLimits,
+			PairHistory:     history[p.authorID],
+			Count:           need,
+			Stage:           "top_up",
+			Cursors:         cursors,
+		}, fallbacks)
+		if err != nil {
+			return inserted, filled, err
+		}
+		// кандидатов не нашлось - PR остаётся недоукомплектованным, пустую трассировку не пишем
+		if len(picked.Reviewers) == 0 {
+			continue
+		}
+
+		if err := saveAssignmentTraceTx(ctx, tx, &entity.AssignmentTrace{
+			PullRequestID: p.id,
+			Action:        entity.TraceActionTopUp,
+			Reviewers:     picked.Reviewers,
+			Steps:         picked.Trace,
+			CreatedAt:     s.assigner.clock.Now(),
+		}); err != nil {
+			return inserted, filled, err
+		}
+
+		fromFallback := idSet(picked.Fallback)
+		for _, reviewerID := range picked.Reviewers {
+			_, isFallback := fromFallback[reviewerID]
+			if _, err := tx.Exec(ctx, `
+					INSERT INTO pr_reviewers (pull_request_id, reviewer_id, from_fallback)
+					VALUES ($1, $2, $3)
+			`, p.id, reviewerID, isFallback); err != nil {
+				return inserted, filled, err
+			}
+			inserted++
+			// учитываем новое назначение чтобы следующие PR не достались тому же человеку
+			loads[reviewerID]++
+		}
+		filled = append(filled, TopUpPullRequest{PullRequestID: p.id, NewReviewers: picked.Reviewers})
+	}
+
+	return inserted, filled, nil
+}
+
+// onlyUsers оставляет из users тех, кто есть в only, nil - всех
+func onlyUsers(users []*entity.User, only map[string]struct{}) []*entity.User {
+	if only == nil {
+		return users
+	}
+	var out []*entity.User
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		if _, ok := only[u.ID]; ok {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// loadPairHistoryTx загружает историю пар автор/ревьювер за окно по каждому автору,
+// закрытые PR и черновики не учитываются, как в GetPairHistory
+func (s *teamMaintenanceServiceImpl) loadPairHistoryTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	authorSet map[string]struct{},
+) (map[string]map[string]entity.ReviewPairHistory, error) {
+	out := make(map[string]map[string]entity.ReviewPairHistory)
+	since, ok := s.assigner.pairHistorySince()
+	if !ok {
+		return out, nil
+	}
+
+	authorIDs := make([]string, 0, len(authorSet))
+	for id := range authorSet {
+		authorIDs = append(authorIDs, id)
+	}
+
+	rows, err := tx.Query(ctx, `
+			SELECT pr.author_id, prr.reviewer_id, COUNT(*), MAX(pr.created_at)
+			FROM pr_reviewers prr
+			JOIN pull_requests pr ON pr.id = prr.pull_request_id
+			WHERE pr.author_id = ANY($1)
+				AND pr.created_at >= $2
+				AND pr.status IN ('OPEN', 'MERGED')
+			GROUP BY pr.author_id, prr.reviewer_id
+	`, authorIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var authorID, reviewerID string
+		var h entity.ReviewPairHistory
+		if err := rows.Scan(&authorID, &reviewerID, &h.Reviews, &h.LastAt); err != nil {
+			return nil, err
+		}
+		if out[authorID] == nil {
+			out[authorID] = make(map[string]entity.ReviewPairHistory)
+		}
+		out[authorID][reviewerID] = h
+	}
+	return out, rows.Err()
+}
+
+// loadConflictsTx загружает по каждому автору пользователей в конфликте интересов с ним
+func loadConflictsTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	authorSet map[string]struct{},
+) (map[string]map[string]struct{}, error) {
+	authorIDs := make([]string, 0, len(authorSet))
+	for id := range authorSet {
+		authorIDs = append(authorIDs, id)
+	}
+
+	rows, err := tx.Query(ctx, `
+			SELECT user_id, other_user_id FROM review_conflicts WHERE user_id = ANY($1)
+			UNION
+			SELECT other_user_id, user_id FROM review_conflicts WHERE other_user_id = ANY($1)
+	`, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]map[string]struct{})
+	for rows.Next() {
+		var authorID, userID string
+		if err := rows.Scan(&authorID, &userID); err != nil {
+			return nil, err
+		}
+		if out[authorID] == nil {
+			out[authorID] = make(map[string]struct{})
+		}
+		out[authorID][userID] = struct{}{}
+	}
+	return out, rows.Err()
+}
+
+func saveAssignmentTraceTx(ctx context.Context, tx pgx.Tx, trace *entity.AssignmentTrace) error {
+	reviewers := trace.Reviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	steps := trace.Steps
+	if steps == nil {
+		steps = []entity.AssignmentTraceStep{}
+	}
+	_, err := tx.Exec(ctx, `
+			INSERT INTO assignment_traces (pull_request_id, action, reviewers, steps, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+	`, trace.PullRequestID, string(trace.Action), reviewers, steps, trace.CreatedAt)
+	return err
+}
+
+func loadReviewersTx(ctx context.Context, tx pgx.Tx, prIDs []string) (map[string][]string, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT pull_request_id, reviewer_id
+			FROM pr_reviewers
+			WHERE pull_request_id = ANY($1)
+	`, prIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]string)
+	for rows.Next() {
+		var prID, reviewerID string
+		if err := rows.Scan(&prID, &reviewerID); err != nil {
+			return nil, err
+		}
+		out[prID] = append(out[prID], reviewerID)
+	}
+	return out, rows.Err()
+}
+
+func loadTeamMembersTx(ctx context.Context, tx pgx.Tx, teamNames []string) (map[string][]*entity.User, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT `+postgresql.UserColumns+`
+			FROM users
+			WHERE team_name = ANY($1)
+	`, teamNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]*entity.User)
+	for rows.Next() {
+		u, err := postgresql.ScanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[u.TeamName] = append(out[u.TeamName], u)
+	}
+	return out, rows.Err()
+}
+
+func loadOpenReviewCountsTx(ctx context.Context, tx pgx.Tx, teamNames []string) (map[string]int, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT prr.reviewer_id, COUNT(*)
+			FROM pr_reviewers prr
+			JOIN pull_requests pr ON pr.id = prr.pull_request_id
+			JOIN users u ON u.id = prr.reviewer_id
+			WHERE pr.status = 'OPEN'
+				AND u.team_name = ANY($1)
+			GROUP BY prr.reviewer_id
+	`, teamNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var reviewerID string
+		var cnt int
+		if err := rows.Scan(&reviewerID, &cnt); err != nil {
+			return nil, err
+		}
+		out[reviewerID] = cnt
+	}
+	return out, rows.Err()
+}
+
+func loadTeamSettingsTx(ctx context.Context, tx pgx.Tx, teamNames []string) (map[string]*entity.TeamSettings, error) {
+	out := make(map[string]*entity.TeamSettings, len(teamNames))
+	for _, name := range teamNames {
+		out[name] = entity.DefaultTeamSettings(name)
+	}
+
+	rows, err := tx.Query(ctx, `
+			SELECT team_name, min_reviewers, max_reviewers, max_open_reviews,
+			       min_senior_reviewers, min_junior_reviewers, fallback_teams
+			FROM team_settings
+			WHERE team_name = ANY($1)
+	`, teamNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st entity.TeamSettings
+		if err := rows.Scan(
+			&st.TeamName, &st.MinReviewers, &st.MaxReviewers, &st.MaxOpenReviews,
+			&st.MinSeniorReviewers, &st.MinJuniorReviewers, &st.FallbackTeams,
+		); err != nil {
+			return nil, err
+		}
+		out[st.TeamName] = &st
+	}
+	return out, rows.Err()
+}
+
+func loadAwayUsersTx(ctx context.Context, tx pgx.Tx, teamNames []string, at time.Time) (map[string]struct{}, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT DISTINCT ooo.user_id
+			FROM out_of_office ooo
+			JOIN users u ON u.id = ooo.user_id
+			WHERE u.team_name = ANY($1)
+				AND ooo.starts_at <= $2
+				AND ooo.ends_at > $2
+	`, teamNames, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = struct{}{}
+	}
+	return out, rows.Err()
+}
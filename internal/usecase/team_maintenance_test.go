@@ -1,107 +1,581 @@
-package usecase
-
-import (
-	"context"
-	"testing"
-	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/stretchr/testify/require"
-
-	"github.com/vandermeer0/pr-reviewer/internal/config"
-	"github.com/vandermeer0/pr-reviewer/internal/infrastructure/repository/postgresql"
-)
-
-func newTestPool(t *testing.T) *pgxpool.Pool {
-	t.Helper()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	t.Cleanup(cancel)
-
-	cfg := config.Load()
-	pool, err := postgresql.NewPool(ctx, cfg.DB.ConnString())
-	require.NoError(t, err)
-	t.Cleanup(func() {
-		pool.Close()
-	})
-
-	return pool
-}
-
-func TestTeamMaintenance_Deactivate_NoPRs(t *testing.T) {
-	ctx := context.Background()
-	pool := newTestPool(t)
-
-	_, err := pool.Exec(ctx, `
-		INSERT INTO teams (name) VALUES ('tm_team1');
-		INSERT INTO users (id, username, team_name, is_active) VALUES
-			('tm_u1', 'Alice', 'tm_team1', TRUE),
-			('tm_u2', 'Bob',   'tm_team1', TRUE);
-	`)
-	require.NoError(t, err)
-
-	svc := NewTeamMaintenanceService(pool)
-	res, err := svc.DeactivateTeamMembers(ctx, "tm_team1")
-	require.NoError(t, err)
-
-	require.Equal(t, "tm_team1", res.TeamName)
-
-	var activeCount int64
-	err = pool.QueryRow(ctx, `
-		SELECT COUNT(*) FROM users WHERE team_name = 'tm_team1' AND is_active = TRUE;
-	`).Scan(&activeCount)
-	require.NoError(t, err)
-	require.EqualValues(t, 0, activeCount)
-}
-
-func TestTeamMaintenance_Deactivate_WithOpenPRsAndReassign(t *testing.T) {
-	ctx := context.Background()
-	pool := newTestPool(t)
-
-	_, err := pool.Exec(ctx, `
-		INSERT INTO teams (name) VALUES ('tm_authors'), ('tm_reviewers');
-
-		INSERT INTO users (id, username, team_name, is_active) VALUES
-			('tm_a1', 'Author1', 'tm_authors', TRUE),
-			('tm_a2', 'Author2', 'tm_authors', TRUE),
-			('tm_r1', 'Rev1',    'tm_reviewers', TRUE),
-			('tm_r2', 'Rev2',    'tm_reviewers', TRUE);
-
-		INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at) VALUES
-			('tm_pr_open_1', 'Open 1', 'tm_a1', 'OPEN',   NOW(), NULL),
-			('tm_pr_open_2', 'Open 2', 'tm_a2', 'OPEN',   NOW(), NULL),
-			('tm_pr_merged', 'Merged', 'tm_a1', 'MERGED', NOW(), NOW());
-
-		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
-			('tm_pr_open_1',  'tm_r1'),
-			('tm_pr_open_1',  'tm_r2'),
-			('tm_pr_open_2',  'tm_r1'),
-			('tm_pr_merged',  'tm_r1');
-	`)
-	require.NoError(t, err)
-
-	svc := NewTeamMaintenanceService(pool)
-	res, err := svc.DeactivateTeamMembers(ctx, "tm_reviewers")
-	require.NoError(t, err)
-
-	require.Equal(t, "tm_reviewers", res.TeamName)
-
-	var activeReviewersTeamCount int64
-	err = pool.QueryRow(ctx, `
-		SELECT COUNT(*) FROM users WHERE team_name = 'tm_reviewers' AND is_active = TRUE;
-	`).Scan(&activeReviewersTeamCount)
-	require.NoError(t, err)
-	require.EqualValues(t, 0, activeReviewersTeamCount)
-
-	var badAssignments int64
-	err = pool.QueryRow(ctx, `
-		SELECT COUNT(*)
-		FROM pr_reviewers r
-		JOIN users u ON u.id = r.reviewer_id
-		JOIN pull_requests p ON p.id = r.pull_request_id
-		WHERE u.team_name = 'tm_reviewers'
-		    AND p.status = 'OPEN';
-	`).Scan(&badAssignments)
-	require.NoError(t, err)
-	require.EqualValues(t, 0, badAssignments)
-}
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vandermeer0/pr-reviewer/internal/config"
+	"github.com/vandermeer0/pr-reviewer/internal/infrastructure/repository/postgresql"
+)
+
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	pool, err := postgresql.NewPool(ctx, cfg.DB.ConnString())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.Close()
+	})
+
+	return pool
+}
+
+func TestTeamMaintenance_Deactivate_NoPRs(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_team1');
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_u1', 'Alice', 'tm_team1', TRUE),
+			('tm_u2', 'Bob',   'tm_team1', TRUE);
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.DeactivateTeamMembers(ctx, "tm_team1")
+	require.NoError(t, err)
+
+	require.Equal(t, "tm_team1", res.TeamName)
+
+	var activeCount int64
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM users WHERE team_name = 'tm_team1' AND is_active = TRUE;
+	`).Scan(&activeCount)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, activeCount)
+}
+
+func TestTeamMaintenance_Deactivate_WithOpenPRsAndReassign(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_authors'), ('tm_reviewers');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_a1', 'Author1', 'tm_authors', TRUE),
+			('tm_a2', 'Author2', 'tm_authors', TRUE),
+			('tm_r1', 'Rev1',    'tm_reviewers', TRUE),
+			('tm_r2', 'Rev2',    'tm_reviewers', TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at) VALUES
+			('tm_pr_open_1', 'Open 1', 'tm_a1', 'OPEN',   NOW(), NULL),
+			('tm_pr_open_2', 'Open 2', 'tm_a2', 'OPEN',   NOW(), NULL),
+			('tm_pr_merged', 'Merged', 'tm_a1', 'MERGED', NOW(), NOW());
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_pr_open_1',  'tm_r1'),
+			('tm_pr_open_1',  'tm_r2'),
+			('tm_pr_open_2',  'tm_r1'),
+			('tm_pr_merged',  'tm_r1');
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.DeactivateTeamMembers(ctx, "tm_reviewers")
+	require.NoError(t, err)
+
+	require.Equal(t, "tm_reviewers", res.TeamName)
+
+	var activeReviewersTeamCount int64
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM users WHERE team_name = 'tm_reviewers' AND is_active = TRUE;
+	`).Scan(&activeReviewersTeamCount)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, activeReviewersTeamCount)
+
+	var badAssignments int64
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM pr_reviewers r
+		JOIN users u ON u.id = r.reviewer_id
+		JOIN pull_requests p ON p.id = r.pull_request_id
+		WHERE u.team_name = 'tm_reviewers'
+		    AND p.status = 'OPEN';
+	`).Scan(&badAssignments)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, badAssignments)
+}
+
+func TestTeamMaintenance_Deactivate_LeastLoadedSpreadsTopUp(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_ll_authors'), ('tm_ll_leavers');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_ll_a',  'Author', 'tm_ll_authors', TRUE),
+			('tm_ll_c1', 'Cand1',  'tm_ll_authors', TRUE),
+			('tm_ll_c2', 'Cand2',  'tm_ll_authors', TRUE),
+			('tm_ll_c3', 'Cand3',  'tm_ll_authors', TRUE),
+			('tm_ll_c4', 'Cand4',  'tm_ll_authors', TRUE),
+			('tm_ll_r1', 'Rev1',   'tm_ll_leavers', TRUE),
+			('tm_ll_r2', 'Rev2',   'tm_ll_leavers', TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at) VALUES
+			('tm_ll_pr1', 'PR 1', 'tm_ll_a', 'OPEN', NOW(), NULL),
+			('tm_ll_pr2', 'PR 2', 'tm_ll_a', 'OPEN', NOW(), NULL);
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_ll_pr1', 'tm_ll_r1'),
+			('tm_ll_pr1', 'tm_ll_r2'),
+			('tm_ll_pr2', 'tm_ll_r1'),
+			('tm_ll_pr2', 'tm_ll_r2');
+	`)
+	require.NoError(t, err)
+
+	selectors, err := NewReviewerSelectors("least_loaded", nil)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool, WithReviewerSelectors(selectors))
+	res, err := svc.DeactivateTeamMembers(ctx, "tm_ll_leavers")
+	require.NoError(t, err)
+	require.EqualValues(t, 4, res.NewAssignments)
+
+	rows, err := pool.Query(ctx, `
+		SELECT reviewer_id, COUNT(*)
+		FROM pr_reviewers
+		WHERE pull_request_id IN ('tm_ll_pr1', 'tm_ll_pr2')
+		GROUP BY reviewer_id
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	perReviewer := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var cnt int64
+		require.NoError(t, rows.Scan(&id, &cnt))
+		perReviewer[id] = cnt
+	}
+	require.NoError(t, rows.Err())
+
+	require.Equal(t, map[string]int64{
+		"tm_ll_c1": 1,
+		"tm_ll_c2": 1,
+		"tm_ll_c3": 1,
+		"tm_ll_c4": 1,
+	}, perReviewer)
+}
+
+func TestTeamMaintenance_Deactivate_TopUpFromFallbackTeam(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_fb_home'), ('tm_fb_spare');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_fb_a',  'Author', 'tm_fb_home',  TRUE),
+			('tm_fb_r1', 'Rev1',   'tm_fb_home',  TRUE),
+			('tm_fb_s1', 'Spare1', 'tm_fb_spare', TRUE);
+
+		INSERT INTO team_settings (team_name, min_reviewers, max_reviewers, fallback_teams) VALUES
+			('tm_fb_home', 0, 1, ARRAY['tm_fb_spare']);
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_fb_pr', 'PR', 'tm_fb_a', 'OPEN', 1, NOW(), NULL);
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_fb_pr', 'tm_fb_r1');
+	`)
+	require.NoError(t, err)
+
+	// деактивируем команду автора целиком: добрать можно только из запасной
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.DeactivateTeamMembers(ctx, "tm_fb_home")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.NewAssignments)
+
+	var reviewerID string
+	var fromFallback bool
+	err = pool.QueryRow(ctx, `
+		SELECT reviewer_id, from_fallback FROM pr_reviewers WHERE pull_request_id = 'tm_fb_pr'
+	`).Scan(&reviewerID, &fromFallback)
+	require.NoError(t, err)
+	require.Equal(t, "tm_fb_s1", reviewerID)
+	require.True(t, fromFallback)
+}
+
+func TestTeamMaintenance_Deactivate_TopUpSkipsConflicts(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_coi_home'), ('tm_coi_leavers');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_coi_a',    'Author',  'tm_coi_home',    TRUE),
+			('tm_coi_boss', 'Manager', 'tm_coi_home',    TRUE),
+			('tm_coi_c1',   'Cand1',   'tm_coi_home',    TRUE),
+			('tm_coi_r1',   'Rev1',    'tm_coi_leavers', TRUE);
+
+		INSERT INTO team_settings (team_name, min_reviewers, max_reviewers) VALUES
+			('tm_coi_home', 0, 2);
+
+		INSERT INTO review_conflicts (user_id, other_user_id) VALUES
+			('tm_coi_boss', 'tm_coi_a');
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_coi_pr', 'PR', 'tm_coi_a', 'OPEN', 2, NOW(), NULL);
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_coi_pr', 'tm_coi_r1');
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.DeactivateTeamMembers(ctx, "tm_coi_leavers")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, res.NewAssignments)
+
+	var reviewerID string
+	err = pool.QueryRow(ctx, `
+		SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = 'tm_coi_pr'
+	`).Scan(&reviewerID)
+	require.NoError(t, err)
+	require.Equal(t, "tm_coi_c1", reviewerID)
+}
+
+func TestTeamMaintenance_HandoffReviews(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_ho_authors'), ('tm_ho_reviewers');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_ho_a',    'Author', 'tm_ho_authors',   TRUE),
+			('tm_ho_u',    'Leaver', 'tm_ho_reviewers', TRUE),
+			('tm_ho_m',    'Mate',   'tm_ho_reviewers', TRUE),
+			('tm_ho_t',    'Target', 'tm_ho_authors',   TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_ho_pr1', 'PR 1', 'tm_ho_a', 'OPEN',   1, NOW(), NULL),
+			('tm_ho_pr2', 'PR 2', 'tm_ho_a', 'OPEN',   2, NOW(), NULL),
+			('tm_ho_pr3', 'PR 3', 'tm_ho_t', 'OPEN',   1, NOW(), NULL),
+			('tm_ho_pr4', 'PR 4', 'tm_ho_a', 'MERGED', 1, NOW(), NOW());
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_ho_pr1', 'tm_ho_u'),
+			('tm_ho_pr2', 'tm_ho_u'),
+			('tm_ho_pr2', 'tm_ho_m'),
+			('tm_ho_pr3', 'tm_ho_u'),
+			('tm_ho_pr4', 'tm_ho_u');
+
+		INSERT INTO pr_shadow_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_ho_pr1', 'tm_ho_t');
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+
+	t.Run("explicit target", func(t *testing.T) {
+		res, err := svc.HandoffReviews(ctx, ReviewHandoffInput{UserID: "tm_ho_u", TargetUserID: "tm_ho_t"})
+		require.NoError(t, err)
+		require.False(t, res.Deactivated)
+		require.Len(t, res.PullRequests, 3)
+
+		require.Equal(t, "tm_ho_t", res.PullRequests[0].NewReviewerID)
+		require.Equal(t, "tm_ho_t", res.PullRequests[1].NewReviewerID)
+		// автор PR 3 - сам получатель
+		require.Equal(t, "tm_ho_pr3", res.PullRequests[2].PullRequestID)
+		require.NotNil(t, res.PullRequests[2].Err)
+		require.Equal(t, ErrorCodeInvalidInput, res.PullRequests[2].Err.Code)
+
+		// получатель был теневым ревьювером PR 1 и остаётся только обычным
+		var shadows int
+		err = pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM pr_shadow_reviewers WHERE pull_request_id = 'tm_ho_pr1'
+		`).Scan(&shadows)
+		require.NoError(t, err)
+		require.Zero(t, shadows)
+	})
+
+	t.Run("strategy with failures and deactivation", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `
+			UPDATE pr_reviewers SET reviewer_id = 'tm_ho_u'
+			WHERE reviewer_id = 'tm_ho_t' AND pull_request_id IN ('tm_ho_pr1', 'tm_ho_pr2');
+		`)
+		require.NoError(t, err)
+
+		res, err := svc.HandoffReviews(ctx, ReviewHandoffInput{UserID: "tm_ho_u", Deactivate: true})
+		require.NoError(t, err)
+		require.False(t, res.Deactivated)
+		require.True(t, res.DeactivationSkipped)
+		require.Len(t, res.PullRequests, 3)
+
+		require.Equal(t, "tm_ho_m", res.PullRequests[0].NewReviewerID)
+		// в PR 2 единственный кандидат уже ревьювер
+		require.Equal(t, ErrorCodeNoCandidate, res.PullRequests[1].Err.Code)
+		require.Equal(t, "tm_ho_m", res.PullRequests[2].NewReviewerID)
+
+		// PR 2 остался за пользователем, поэтому деактивация пропущена
+		var isActive bool
+		err = pool.QueryRow(ctx, `SELECT is_active FROM users WHERE id = 'tm_ho_u'`).Scan(&isActive)
+		require.NoError(t, err)
+		require.True(t, isActive)
+
+		var left int
+		err = pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM pr_reviewers WHERE reviewer_id = 'tm_ho_u'
+		`).Scan(&left)
+		require.NoError(t, err)
+		// PR 2 без замены и смерженный PR 4
+		require.Equal(t, 2, left)
+	})
+
+	t.Run("deactivation after full handoff", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `
+			INSERT INTO users (id, username, team_name, is_active) VALUES
+				('tm_ho_m2', 'Mate 2', 'tm_ho_reviewers', TRUE);
+		`)
+		require.NoError(t, err)
+
+		res, err := svc.HandoffReviews(ctx, ReviewHandoffInput{UserID: "tm_ho_u", Deactivate: true})
+		require.NoError(t, err)
+		require.True(t, res.Deactivated)
+		require.False(t, res.DeactivationSkipped)
+		require.Len(t, res.PullRequests, 1)
+		require.Equal(t, "tm_ho_m2", res.PullRequests[0].NewReviewerID)
+
+		var isActive bool
+		err = pool.QueryRow(ctx, `SELECT is_active FROM users WHERE id = 'tm_ho_u'`).Scan(&isActive)
+		require.NoError(t, err)
+		require.False(t, isActive)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var de *DomainError
+
+		_, err := svc.HandoffReviews(ctx, ReviewHandoffInput{UserID: "tm_ho_ghost"})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeNotFound, de.Code)
+
+		_, err = svc.HandoffReviews(ctx, ReviewHandoffInput{UserID: "tm_ho_m", TargetUserID: "tm_ho_u"})
+		require.ErrorAs(t, err, &de)
+		require.Equal(t, ErrorCodeUserInactive, de.Code)
+	})
+}
+
+func TestTeamMaintenance_DeactivateUser(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_du_team');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_du_a',  'Author', 'tm_du_team', TRUE),
+			('tm_du_u',  'Leaver', 'tm_du_team', TRUE),
+			('tm_du_c1', 'Cand1',  'tm_du_team', TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_du_open',   'Open',   'tm_du_a', 'OPEN',   1, NOW(), NULL),
+			('tm_du_merged', 'Merged', 'tm_du_a', 'MERGED', 1, NOW(), NOW());
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_du_open',   'tm_du_u'),
+			('tm_du_merged', 'tm_du_u');
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.DeactivateUser(ctx, "tm_du_u")
+	require.NoError(t, err)
+	require.False(t, res.User.IsActive)
+	require.EqualValues(t, 1, res.RemovedAssignments)
+	require.EqualValues(t, 1, res.NewAssignments)
+	require.Equal(t, 1, res.AffectedPullRequests)
+
+	var reviewerID string
+	err = pool.QueryRow(ctx, `
+		SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = 'tm_du_open'
+	`).Scan(&reviewerID)
+	require.NoError(t, err)
+	require.Equal(t, "tm_du_c1", reviewerID)
+
+	// смерженные PR не трогаются
+	err = pool.QueryRow(ctx, `
+		SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = 'tm_du_merged'
+	`).Scan(&reviewerID)
+	require.NoError(t, err)
+	require.Equal(t, "tm_du_u", reviewerID)
+
+	var de *DomainError
+	_, err = svc.DeactivateUser(ctx, "tm_du_ghost")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestTeamMaintenance_RebalanceTeam(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_rb_authors'), ('tm_rb_team');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_rb_a',    'Author', 'tm_rb_authors', TRUE),
+			('tm_rb_busy', 'Busy',   'tm_rb_team',    TRUE),
+			('tm_rb_idle', 'Idle',   'tm_rb_team',    TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at) VALUES
+			('tm_rb_pr1', 'PR 1', 'tm_rb_a',    'OPEN', NOW(), NULL),
+			('tm_rb_pr2', 'PR 2', 'tm_rb_a',    'OPEN', NOW(), NULL),
+			('tm_rb_pr3', 'PR 3', 'tm_rb_a',    'OPEN', NOW(), NULL),
+			('tm_rb_pr4', 'PR 4', 'tm_rb_idle', 'OPEN', NOW(), NULL);
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id, from_fallback) VALUES
+			('tm_rb_pr1', 'tm_rb_busy', TRUE),
+			('tm_rb_pr2', 'tm_rb_busy', TRUE),
+			('tm_rb_pr3', 'tm_rb_busy', TRUE),
+			('tm_rb_pr4', 'tm_rb_busy', FALSE);
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+
+	countOf := func(userID string) int {
+		var n int
+		err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM pr_reviewers WHERE reviewer_id = $1`, userID).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+
+	plan, err := svc.RebalanceTeam(ctx, "tm_rb_team", RebalanceOptions{Tolerance: 1, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, 4, plan.SpreadBefore)
+	require.Equal(t, 0, plan.SpreadAfter)
+	require.Len(t, plan.Moves, 2)
+	for _, m := range plan.Moves {
+		// idle - автор PR 4
+		require.NotEqual(t, "tm_rb_pr4", m.PullRequestID)
+	}
+	require.Equal(t, 4, countOf("tm_rb_busy"))
+
+	res, err := svc.RebalanceTeam(ctx, "tm_rb_team", RebalanceOptions{Tolerance: 1})
+	require.NoError(t, err)
+	require.Equal(t, plan.Moves, res.Moves)
+	require.Equal(t, 2, countOf("tm_rb_busy"))
+	require.Equal(t, 2, countOf("tm_rb_idle"))
+
+	// tm_rb_team не запасная команда авторов, перенесённые назначения не запасные
+	var fallbacks int
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM pr_reviewers WHERE reviewer_id = 'tm_rb_idle' AND from_fallback
+	`).Scan(&fallbacks)
+	require.NoError(t, err)
+	require.Zero(t, fallbacks)
+
+	prRepo := postgresql.NewPullRequestRepository(pool)
+	traces, err := prRepo.ListAssignmentTraces(ctx, res.Moves[0].PullRequestID)
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0].Steps, 1)
+	step := traces[0].Steps[0]
+	require.Equal(t, "rebalance", step.Stage)
+	require.Equal(t, []string{"tm_rb_idle"}, step.Selected)
+	require.Equal(t, "tm_rb_busy", step.Candidates[0].UserID)
+	require.Equal(t, 4, step.Candidates[0].OpenReviews)
+
+	var de *DomainError
+	_, err = svc.RebalanceTeam(ctx, "tm_rb_ghosts", RebalanceOptions{})
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestTeamMaintenance_ActivateUser_TopsUpUnderstaffed(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_au_team');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_au_a',    'Author',   'tm_au_team', TRUE),
+			('tm_au_r',    'Reviewer', 'tm_au_team', TRUE),
+			('tm_au_back', 'Back',     'tm_au_team', FALSE),
+			('tm_au_idle', 'Idle',     'tm_au_team', FALSE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_au_short',  'Short',  'tm_au_a', 'OPEN',   2, NOW(), NULL),
+			('tm_au_full',   'Full',   'tm_au_a', 'OPEN',   1, NOW(), NULL),
+			('tm_au_merged', 'Merged', 'tm_au_a', 'MERGED', 2, NOW(), NOW());
+
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES
+			('tm_au_short',  'tm_au_r'),
+			('tm_au_full',   'tm_au_r'),
+			('tm_au_merged', 'tm_au_r');
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.ActivateUser(ctx, "tm_au_back")
+	require.NoError(t, err)
+	require.True(t, res.User.IsActive)
+	require.EqualValues(t, 1, res.NewAssignments)
+	require.Equal(t, []TopUpPullRequest{{PullRequestID: "tm_au_short", NewReviewers: []string{"tm_au_back"}}}, res.PullRequests)
+
+	// неактивный участник команды не добирается, даже если PR недоукомплектован
+	var n int
+	err = pool.QueryRow(ctx, `SELECT COUNT(*) FROM pr_reviewers WHERE reviewer_id = 'tm_au_idle'`).Scan(&n)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	var de *DomainError
+	_, err = svc.ActivateUser(ctx, "tm_au_ghost")
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+}
+
+func TestTeamMaintenance_AddTeamMembers(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t)
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO teams (name) VALUES ('tm_am_team');
+
+		INSERT INTO users (id, username, team_name, is_active) VALUES
+			('tm_am_a', 'Author', 'tm_am_team', TRUE);
+
+		INSERT INTO pull_requests (id, name, author_id, status, target_reviewers, created_at, merged_at) VALUES
+			('tm_am_pr1', 'PR 1', 'tm_am_a', 'OPEN', 2, NOW(), NULL),
+			('tm_am_pr2', 'PR 2', 'tm_am_a', 'OPEN', 1, NOW(), NULL);
+	`)
+	require.NoError(t, err)
+
+	svc := NewTeamMaintenanceService(pool)
+	res, err := svc.AddTeamMembers(ctx, "tm_am_team", []CreateTeamMemberInput{
+		{UserID: "tm_am_new", Username: "New", IsActive: true},
+		{UserID: "tm_am_off", Username: "Off", IsActive: false},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Members, 2)
+	require.Equal(t, "tm_am_team", res.Members[0].TeamName)
+	require.EqualValues(t, 2, res.NewAssignments)
+	require.Equal(t, []TopUpPullRequest{
+		{PullRequestID: "tm_am_pr1", NewReviewers: []string{"tm_am_new"}},
+		{PullRequestID: "tm_am_pr2", NewReviewers: []string{"tm_am_new"}},
+	}, res.PullRequests)
+
+	var de *DomainError
+	_, err = svc.AddTeamMembers(ctx, "tm_am_ghost", []CreateTeamMemberInput{{UserID: "tm_am_x", Username: "X", IsActive: true}})
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeNotFound, de.Code)
+
+	_, err = svc.AddTeamMembers(ctx, "tm_am_team", nil)
+	require.ErrorAs(t, err, &de)
+	require.Equal(t, ErrorCodeInvalidInput, de.Code)
+}
@@ -1,4 +1,4 @@
-DROP TABLE IF EXISTS pr_reviewers;
-DROP TABLE IF EXISTS pull_requests;
-DROP TABLE IF EXISTS users;
-DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS pr_reviewers;
+DROP TABLE IF EXISTS pull_requests;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS teams;
@@ -1,31 +1,31 @@
-CREATE TABLE teams (
-    name TEXT PRIMARY KEY
-);
-
-CREATE TABLE users (
-    id TEXT PRIMARY KEY,
-    username TEXT NOT NULL,
-    team_name TEXT NOT NULL REFERENCES teams(name),
-    is_active BOOLEAN NOT NULL
-);
-
-CREATE INDEX idx_users_team_active
-    ON users (team_name, is_active);
-
-CREATE TABLE pull_requests (
-    id TEXT PRIMARY KEY,
-    name TEXT NOT NULL,
-    author_id TEXT NOT NULL REFERENCES users(id),
-    status TEXT NOT NULL,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    merged_at TIMESTAMPTZ
-);
-
-CREATE TABLE pr_reviewers (
-    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
-    reviewer_id TEXT NOT NULL REFERENCES users(id),
-    PRIMARY KEY (pull_request_id, reviewer_id)
-);
-
-CREATE INDEX idx_pr_reviewers_reviewer
-    ON pr_reviewers (reviewer_id);
+CREATE TABLE teams (
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    team_name TEXT NOT NULL REFERENCES teams(name),
+    is_active BOOLEAN NOT NULL
+);
+
+CREATE INDEX idx_users_team_active
+    ON users (team_name, is_active);
+
+CREATE TABLE pull_requests (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    author_id TEXT NOT NULL REFERENCES users(id),
+    status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    merged_at TIMESTAMPTZ
+);
+
+CREATE TABLE pr_reviewers (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    reviewer_id TEXT NOT NULL REFERENCES users(id),
+    PRIMARY KEY (pull_request_id, reviewer_id)
+);
+
+CREATE INDEX idx_pr_reviewers_reviewer
+    ON pr_reviewers (reviewer_id);
@@ -0,0 +1,8 @@
+CREATE TABLE team_settings (
+    team_name TEXT PRIMARY KEY REFERENCES teams(name) ON DELETE CASCADE,
+    min_reviewers INTEGER NOT NULL DEFAULT 0,
+    max_reviewers INTEGER NOT NULL DEFAULT 2,
+    CHECK (min_reviewers >= 0),
+    CHECK (max_reviewers >= 1),
+    CHECK (min_reviewers <= max_reviewers)
+);
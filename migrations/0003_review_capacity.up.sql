@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN max_open_reviews INTEGER CHECK (max_open_reviews >= 0);
+
+ALTER TABLE team_settings
+    ADD COLUMN max_open_reviews INTEGER CHECK (max_open_reviews >= 0);
+
+ALTER TABLE pull_requests
+    ADD COLUMN target_reviewers INTEGER NOT NULL DEFAULT 2;
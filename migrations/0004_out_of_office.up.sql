@@ -0,0 +1,11 @@
+CREATE TABLE out_of_office (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_out_of_office_user_period
+    ON out_of_office (user_id, starts_at, ends_at);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS work_days,
+    DROP COLUMN IF EXISTS work_end_minute,
+    DROP COLUMN IF EXISTS work_start_minute,
+    DROP COLUMN IF EXISTS timezone;
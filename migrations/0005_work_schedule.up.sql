@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN work_start_minute INTEGER CHECK (work_start_minute BETWEEN 0 AND 1439),
+    ADD COLUMN work_end_minute INTEGER CHECK (work_end_minute BETWEEN 0 AND 1439),
+    ADD COLUMN work_days SMALLINT CHECK (work_days BETWEEN 1 AND 127);
@@ -0,0 +1,7 @@
+CREATE TABLE ownership_rules (
+    id BIGSERIAL PRIMARY KEY,
+    pattern TEXT NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    team_name TEXT REFERENCES teams(name) ON DELETE CASCADE,
+    CHECK ((user_id IS NULL) <> (team_name IS NULL))
+);
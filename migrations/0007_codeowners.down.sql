@@ -0,0 +1,4 @@
+ALTER TABLE pr_reviewers
+    DROP COLUMN IF EXISTS required_pattern;
+
+DROP TABLE IF EXISTS codeowners_rules;
@@ -0,0 +1,8 @@
+CREATE TABLE codeowners_rules (
+    line INTEGER PRIMARY KEY,
+    pattern TEXT NOT NULL,
+    owners TEXT[] NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE pr_reviewers
+    ADD COLUMN required_pattern TEXT;
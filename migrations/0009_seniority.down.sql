@@ -0,0 +1,6 @@
+ALTER TABLE team_settings
+    DROP COLUMN IF EXISTS min_junior_reviewers,
+    DROP COLUMN IF EXISTS min_senior_reviewers;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS seniority;
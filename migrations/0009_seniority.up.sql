@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN seniority TEXT NOT NULL DEFAULT ''
+        CHECK (seniority IN ('', 'junior', 'middle', 'senior'));
+
+ALTER TABLE team_settings
+    ADD COLUMN min_senior_reviewers INTEGER NOT NULL DEFAULT 0 CHECK (min_senior_reviewers >= 0),
+    ADD COLUMN min_junior_reviewers INTEGER NOT NULL DEFAULT 0 CHECK (min_junior_reviewers >= 0);
@@ -0,0 +1,12 @@
+CREATE TABLE assignment_traces (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('create', 'reassign', 'top_up')),
+    replaced_reviewer_id TEXT,
+    reviewers TEXT[] NOT NULL DEFAULT '{}',
+    steps JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_assignment_traces_pull_request
+    ON assignment_traces (pull_request_id);
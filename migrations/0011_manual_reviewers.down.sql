@@ -0,0 +1,7 @@
+DELETE FROM assignment_traces
+WHERE action IN ('add', 'remove');
+
+ALTER TABLE assignment_traces
+    DROP CONSTRAINT assignment_traces_action_check,
+    ADD CONSTRAINT assignment_traces_action_check
+        CHECK (action IN ('create', 'reassign', 'top_up'));
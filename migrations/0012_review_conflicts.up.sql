@@ -0,0 +1,13 @@
+CREATE TABLE review_conflicts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    other_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    CHECK (user_id <> other_user_id)
+);
+
+CREATE UNIQUE INDEX idx_review_conflicts_pair
+    ON review_conflicts (LEAST(user_id, other_user_id), GREATEST(user_id, other_user_id));
+
+CREATE INDEX idx_review_conflicts_other_user
+    ON review_conflicts (other_user_id);
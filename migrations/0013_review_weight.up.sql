@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN review_weight DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (review_weight > 0);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS pr_shadow_reviewers;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_trainee;
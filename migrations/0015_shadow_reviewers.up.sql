@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN is_trainee BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE pr_shadow_reviewers (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    reviewer_id TEXT NOT NULL REFERENCES users(id),
+    PRIMARY KEY (pull_request_id, reviewer_id)
+);
+
+CREATE INDEX idx_pr_shadow_reviewers_reviewer
+    ON pr_shadow_reviewers (reviewer_id);
@@ -0,0 +1,4 @@
+UPDATE pull_requests SET status = 'OPEN' WHERE status = 'CLOSED';
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS closed_at;
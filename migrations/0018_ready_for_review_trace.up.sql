@@ -0,0 +1,4 @@
+ALTER TABLE assignment_traces
+    DROP CONSTRAINT assignment_traces_action_check,
+    ADD CONSTRAINT assignment_traces_action_check
+        CHECK (action IN ('create', 'ready_for_review', 'reassign', 'top_up', 'add', 'remove'));
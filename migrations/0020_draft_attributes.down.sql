@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS draft_changed_files,
+    DROP COLUMN IF EXISTS draft_requested_reviewers,
+    DROP COLUMN IF EXISTS draft_labels;
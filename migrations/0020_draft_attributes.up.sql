@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests
+    ADD COLUMN draft_changed_files TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN draft_requested_reviewers TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN draft_labels TEXT[] NOT NULL DEFAULT '{}';